@@ -0,0 +1,103 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// BalanceTrim3D carves pockets inside sdf to move its centroid towards targetCentroid,
+// as is done to balance spinning parts. The bounding box is divided into cubic cells with
+// samples cells along its longest side and, one at a time, the interior cell farthest
+// from the target on the heavy side is removed while doing so brings the estimated
+// centroid, as returned by Centroid3D, closer to the target. Removed cells are at least a
+// cell away from the surface so pockets are closed and do not change the outside of the part.
+//
+// This is a greedy heuristic: the target is only reached within about a cell size and
+// targets which can not be reached by removing interior material, such as those outside
+// the part, are approached as far as possible. The result is the difference of sdf and
+// the pockets, which are interpolated from the removed cells and are not an exact
+// distance field. sdf is returned unmodified if no cell is removed.
+func BalanceTrim3D(sdf SDF3, targetCentroid r3.Vec, samples int) SDF3 {
+	var mass float64
+	var moment r3.Vec
+	integrate3(sdf, samples, func(p r3.Vec, v float64) {
+		mass += v
+		moment = r3.Add(moment, r3.Scale(v, p))
+	})
+	if mass == 0 {
+		panic("sdf encloses no volume")
+	}
+	bb := d3.Box(sdf.Bounds())
+	size := bb.Size()
+	cell := d3.Max(size) / float64(samples)
+	n := V3i{int(math.Ceil(size.X / cell)), int(math.Ceil(size.Y / cell)), int(math.Ceil(size.Z / cell))}
+	center := func(i, j, k int) r3.Vec {
+		return r3.Add(bb.Min, r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+	}
+	// Cells which can be removed keeping a wall of a cell.
+	wall := 0.5*math.Sqrt(3)*cell + cell
+	var candidates []V3i
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				if sdf.Evaluate(center(i, j, k)) <= -wall {
+					candidates = append(candidates, V3i{i, j, k})
+				}
+			}
+		}
+	}
+	volume := cell * cell * cell
+	removed := make(map[V3i]bool)
+	for len(candidates) > 0 {
+		c := r3.Scale(1/mass, moment)
+		e := r3.Sub(c, targetCentroid)
+		best, bestDot := -1, 0.0
+		for i, idx := range candidates {
+			if dot := r3.Dot(r3.Sub(center(idx[0], idx[1], idx[2]), c), e); dot > bestDot {
+				best, bestDot = i, dot
+			}
+		}
+		if best < 0 || mass-volume <= 0 {
+			break
+		}
+		idx := candidates[best]
+		newMoment := r3.Sub(moment, r3.Scale(volume, center(idx[0], idx[1], idx[2])))
+		newC := r3.Scale(1/(mass-volume), newMoment)
+		if r3.Norm(r3.Sub(newC, targetCentroid)) >= r3.Norm(e) {
+			break
+		}
+		mass -= volume
+		moment = newMoment
+		removed[idx] = true
+		candidates[best] = candidates[len(candidates)-1]
+		candidates = candidates[:len(candidates)-1]
+	}
+	if len(removed) == 0 {
+		return sdf
+	}
+	// Interpolate between cell centers, with a layer of cells around the grid, so
+	// the field of the pockets vanishes halfway between removed and kept cells.
+	res := n.AddScalar(1)
+	half := r3.Scale(0.5, r3.Vec{X: cell, Y: cell, Z: cell})
+	pockets := proxy3{
+		res:    res,
+		cell:   r3.Vec{X: cell, Y: cell, Z: cell},
+		values: make([]float64, (res[0]+1)*(res[1]+1)*(res[2]+1)),
+	}
+	min := r3.Sub(bb.Min, half)
+	pockets.bb = r3.Box{Min: min, Max: r3.Add(min, r3.Scale(cell, R3FromI(res)))}
+	for k := 0; k <= res[2]; k++ {
+		for j := 0; j <= res[1]; j++ {
+			for i := 0; i <= res[0]; i++ {
+				v := cell / 2
+				if removed[V3i{i - 1, j - 1, k - 1}] {
+					v = -v
+				}
+				pockets.values[pockets.index(i, j, k)] = v
+			}
+		}
+	}
+	return Difference3D(sdf, &pockets)
+}
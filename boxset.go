@@ -0,0 +1,124 @@
+package sdf
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// boxSet3 is a union of many axis aligned boxes grown by a radius, stored in
+// a flat slice and accelerated with a bounding volume hierarchy.
+type boxSet3 struct {
+	centers []r3.Vec
+	halves  []r3.Vec // half sizes of the boxes.
+	nodes   []sphereNode
+	round   float64
+	bb      r3.Box
+}
+
+// maximum number of boxes in a bounding volume hierarchy leaf.
+const boxLeafSize = 4
+
+// BoxSet3D returns the union of axis aligned boxes, such as the occupied cells of
+// an occupancy grid or the leaves of an octree, grown by round. Growing rounds the
+// convex edges and corners of the set with radius round while boxes which touch
+// merge into a continuous surface without seams, so the surface lies round outside
+// the boxes. Shrink the boxes beforehand to keep their size.
+//
+// It is equivalent to the Union3D of the boxes but much faster for large numbers
+// of boxes since far away boxes are skipped using a bounding volume hierarchy,
+// built once. The field is exact outside the boxes and a bound inside them where
+// boxes touch or overlap.
+func BoxSet3D(boxes []r3.Box, round float64) (SDF3, error) {
+	if len(boxes) == 0 {
+		return nil, errors.New("no boxes")
+	}
+	if round < 0 || math.IsNaN(round) || math.IsInf(round, 0) {
+		return nil, errors.New("round must be finite and >= 0")
+	}
+	sorted := append([]r3.Box{}, boxes...)
+	for _, b := range sorted {
+		size := d3.Box(b).Size()
+		if !(size.X >= 0 && size.Y >= 0 && size.Z >= 0) || math.IsInf(d3.Max(size), 0) {
+			return nil, errors.New("boxes must be finite with Min <= Max")
+		}
+	}
+	s := boxSet3{round: round}
+	s.build(sorted, 0)
+	s.centers = make([]r3.Vec, len(sorted))
+	s.halves = make([]r3.Vec, len(sorted))
+	for i, b := range sorted {
+		s.centers[i] = d3.Box(b).Center()
+		s.halves[i] = r3.Scale(0.5, d3.Box(b).Size())
+	}
+	s.bb = r3.Box(d3.Box(s.nodes[0].bb).Enlarge(d3.Elem(2 * round)))
+	return &s, nil
+}
+
+// build adds the node of boxes and its descendants to the hierarchy, sorting
+// the boxes so that every node's boxes are contiguous. offset is the index
+// of the first box of boxes in the set. Returns the index of the node.
+func (s *boxSet3) build(boxes []r3.Box, offset int) int {
+	bb := d3.Box(boxes[0])
+	for _, b := range boxes[1:] {
+		bb = bb.Extend(d3.Box(b))
+	}
+	idx := len(s.nodes)
+	s.nodes = append(s.nodes, sphereNode{bb: r3.Box(bb), start: offset, end: offset + len(boxes)})
+	if len(boxes) <= boxLeafSize {
+		return idx
+	}
+	// Split at the median along the longest axis.
+	size := bb.Size()
+	var key func(b r3.Box) float64
+	switch {
+	case size.X >= size.Y && size.X >= size.Z:
+		key = func(b r3.Box) float64 { return b.Min.X + b.Max.X }
+	case size.Y >= size.Z:
+		key = func(b r3.Box) float64 { return b.Min.Y + b.Max.Y }
+	default:
+		key = func(b r3.Box) float64 { return b.Min.Z + b.Max.Z }
+	}
+	sort.Slice(boxes, func(i, j int) bool { return key(boxes[i]) < key(boxes[j]) })
+	half := len(boxes) / 2
+	left := s.build(boxes[:half], offset)
+	right := s.build(boxes[half:], offset+half)
+	s.nodes[idx].left, s.nodes[idx].right = left, right
+	return idx
+}
+
+// Evaluate returns the minimum distance to the box set.
+func (s *boxSet3) Evaluate(p r3.Vec) float64 {
+	d := math.Inf(1)
+	var stack [64]int
+	n := 1 // stack[0] = 0 is the root node.
+	for n > 0 {
+		n--
+		node := &s.nodes[stack[n]]
+		if boxDistance(p, node.bb) >= d {
+			continue
+		}
+		if node.left == 0 {
+			for i := node.start; i < node.end; i++ {
+				d = math.Min(d, sdfBox3d(r3.Sub(p, s.centers[i]), s.halves[i]))
+			}
+			continue
+		}
+		// Visit the closest child first to prune more of the farthest.
+		a, b := node.left, node.right
+		if boxDistance(p, s.nodes[a].bb) < boxDistance(p, s.nodes[b].bb) {
+			a, b = b, a
+		}
+		stack[n], stack[n+1] = a, b
+		n += 2
+	}
+	return d - s.round
+}
+
+// Bounds returns the bounding box of the box set.
+func (s *boxSet3) Bounds() r3.Box {
+	return s.bb
+}
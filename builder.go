@@ -0,0 +1,101 @@
+package sdf
+
+// Builder builds an SDF3 from a sequence of CSG operations applied in order
+// to an accumulated SDF3, which reads like a CSG script instead of nested calls:
+//
+//	var b sdf.Builder
+//	b.Add(body).Add(boss).Subtract(hole).Intersect(stock)
+//	part := b.Build()
+//
+// The first operation must be Add. The zero value is ready to use.
+type Builder struct {
+	ops   []builderOp
+	round float64
+}
+
+type builderOp struct {
+	op  byte // '+' add, '-' subtract or '*' intersect.
+	sdf SDF3
+}
+
+// Add adds s to the accumulated SDF3 (union).
+func (b *Builder) Add(s SDF3) *Builder {
+	return b.push('+', s)
+}
+
+// Subtract removes s from the accumulated SDF3 (difference).
+func (b *Builder) Subtract(s SDF3) *Builder {
+	return b.push('-', s)
+}
+
+// Intersect keeps the part of the accumulated SDF3 within s (intersection).
+func (b *Builder) Intersect(s SDF3) *Builder {
+	return b.push('*', s)
+}
+
+// Round rounds the edges formed by all operations with radius r by
+// blending them with SmoothMin, as SmoothUnion3D does, and SmoothMax.
+// Edges of the SDF3s themselves are not rounded. r<=0 gives sharp edges.
+func (b *Builder) Round(r float64) *Builder {
+	b.round = r
+	return b
+}
+
+func (b *Builder) push(op byte, s SDF3) *Builder {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	if len(b.ops) == 0 && op != '+' {
+		panic("first builder operation must be Add")
+	}
+	b.ops = append(b.ops, builderOp{op: op, sdf: s})
+	return b
+}
+
+// Build returns the SDF3 resulting from the operations. Consecutive
+// additions are combined into a single union.
+func (b *Builder) Build() SDF3 {
+	if len(b.ops) == 0 {
+		panic("no SDF3 added to builder")
+	}
+	k := b.round
+	smax := func(x, y float64) float64 { return SmoothMax(x, y, k) }
+	var acc SDF3
+	for i := 0; i < len(b.ops); i++ {
+		op := b.ops[i]
+		switch op.op {
+		case '+':
+			objects := []SDF3{op.sdf}
+			if acc != nil {
+				objects = []SDF3{acc, op.sdf}
+			}
+			for i+1 < len(b.ops) && b.ops[i+1].op == '+' {
+				i++
+				objects = append(objects, b.ops[i].sdf)
+			}
+			if len(objects) == 1 {
+				acc = objects[0]
+				continue
+			}
+			if k > 0 {
+				// SmoothUnion3D enlarges the bounds to hold the blend.
+				acc = SmoothUnion3D(k, objects...)
+			} else {
+				acc = Union3D(objects...)
+			}
+		case '-':
+			d := Difference3D(acc, op.sdf)
+			if k > 0 {
+				d.SetMax(smax)
+			}
+			acc = d
+		case '*':
+			in := Intersect3D(acc, op.sdf)
+			if k > 0 {
+				in.SetMax(smax)
+			}
+			acc = in
+		}
+	}
+	return acc
+}
@@ -0,0 +1,54 @@
+package sdf_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestBuilder(t *testing.T) {
+	body := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	boss := sdf.Transform3D(must3.Cylinder(1, 0.5, 0), sdf.Translate3D(r3.Vec{Z: 1.5}))
+	knob := sdf.Transform3D(must3.Sphere(0.5), sdf.Translate3D(r3.Vec{X: 1}))
+	hole := must3.Cylinder(4, 0.3, 0)
+	stock := must3.Sphere(1.6)
+
+	var b sdf.Builder
+	b.Add(body).Add(boss).Add(knob).Subtract(hole).Intersect(stock)
+	got := b.Build()
+	want := sdf.Intersect3D(sdf.Difference3D(sdf.Union3D(body, boss, knob), hole), stock)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := r3.Vec{X: 4*rng.Float64() - 2, Y: 4*rng.Float64() - 2, Z: 4*rng.Float64() - 2}
+		if d0, d1 := got.Evaluate(p), want.Evaluate(p); math.Abs(d0-d1) > 1e-12 {
+			t.Fatalf("distance at %v: got %g, want %g", p, d0, d1)
+		}
+	}
+
+	// Rounding fills the concave edge where the boss meets the body.
+	b.Round(0.2)
+	rounded := b.Build()
+	corner := r3.Vec{X: 0.52, Z: 1.02}
+	if d0, d1 := want.Evaluate(corner), rounded.Evaluate(corner); d1 >= 0 || d0 <= 0 {
+		t.Errorf("concave edge not filled: sharp %g, rounded %g", d0, d1)
+	}
+	// The material added by rounding stays within the bounds.
+	var pair sdf.Builder
+	cube := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	pair.Add(cube).Add(sdf.Transform3D(cube, sdf.Translate3D(r3.Vec{X: 1}))).Round(0.4)
+	joined := pair.Build()
+	bb := joined.Bounds()
+	for x := -1.; x <= 2; x += 0.05 {
+		for z := -1.; z <= 1; z += 0.05 {
+			p := r3.Vec{X: x, Z: z}
+			if d := joined.Evaluate(p); d < 0 && !d3.Box(bb).Contains(p) {
+				t.Fatalf("got %g at %v outside bounds %v", d, p, bb)
+			}
+		}
+	}
+}
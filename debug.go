@@ -0,0 +1,30 @@
+package sdf
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// DebugChecks enables checks in the Evaluate methods of unions, differences,
+// intersections and transforms which panic as soon as an SDF3 evaluates to NaN
+// or an infinity, naming the type of the offending SDF3. Without the checks a
+// single bad value, such as from a transform built with NaN, silently spreads
+// through the whole tree and renders as garbage. It is meant for debugging
+// and slows evaluation slightly so it is disabled by default.
+var DebugChecks = false
+
+// checkDistance panics if d, the distance returned by s at p, is not finite.
+func checkDistance(s SDF3, p r3.Vec, d float64) {
+	if math.IsNaN(d) || math.IsInf(d, 0) {
+		panic(fmt.Sprintf("sdf: %T evaluated to %g at %v", s, d, p))
+	}
+}
+
+// checkPoint panics if q, the point s maps p to, is not finite.
+func checkPoint(s SDF3, p, q r3.Vec) {
+	if c := q.X + q.Y + q.Z; math.IsNaN(c) || math.IsInf(c, 0) {
+		panic(fmt.Sprintf("sdf: %T mapped %v to %v", s, p, q))
+	}
+}
@@ -0,0 +1,211 @@
+package sdf
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// SDF3Describer is an SDF3 which can describe its kind and parameters, allowing
+// tools such as editors and exporters to reconstruct an SDF3 tree when used
+// along with SDF3Parent. Parameter names are stable and documented
+// on each Describe method. Vectors are described by their components
+// with _x, _y and _z suffixes.
+type SDF3Describer interface {
+	SDF3
+	Describe() (kind string, params map[string]float64)
+}
+
+// Describe returns kind "union" and no parameters.
+func (s *union3) Describe() (string, map[string]float64) { return "union", map[string]float64{} }
+
+// Describe returns kind "difference" and no parameters.
+func (s *diff3) Describe() (string, map[string]float64) { return "difference", map[string]float64{} }
+
+// Describe returns kind "intersection" and no parameters.
+func (s *intersection3) Describe() (string, map[string]float64) {
+	return "intersection", map[string]float64{}
+}
+
+// Describe returns kind "transform" with the transformation matrix
+// elements m00 through m33, where the first digit is the row.
+func (s *transform3) Describe() (string, map[string]float64) {
+	m := s.matrix
+	return "transform", map[string]float64{
+		"m00": m.x00, "m01": m.x01, "m02": m.x02, "m03": m.x03,
+		"m10": m.x10, "m11": m.x11, "m12": m.x12, "m13": m.x13,
+		"m20": m.x20, "m21": m.x21, "m22": m.x22, "m23": m.x23,
+		"m30": m.x30, "m31": m.x31, "m32": m.x32, "m33": m.x33,
+	}
+}
+
+// Describe returns kind "scale_uniform" with the scale factor "k".
+func (s *scaleUniform3) Describe() (string, map[string]float64) {
+	return "scale_uniform", map[string]float64{"k": s.k}
+}
+
+// Describe returns kind "elongate" with the elongation vector "h".
+func (s *elongate3) Describe() (string, map[string]float64) {
+	return "elongate", vecParams(map[string]float64{}, "h", r3.Sub(s.hp, s.hn))
+}
+
+// Describe returns kind "cut" with the plane "point" and "normal"
+// vectors. The part on the side of the normal remains.
+func (s *cut3) Describe() (string, map[string]float64) {
+	params := vecParams(map[string]float64{}, "point", s.a)
+	return "cut", vecParams(params, "normal", r3.Scale(-1, s.n))
+}
+
+// Describe returns kind "trim" with the number of "planes".
+func (s *trim3) Describe() (string, map[string]float64) {
+	return "trim", map[string]float64{"planes": float64(len(s.planes))}
+}
+
+// Describe returns kind "array" with the number of copies "num"
+// and the "step" between copies.
+func (s *array3) Describe() (string, map[string]float64) {
+	params := vecParams(map[string]float64{}, "step", s.step)
+	return "array", vecParams(params, "num", s.num.ToV3())
+}
+
+// Describe returns kind "rotate_union" with the number of copies "num".
+// The rotation step matrix elements are m00 through m33, as in transforms.
+func (s *rotateUnion) Describe() (string, map[string]float64) {
+	_, params := (&transform3{matrix: s.step.Inverse()}).Describe()
+	params["num"] = float64(s.num)
+	return "rotate_union", params
+}
+
+// Describe returns kind "rotate_copy" with the number of copies "num".
+func (s *rotateCopy3) Describe() (string, map[string]float64) {
+	return "rotate_copy", map[string]float64{"num": math.Round(tau / s.theta)}
+}
+
+// Describe returns kind "symmetrize" with the number of copies "num".
+func (s *symmetrize3) Describe() (string, map[string]float64) {
+	return "symmetrize", map[string]float64{"num": float64(len(s.rot))}
+}
+
+// Describe returns kind "mirror" with the mirror plane "point" and unit "normal" vectors.
+func (s *mirror3) Describe() (string, map[string]float64) {
+	params := vecParams(map[string]float64{}, "point", s.point)
+	return "mirror", vecParams(params, "normal", s.normal)
+}
+
+// Describe returns kind "offset" with the "offset" distance.
+func (s *offset3) Describe() (string, map[string]float64) {
+	return "offset", map[string]float64{"offset": s.distance}
+}
+
+// Describe returns kind "shell" with the shell "thickness".
+func (s *shell3) Describe() (string, map[string]float64) {
+	return "shell", map[string]float64{"thickness": 2 * s.delta}
+}
+
+// Describe returns kind "variable_round" and no parameters
+// since the radius is a function of position.
+func (s *variableRound3) Describe() (string, map[string]float64) {
+	return "variable_round", map[string]float64{}
+}
+
+// Describe returns kind "image_relief" with the carving "depth".
+func (s *relief3) Describe() (string, map[string]float64) {
+	return "image_relief", map[string]float64{"depth": s.depth}
+}
+
+// Describe returns kind "bevel_edge" with the chamfer "size".
+func (s *bevelEdge3) Describe() (string, map[string]float64) {
+	return "bevel_edge", map[string]float64{"size": s.size}
+}
+
+// Describe returns kind "graded_gyroid" with the cell "period". The
+// thickness is not described since it is a function of position.
+func (s *gyroid3) Describe() (string, map[string]float64) {
+	return "graded_gyroid", vecParams(map[string]float64{}, "period", s.period)
+}
+
+// Describe returns kind "cylindrical_text" with the cylinder "radius", the
+// text "depth" and "engrave", which is 1 if engraving and 0 if embossing.
+func (s *cylindricalText3) Describe() (string, map[string]float64) {
+	engrave := 0.0
+	if s.engrave {
+		engrave = 1
+	}
+	return "cylindrical_text", map[string]float64{"radius": s.radius, "depth": s.depth, "engrave": engrave}
+}
+
+// Describe returns kind "rail_sweep" with the number of "stations" of its rails.
+func (s *railSweep3) Describe() (string, map[string]float64) {
+	return "rail_sweep", map[string]float64{"stations": float64(len(s.path))}
+}
+
+// Describe returns kind "solidify" with the "thickness" and
+// the "direction" towards which the sheet is thickened.
+func (s *solidify3) Describe() (string, map[string]float64) {
+	return "solidify", vecParams(map[string]float64{"thickness": 2 * s.delta}, "direction", s.direction)
+}
+
+// Describe returns kind "draft" with the draft "angle" in radians and "parting_z".
+func (s *draft3) Describe() (string, map[string]float64) {
+	return "draft", map[string]float64{"angle": s.angle, "parting_z": s.partingZ}
+}
+
+// Describe returns kind "auto_box" with the "search_min" and "search_max"
+// corners of the search box and the sampling "resolution".
+func (s *autoBox3) Describe() (string, map[string]float64) {
+	params := vecParams(map[string]float64{"resolution": float64(s.resolution)}, "search_min", s.search.Min)
+	return "auto_box", vecParams(params, "search_max", s.search.Max)
+}
+
+// Describe returns kind "box_set" with the number of "boxes" and the "round" radius.
+func (s *boxSet3) Describe() (string, map[string]float64) {
+	return "box_set", map[string]float64{"boxes": float64(len(s.centers)), "round": s.round}
+}
+
+// Describe returns kind "auto_fillet" with the fillet "radius", the
+// "angle_threshold" in radians and the sampling "resolution".
+func (s *autoFillet3) Describe() (string, map[string]float64) {
+	return "auto_fillet", map[string]float64{"radius": s.radius, "angle_threshold": s.angle, "resolution": float64(s.resolution)}
+}
+
+// Describe returns kind "voxelize" with the voxel "size" and "smoothness".
+func (s *voxelize3) Describe() (string, map[string]float64) {
+	return "voxelize", map[string]float64{"size": s.size, "smoothness": s.smoothness}
+}
+
+// Describe returns kind "revolve" with the revolution angle "theta" in radians.
+func (s *revolution3) Describe() (string, map[string]float64) {
+	theta := s.theta
+	if theta == 0 {
+		theta = tau
+	}
+	return "revolve", map[string]float64{"theta": theta}
+}
+
+// Describe returns kind "revolve_capped" with the revolution angle "theta" in radians.
+func (s *revolutionCapped3) Describe() (string, map[string]float64) {
+	return "revolve_capped", map[string]float64{"theta": s.theta}
+}
+
+// Describe returns kind "extrude" with the extrusion "height".
+func (s *extrude3) Describe() (string, map[string]float64) {
+	return "extrude", map[string]float64{"height": 2 * s.height}
+}
+
+// Describe returns kind "extrude_rounded" with the extrusion "height" and "round" radius.
+func (s *extrudeRounded) Describe() (string, map[string]float64) {
+	return "extrude_rounded", map[string]float64{"height": 2 * (s.height + s.round), "round": s.round}
+}
+
+// Describe returns kind "loft" with the loft "height" and "round" radius.
+func (s *loft3) Describe() (string, map[string]float64) {
+	return "loft", map[string]float64{"height": 2 * (s.height + s.round), "round": s.round}
+}
+
+// vecParams adds the components of v to params with the name prefix.
+func vecParams(params map[string]float64, name string, v r3.Vec) map[string]float64 {
+	params[name+"_x"] = v.X
+	params[name+"_y"] = v.Y
+	params[name+"_z"] = v.Z
+	return params
+}
@@ -1 +1,8 @@
 package sdf
+
+import "errors"
+
+// ErrTopologyChanged is returned along with a usable result by operations
+// which change the number of separate parts of a solid, such as OffsetSafe3D
+// eroding a part entirely or pinching one into several.
+var ErrTopologyChanged = errors.New("topology of solid changed")
@@ -0,0 +1,94 @@
+package sdf
+
+import (
+	"math"
+)
+
+// Exactness is an SDF3 which reports whether it returns exact Euclidean
+// distances or only approximations, which may overestimate the distance and
+// make sphere tracers overshoot the surface. Combinators are exact when their
+// operation preserves distances, such as rotations, translations and unions,
+// and all their SDF3s are exact. Operations such as twists, non-uniform
+// scaling and blending are not exact. Differences and intersections return
+// lower bounds of the distance and are also reported as approximate.
+type Exactness interface {
+	SDF3
+	IsExact() bool
+}
+
+// IsExactSDF3 returns true if sdf returns exact distances. SDF3s which do
+// not implement Exactness are assumed to be approximate. Sphere tracers
+// should take smaller steps when it returns false.
+func IsExactSDF3(sdf SDF3) bool {
+	e, ok := sdf.(Exactness)
+	return ok && e.IsExact()
+}
+
+// exactAll returns true if all of sdf are exact.
+func exactAll(sdf ...SDF3) bool {
+	for _, s := range sdf {
+		if !IsExactSDF3(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRigid returns true if m is a rotation, reflection and translation,
+// which preserve distances.
+func (m m44) isRigid() bool {
+	cols := [3][3]float64{
+		{m.x00, m.x10, m.x20},
+		{m.x01, m.x11, m.x21},
+		{m.x02, m.x12, m.x22},
+	}
+	for i := range cols {
+		for j := i; j < 3; j++ {
+			dot := cols[i][0]*cols[j][0] + cols[i][1]*cols[j][1] + cols[i][2]*cols[j][2]
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(dot-want) > tolerance {
+				return false
+			}
+		}
+	}
+	return m.x30 == 0 && m.x31 == 0 && m.x32 == 0 && m.x33 == 1
+}
+
+// IsExact returns true if the union does not blend and its SDF3s are exact.
+func (s *union3) IsExact() bool { return !s.blend && exactAll(s.sdf...) }
+
+// IsExact returns false since the difference is a lower bound of the distance.
+func (s *diff3) IsExact() bool { return false }
+
+// IsExact returns false since the intersection is a lower bound of the distance.
+func (s *intersection3) IsExact() bool { return false }
+
+// IsExact returns true if the transform is rigid and the SDF3 is exact.
+func (s *transform3) IsExact() bool { return s.matrix.isRigid() && IsExactSDF3(s.sdf) }
+
+// IsExact returns true if the scaled SDF3 is exact.
+func (s *scaleUniform3) IsExact() bool { return IsExactSDF3(s.sdf) }
+
+// IsExact returns true if the elongated SDF3 is exact.
+func (s *elongate3) IsExact() bool { return IsExactSDF3(s.sdf) }
+
+// IsExact returns true if the array does not blend and the SDF3 is exact.
+func (s *array3) IsExact() bool { return !s.blend && !s.near && IsExactSDF3(s.sdf) }
+
+// IsExact returns true if the union does not blend, the step
+// is a rigid transform and the SDF3 is exact.
+func (s *rotateUnion) IsExact() bool {
+	return !s.blend && s.step.isRigid() && IsExactSDF3(s.sdf)
+}
+
+// IsExact returns true if the offset SDF3 is exact.
+func (s *offset3) IsExact() bool { return IsExactSDF3(s.sdf) }
+
+// IsExact returns true if the shelled SDF3 is exact.
+func (s *shell3) IsExact() bool { return IsExactSDF3(s.sdf) }
+
+// IsExact returns true since the distance to the nearest sphere is exact.
+func (s *sphereUnion) IsExact() bool { return true }
@@ -56,3 +56,59 @@ func Line(l, round float64) (s sdf.SDF2, err error) {
 	}()
 	return must2.Line(l, round), err
 }
+
+// Pie returns a circular sector of radius with its aperture of 2*halfAngle
+// radians centered on the positive X axis. halfAngle=π gives a full circle.
+func Pie(radius, halfAngle float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.Pie(radius, halfAngle), err
+}
+
+// Moon returns a crescent formed by subtracting a circle of radius rb centered
+// on (d,0) from a circle of radius ra centered on the origin.
+func Moon(d, ra, rb float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.Moon(d, ra, rb), err
+}
+
+// EquilateralTriangle returns an equilateral triangle centered on the origin
+// with its vertices at distance radius from the origin and one of them on +Y.
+func EquilateralTriangle(radius float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.EquilateralTriangle(radius), err
+}
+
+// IsoscelesTriangle returns an isosceles triangle with its base centered on the
+// origin along the X axis and its apex at (0, height).
+func IsoscelesTriangle(base, height float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.IsoscelesTriangle(base, height), err
+}
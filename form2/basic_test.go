@@ -0,0 +1,125 @@
+package form2_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2"
+	"github.com/soypat/sdf/form2/must2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestPie(t *testing.T) {
+	quarter, err := form2.Pie(2, math.Pi/4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqrtHalf := math.Sqrt(0.5)
+	for _, test := range []struct {
+		p    r2.Vec
+		want float64
+	}{
+		{p: r2.Vec{X: 1}, want: -sqrtHalf},              // inside, closest to the straight edges
+		{p: r2.Vec{X: 1.9}, want: -0.1},                 // inside, closest to the arc
+		{p: r2.Vec{X: 3}, want: 1},                      // beyond the arc
+		{p: r2.Vec{X: -1}, want: 1},                     // behind the apex
+		{p: r2.Vec{Y: 1}, want: sqrtHalf},               // beside a straight edge
+		{p: r2.Vec{X: 1, Y: -1}, want: 0},               // on the lower straight edge
+		{p: r2.Vec{X: 2, Y: 2}, want: 2*math.Sqrt2 - 2}, // beyond the corner
+	} {
+		if got := quarter.Evaluate(test.p); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("distance at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+	want := r2.Box{Min: r2.Vec{Y: -2 * sqrtHalf}, Max: r2.Vec{X: 2, Y: 2 * sqrtHalf}}
+	if bb := quarter.Bounds(); r2.Norm(r2.Sub(bb.Min, want.Min)) > 1e-12 || r2.Norm(r2.Sub(bb.Max, want.Max)) > 1e-12 {
+		t.Errorf("got bounds %v, want %v", bb, want)
+	}
+	// A half angle of π is a circle.
+	full, err := form2.Pie(1, math.Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []r2.Vec{{X: -2}, {X: 0.5, Y: -0.3}, {X: -0.2, Y: 0.1}, {Y: 3}} {
+		if got, want := full.Evaluate(p), r2.Norm(p)-1; math.Abs(got-want) > 1e-9 {
+			t.Errorf("full pie distance at %v: got %g, want %g", p, got, want)
+		}
+	}
+	for _, halfAngle := range []float64{0, -1, 4} {
+		if _, err := form2.Pie(1, halfAngle); err == nil {
+			t.Errorf("expected error for half angle %g", halfAngle)
+		}
+	}
+}
+
+func TestMoon(t *testing.T) {
+	// Circles of radius 1 intersecting at (0.5, ±sqrt(0.75)).
+	moon, err := form2.Moon(1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cusp := r2.Vec{X: 0.5, Y: math.Sqrt(0.75)}
+	for _, test := range []struct {
+		p    r2.Vec
+		want float64
+	}{
+		{p: r2.Vec{X: -0.5}, want: -0.5},             // inside the crescent
+		{p: r2.Vec{X: -2}, want: 1},                  // outside the outer arc
+		{p: r2.Vec{X: 0.5}, want: 0.5},               // inside the subtracted circle
+		{p: r2.Vec{X: 1}, want: 1},                   // center of the subtracted circle
+		{p: r2.Add(cusp, r2.Vec{X: 0.3}), want: 0.3}, // beyond the cusp
+		{p: r2.Vec{X: 0.8, Y: -cusp.Y}, want: 0.3},   // beyond the lower cusp
+	} {
+		if got := moon.Evaluate(test.p); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("distance at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+	for _, args := range [][3]float64{{3, 1, 1}, {0.1, 1, 2}, {0.5, 1, 0.2}, {1, 0, 1}} {
+		if _, err := form2.Moon(args[0], args[1], args[2]); err == nil {
+			t.Errorf("expected error for d, ra, rb = %v", args)
+		}
+	}
+}
+
+func TestTriangles(t *testing.T) {
+	equilateral, err := form2.EquilateralTriangle(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isosceles, err := form2.IsoscelesTriangle(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := math.Sqrt(3)
+	for _, test := range []struct {
+		name     string
+		s        sdf.SDF2
+		vertices []r2.Vec
+	}{
+		{name: "equilateral", s: equilateral, vertices: []r2.Vec{{X: 0, Y: 2}, {X: -h, Y: -1}, {X: h, Y: -1}}},
+		{name: "isosceles", s: isosceles, vertices: []r2.Vec{{X: -1.5}, {X: 1.5}, {X: 0, Y: 2}}},
+	} {
+		// The exact distance matches that of the polygon with the same vertices.
+		poly := must2.Polygon(test.vertices)
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 1000; i++ {
+			p := r2.Vec{X: 8*rng.Float64() - 4, Y: 8*rng.Float64() - 4}
+			if got, want := test.s.Evaluate(p), poly.Evaluate(p); math.Abs(got-want) > 1e-9 {
+				t.Fatalf("%s: distance at %v: got %g, want %g", test.name, p, got, want)
+			}
+		}
+		bb := test.s.Bounds()
+		want := poly.Bounds()
+		if r2.Norm(r2.Sub(bb.Min, want.Min)) > 1e-12 || r2.Norm(r2.Sub(bb.Max, want.Max)) > 1e-12 {
+			t.Errorf("%s: bounds %v, want %v", test.name, bb, want)
+		}
+	}
+	if _, err := form2.IsoscelesTriangle(1, 0); err == nil {
+		t.Error("expected error for zero height")
+	}
+	if _, err := form2.EquilateralTriangle(-1); err == nil {
+		t.Error("expected error for negative radius")
+	}
+}
@@ -1,6 +1,8 @@
 package must2
 
 import (
+	"math"
+
 	"github.com/soypat/sdf/internal/d2"
 	"gonum.org/v1/gonum/spatial/r2"
 )
@@ -95,3 +97,186 @@ func (s *line) Evaluate(p r2.Vec) float64 {
 func (s *line) Bounds() r2.Box {
 	return s.bb
 }
+
+// 2D Pie (exact distance field)
+
+// pie is a circular sector.
+type pie struct {
+	radius float64
+	c      r2.Vec // sin and cos of the half angle
+	full   bool   // the sector is a full circle
+	bb     r2.Box
+}
+
+// Pie returns a circular sector of radius with its aperture of 2*halfAngle
+// radians centered on the positive X axis. halfAngle=π gives a full circle.
+// See https://iquilezles.org/articles/distfunctions2d/
+func Pie(radius, halfAngle float64) *pie {
+	if radius <= 0 {
+		panic("radius <= 0")
+	}
+	if halfAngle <= 0 || halfAngle > math.Pi {
+		panic("halfAngle must be in (0, π]")
+	}
+	sin, cos := math.Sincos(halfAngle)
+	s := pie{
+		radius: radius,
+		c:      r2.Vec{X: sin, Y: cos},
+		full:   halfAngle == math.Pi,
+	}
+	// work out the bounding box from the arc end points and its extremes.
+	xmin := math.Min(0, radius*cos)
+	ymax := radius * sin
+	if halfAngle >= math.Pi/2 {
+		ymax = radius
+	}
+	s.bb = r2.Box{Min: r2.Vec{X: xmin, Y: -ymax}, Max: r2.Vec{X: radius, Y: ymax}}
+	return &s
+}
+
+// Evaluate returns the minimum distance to a circular sector.
+func (s *pie) Evaluate(p r2.Vec) float64 {
+	// The sector is symmetric about the X axis.
+	q := r2.Vec{X: math.Abs(p.Y), Y: p.X}
+	l := r2.Norm(q) - s.radius
+	if s.full {
+		return l
+	}
+	m := r2.Norm(r2.Sub(q, r2.Scale(math.Max(0, math.Min(s.radius, r2.Dot(q, s.c))), s.c)))
+	if s.c.Y*q.X-s.c.X*q.Y < 0 {
+		// q lies within the aperture.
+		m = -m
+	}
+	return math.Max(l, m)
+}
+
+// BoundingBox returns the bounding box for a circular sector.
+func (s *pie) Bounds() r2.Box {
+	return s.bb
+}
+
+// 2D Moon (exact distance field)
+
+// moon is a crescent formed by subtracting a circle from another.
+type moon struct {
+	d, ra, rb float64
+	a, b      float64 // intersection point of the two circles
+	bb        r2.Box
+}
+
+// Moon returns a crescent formed by subtracting a circle of radius rb centered
+// on (d,0) from a circle of radius ra centered on the origin. The circles must
+// intersect, i.e. |ra-rb| < d < ra+rb.
+// See https://iquilezles.org/articles/distfunctions2d/
+func Moon(d, ra, rb float64) *moon {
+	if ra <= 0 || rb <= 0 {
+		panic("radius <= 0")
+	}
+	if d <= math.Abs(ra-rb) || d >= ra+rb {
+		panic("circles do not intersect to form a crescent")
+	}
+	a := (ra*ra - rb*rb + d*d) / (2 * d)
+	return &moon{
+		d:  d,
+		ra: ra,
+		rb: rb,
+		a:  a,
+		b:  math.Sqrt(math.Max(ra*ra-a*a, 0)),
+		bb: r2.Box{Min: r2.Vec{X: -ra, Y: -ra}, Max: r2.Vec{X: ra, Y: ra}},
+	}
+}
+
+// Evaluate returns the minimum distance to a crescent.
+func (s *moon) Evaluate(p r2.Vec) float64 {
+	p.Y = math.Abs(p.Y)
+	if s.d*(p.X*s.b-p.Y*s.a) > s.d*s.d*math.Max(s.b-p.Y, 0) {
+		// closest to the cusp.
+		return r2.Norm(r2.Sub(p, r2.Vec{X: s.a, Y: s.b}))
+	}
+	return math.Max(r2.Norm(p)-s.ra, -(r2.Norm(r2.Sub(p, r2.Vec{X: s.d})) - s.rb))
+}
+
+// BoundingBox returns the bounding box for a crescent.
+func (s *moon) Bounds() r2.Box {
+	return s.bb
+}
+
+// Equilateral Triangle (exact distance field)
+
+// equilateralTriangle is an equilateral triangle centered on the origin.
+type equilateralTriangle struct {
+	r  float64 // half side length
+	bb r2.Box
+}
+
+// EquilateralTriangle returns an equilateral triangle centered on the origin
+// with its vertices at distance radius from the origin and one of them on +Y.
+// See https://iquilezles.org/articles/distfunctions2d/
+func EquilateralTriangle(radius float64) *equilateralTriangle {
+	if radius <= 0 {
+		panic("radius <= 0")
+	}
+	r := radius * math.Sqrt(3) / 2
+	return &equilateralTriangle{
+		r:  r,
+		bb: r2.Box{Min: r2.Vec{X: -r, Y: -radius / 2}, Max: r2.Vec{X: r, Y: radius}},
+	}
+}
+
+// Evaluate returns the minimum distance to an equilateral triangle.
+func (s *equilateralTriangle) Evaluate(p r2.Vec) float64 {
+	k := math.Sqrt(3)
+	p.X = math.Abs(p.X) - s.r
+	p.Y += s.r / k
+	if p.X+k*p.Y > 0 {
+		p = r2.Vec{X: (p.X - k*p.Y) / 2, Y: (-k*p.X - p.Y) / 2}
+	}
+	p.X -= math.Max(-2*s.r, math.Min(0, p.X))
+	return -math.Copysign(r2.Norm(p), p.Y)
+}
+
+// BoundingBox returns the bounding box for an equilateral triangle.
+func (s *equilateralTriangle) Bounds() r2.Box {
+	return s.bb
+}
+
+// Isosceles Triangle (exact distance field)
+
+// isoscelesTriangle is an isosceles triangle with its base on the X axis.
+type isoscelesTriangle struct {
+	q  r2.Vec // half base and height
+	bb r2.Box
+}
+
+// IsoscelesTriangle returns an isosceles triangle with its base centered on the
+// origin along the X axis and its apex at (0, height).
+// See https://iquilezles.org/articles/distfunctions2d/
+func IsoscelesTriangle(base, height float64) *isoscelesTriangle {
+	if base <= 0 {
+		panic("base <= 0")
+	}
+	if height <= 0 {
+		panic("height <= 0")
+	}
+	return &isoscelesTriangle{
+		q:  r2.Vec{X: base / 2, Y: height},
+		bb: r2.Box{Min: r2.Vec{X: -base / 2}, Max: r2.Vec{X: base / 2, Y: height}},
+	}
+}
+
+// Evaluate returns the minimum distance to an isosceles triangle.
+func (s *isoscelesTriangle) Evaluate(p r2.Vec) float64 {
+	// Work with the apex on the origin and the base at y=height.
+	q := s.q
+	p = r2.Vec{X: math.Abs(p.X), Y: q.Y - p.Y}
+	a := r2.Sub(p, r2.Scale(math.Max(0, math.Min(1, r2.Dot(p, q)/r2.Dot(q, q))), q))
+	b := r2.Sub(p, r2.Vec{X: q.X * math.Max(0, math.Min(1, p.X/q.X)), Y: q.Y})
+	d := math.Min(r2.Dot(a, a), r2.Dot(b, b))
+	inside := math.Min(-(p.X*q.Y - p.Y*q.X), -(p.Y - q.Y))
+	return -math.Copysign(math.Sqrt(d), inside)
+}
+
+// BoundingBox returns the bounding box for an isosceles triangle.
+func (s *isoscelesTriangle) Bounds() r2.Box {
+	return s.bb
+}
@@ -18,19 +18,30 @@ type polygon struct {
 }
 
 // Polygon returns an SDF2 made from a closed set of line segments.
+// The polygon is closed automatically if the last vertex does not
+// coincide with the first. Consecutive duplicate vertices are ignored.
+// Concave polygons are supported. Self-intersecting polygons are filled using
+// the non-zero winding rule, so regions enclosed by the outline are inside
+// regardless of vertex ordering, and the distance is exact to the outline.
 func Polygon(vertex []r2.Vec) sdf.SDF2 {
 	s := polygon{}
-
-	n := len(vertex)
-	if n < 3 {
-		panic("number of vertices < 3")
+	// Copy vertices so as to not modify the caller's slice when closing the loop.
+	s.vertex = make([]r2.Vec, 0, len(vertex)+1)
+	for _, v := range vertex {
+		if len(s.vertex) > 0 && d2.EqualWithin(v, s.vertex[len(s.vertex)-1], tolerance) {
+			continue // zero length segment
+		}
+		s.vertex = append(s.vertex, v)
 	}
-
-	// Close the loop (if necessary)
-	s.vertex = vertex
-	if !d2.EqualWithin(vertex[0], vertex[n-1], tolerance) {
-		s.vertex = append(s.vertex, vertex[0])
+	n := len(s.vertex)
+	if n > 1 && d2.EqualWithin(s.vertex[0], s.vertex[n-1], tolerance) {
+		n--
 	}
+	if n < 3 {
+		panic("number of distinct vertices < 3")
+	}
+	// Close the loop.
+	s.vertex = append(s.vertex[:n], s.vertex[0])
 
 	// allocate pre-calculated line segment info
 	nsegs := len(s.vertex) - 1
@@ -111,6 +122,54 @@ func (s *polygon) Bounds() r2.Box {
 	return s.bb
 }
 
+// RoundedPolygon returns an SDF2 made from a closed set of line segments with
+// all convex vertices rounded by radius. The polygon is inset by radius and the
+// distance field of the inset polygon is then offset outward by radius.
+// Concave vertices remain sharp since the offset restores them exactly.
+// RoundedPolygon panics if a feature of the polygon is thinner than 2*radius,
+// since the inset polygon would degenerate. The polygon must not be self-intersecting.
+func RoundedPolygon(vertex []r2.Vec, radius float64) sdf.SDF2 {
+	if radius < 0 {
+		panic("radius < 0")
+	}
+	poly := Polygon(vertex).(*polygon)
+	if radius == 0 {
+		return poly
+	}
+	v := poly.vertex[:len(poly.vertex)-1] // open loop
+	n := len(v)
+	// Orientation of polygon given by sign of area (shoelace formula).
+	area := 0.0
+	for i := range v {
+		area += v[i].Cross(v[(i+1)%n])
+	}
+	orientation := Sign(area)
+	// Inward pointing normal of the i'th segment.
+	normal := func(i int) r2.Vec {
+		u := poly.vector[i]
+		return r2.Scale(orientation, r2.Vec{X: -u.Y, Y: u.X})
+	}
+	inset := make([]r2.Vec, n)
+	for i := range v {
+		n0 := normal((i + n - 1) % n) // incoming segment
+		n1 := normal(i)               // outgoing segment
+		k := 1 + n0.Dot(n1)
+		if k < tolerance {
+			panic("polygon has a zero angle vertex")
+		}
+		inset[i] = v[i].Add(r2.Scale(radius/k, n0.Add(n1)))
+	}
+	// Segments of the inset polygon must keep their direction. If not
+	// the radius is too large for the polygon's features.
+	for i := range inset {
+		seg := inset[(i+1)%n].Sub(inset[i])
+		if seg.Dot(poly.vector[i]) <= 0 {
+			panic("radius too large for polygon features")
+		}
+	}
+	return sdf.Offset2D(Polygon(inset), radius)
+}
+
 // Polygon building code.
 
 // PolygonBuilder stores a set of 2d polygon vertices.
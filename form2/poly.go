@@ -10,6 +10,9 @@ import (
 )
 
 // Polygon returns an SDF2 made from a closed set of line segments.
+// At least 3 distinct vertices are required. Concave polygons are supported
+// and self-intersecting polygons are filled using the non-zero winding rule.
+// The resulting SDF2 can be extruded or revolved to build an SDF3.
 func Polygon(vertex []r2.Vec) (s sdf.SDF2, err error) {
 	defer func() {
 		if a := recover(); a != nil {
@@ -22,6 +25,22 @@ func Polygon(vertex []r2.Vec) (s sdf.SDF2, err error) {
 	return must2.Polygon(vertex), err
 }
 
+// RoundedPolygon returns an SDF2 made from a closed set of line segments with
+// all convex vertices rounded by radius. Concave vertices remain sharp.
+// An error is returned if radius < 0 or if a feature of the
+// polygon is thinner than 2*radius.
+func RoundedPolygon(vertex []r2.Vec, radius float64) (s sdf.SDF2, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must2.RoundedPolygon(vertex, radius), err
+}
+
 // NewPolygon returns an empty polygon.
 func NewPolygon() *must2.PolygonBuilder {
 	return must2.NewPolygon()
@@ -0,0 +1,127 @@
+package form2_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/form2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestPolygon(t *testing.T) {
+	const tol = 1e-12
+	// Concave L shape.
+	lshape := []r2.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+	poly, err := form2.Polygon(lshape)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p    r2.Vec
+		want float64
+	}{
+		{p: r2.Vec{X: 0.5, Y: 0.5}, want: -0.5},
+		{p: r2.Vec{X: 1.5, Y: 1.5}, want: 0.5},            // in the concavity
+		{p: r2.Vec{X: 1.5, Y: 0.5}, want: -0.5},           // inside the short leg
+		{p: r2.Vec{X: 3, Y: 0.5}, want: 1},                // outside right edge
+		{p: r2.Vec{X: 2, Y: 2}, want: 1},                  // closest to concave corner edges
+		{p: r2.Vec{X: -1, Y: -1}, want: math.Sqrt2},       // closest to vertex
+		{p: r2.Vec{X: 1.25, Y: 1.25}, want: 0.25},         // equidistant to concave corner edges
+		{p: r2.Vec{X: 0.75, Y: 1.5}, want: -0.25},         // inside the tall leg
+		{p: r2.Vec{X: 1, Y: 1}, want: 0},                  // concave vertex
+		{p: r2.Vec{X: 0.5, Y: -0.5}, want: 0.5},           // below the base
+		{p: r2.Vec{X: 2.5, Y: 1.5}, want: math.Sqrt(0.5)}, // closest to convex vertex (2,1)
+	} {
+		got := poly.Evaluate(test.p)
+		if math.Abs(got-test.want) > tol {
+			t.Errorf("L shape at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+
+	// Self-intersecting bowtie is filled with the non-zero winding rule.
+	bowtie := []r2.Vec{{X: -1, Y: -1}, {X: 1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: 1}}
+	poly, err = form2.Polygon(bowtie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []r2.Vec{{X: 0.5, Y: 0}, {X: -0.5, Y: 0}} {
+		if d := poly.Evaluate(p); d >= 0 {
+			t.Errorf("bowtie lobe point %v should be inside, got %g", p, d)
+		}
+	}
+	for _, p := range []r2.Vec{{X: 0, Y: 0.5}, {X: 0, Y: -0.5}} {
+		if d := poly.Evaluate(p); d <= 0 {
+			t.Errorf("bowtie waist point %v should be outside, got %g", p, d)
+		}
+	}
+
+	// Duplicate and closing vertices should not generate NaNs.
+	dup := []r2.Vec{{X: 0, Y: 0}, {X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 1, Y: 1}, {X: 0, Y: 0}}
+	input := append(make([]r2.Vec, 0, 16), dup...)
+	poly, err = form2.Polygon(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := poly.Evaluate(r2.Vec{X: 0.75, Y: 0.25}); math.IsNaN(d) || d >= 0 {
+		t.Errorf("expected negative distance inside triangle, got %g", d)
+	}
+	if spare := input[len(input):cap(input)]; spare[0] != (r2.Vec{}) {
+		t.Error("Polygon modified the caller's slice")
+	}
+
+	// Too few vertices.
+	for _, pts := range [][]r2.Vec{
+		nil,
+		{{X: 0, Y: 0}, {X: 1, Y: 0}},
+		{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 0}},
+	} {
+		_, err = form2.Polygon(pts)
+		if err == nil {
+			t.Errorf("expected error for %d vertices", len(pts))
+		}
+	}
+}
+
+func TestRoundedPolygon(t *testing.T) {
+	const tol = 1e-9
+	// Concave L shape.
+	lshape := []r2.Vec{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+	const r = 0.25
+	for _, pts := range [][]r2.Vec{lshape, reversed(lshape)} {
+		poly, err := form2.RoundedPolygon(pts, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, test := range []struct {
+			p    r2.Vec
+			want float64
+		}{
+			{p: r2.Vec{X: 0.5, Y: 0.5}, want: -0.5},                  // interior unaffected
+			{p: r2.Vec{X: 1, Y: -0.5}, want: 0.5},                    // straight edge unaffected
+			{p: r2.Vec{X: 1.25, Y: 1.25}, want: 0.25},                // concave corner stays sharp
+			{p: r2.Vec{X: 2, Y: 0}, want: math.Sqrt(2*r*r) - r},      // convex corner is rounded
+			{p: r2.Vec{X: 2 - r, Y: r}, want: -r},                    // fillet center
+			{p: r2.Vec{X: 3, Y: -1}, want: math.Hypot(1+r, 1+r) - r}, // distance to fillet arc
+		} {
+			got := poly.Evaluate(test.p)
+			if math.Abs(got-test.want) > tol {
+				t.Errorf("rounded L shape at %v: got %g, want %g", test.p, got, test.want)
+			}
+		}
+	}
+	// Legs of the L shape are 1 wide, radius can not exceed half of that.
+	if _, err := form2.RoundedPolygon(lshape, 0.6); err == nil {
+		t.Error("expected error for radius larger than features")
+	}
+	if _, err := form2.RoundedPolygon(lshape, -1); err == nil {
+		t.Error("expected error for negative radius")
+	}
+}
+
+func reversed(v []r2.Vec) []r2.Vec {
+	r := make([]r2.Vec, len(v))
+	for i := range v {
+		r[len(v)-1-i] = v[i]
+	}
+	return r
+}
@@ -0,0 +1,172 @@
+package form2
+
+import (
+	"errors"
+	"io/ioutil"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/soypat/sdf"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// bezierSegments is the number of line segments approximating
+// each quadratic Bézier segment of a glyph outline.
+const bezierSegments = 8
+
+// LoadFont loads a TrueType (*.ttf) font file. An empty name loads
+// the Go Regular font bundled with golang.org/x/image.
+func LoadFont(name string) (*truetype.Font, error) {
+	if name == "" {
+		return truetype.Parse(goregular.TTF)
+	}
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return truetype.Parse(b)
+}
+
+// Text returns the SDF2 of text laid out on a single line with font f.
+// The text is scaled so the font's em is height tall, its baseline lies
+// on the X axis and it is centered horizontally on the origin.
+// Glyph outlines are approximated by polygons.
+func Text(f *truetype.Font, text string, height float64) (sdf.SDF2, error) {
+	if f == nil {
+		return nil, errors.New("nil font")
+	}
+	if height <= 0 {
+		return nil, errors.New("text height must be positive")
+	}
+	// Glyph coordinates are font units when scaled to the units per em.
+	scale := fixed.Int26_6(f.FUnitsPerEm())
+	var (
+		glyphs []sdf.SDF2
+		buf    truetype.GlyphBuf
+		prev   truetype.Index
+		x      float64
+	)
+	for i, r := range text {
+		idx := f.Index(r)
+		if i > 0 {
+			x += float64(f.Kern(scale, prev, idx))
+		}
+		prev = idx
+		if err := buf.Load(f, scale, idx, font.HintingNone); err != nil {
+			return nil, err
+		}
+		glyph, err := glyphSDF2(&buf)
+		if err != nil {
+			return nil, err
+		}
+		if glyph != nil {
+			glyphs = append(glyphs, sdf.Transform2D(glyph, sdf.Translate2D(r2.Vec{X: x})))
+		}
+		x += float64(f.HMetric(scale, idx).AdvanceWidth)
+	}
+	s := union2(glyphs)
+	if s == nil {
+		return nil, errors.New("text has no glyph outlines")
+	}
+	s = sdf.Transform2D(s, sdf.Translate2D(r2.Vec{X: -x / 2}))
+	return sdf.ScaleUniform2D(s, height/float64(f.FUnitsPerEm())), nil
+}
+
+// TextLabel returns a function laying out labels with Text in font f with
+// height tall letters, such as the label function of sdf.NumberedParts3D.
+func TextLabel(f *truetype.Font, height float64) func(text string) (sdf.SDF2, error) {
+	return func(text string) (sdf.SDF2, error) {
+		return Text(f, text, height)
+	}
+}
+
+// glyphSDF2 returns the SDF2 of a loaded glyph or nil if it has no outline.
+// TrueType outer contours run clockwise and holes counter-clockwise.
+func glyphSDF2(g *truetype.GlyphBuf) (sdf.SDF2, error) {
+	var outer, holes []sdf.SDF2
+	start := 0
+	for _, end := range g.Ends {
+		vertex := glyphContour(g.Points[start:end])
+		start = end
+		poly, err := Polygon(vertex)
+		if err != nil {
+			return nil, err
+		}
+		if signedArea(vertex) < 0 {
+			outer = append(outer, poly)
+		} else {
+			holes = append(holes, poly)
+		}
+	}
+	s := union2(outer)
+	if s != nil && len(holes) > 0 {
+		s = sdf.Difference2D(s, union2(holes))
+	}
+	return s, nil
+}
+
+// glyphContour returns the vertices of a closed glyph contour,
+// approximating its quadratic Bézier segments by line segments.
+func glyphContour(pts []truetype.Point) []r2.Vec {
+	type node struct {
+		v  r2.Vec
+		on bool
+	}
+	nodes := make([]node, 0, 2*len(pts))
+	for i, p := range pts {
+		prev := pts[(i+len(pts)-1)%len(pts)]
+		v := r2.Vec{X: float64(p.X), Y: float64(p.Y)}
+		on := p.Flags&1 != 0
+		if !on && prev.Flags&1 == 0 {
+			// Two consecutive off curve points imply an on curve point between them.
+			pv := r2.Vec{X: float64(prev.X), Y: float64(prev.Y)}
+			nodes = append(nodes, node{v: r2.Scale(0.5, r2.Add(pv, v)), on: true})
+		}
+		nodes = append(nodes, node{v: v, on: on})
+	}
+	first := 0
+	for !nodes[first].on {
+		first++
+	}
+	nodes = append(nodes[first:len(nodes):len(nodes)], nodes[:first]...)
+	vertex := []r2.Vec{nodes[0].v}
+	for i := 1; i <= len(nodes); i++ {
+		n := nodes[i%len(nodes)]
+		if n.on {
+			vertex = append(vertex, n.v)
+			continue
+		}
+		a, c, b := vertex[len(vertex)-1], n.v, nodes[(i+1)%len(nodes)].v
+		for k := 1; k <= bezierSegments; k++ {
+			t := float64(k) / bezierSegments
+			v := r2.Add(r2.Scale((1-t)*(1-t), a), r2.Scale(2*(1-t)*t, c))
+			vertex = append(vertex, r2.Add(v, r2.Scale(t*t, b)))
+		}
+		i++ // Skip the end point of the curve.
+	}
+	return vertex
+}
+
+// signedArea returns the area enclosed by a closed polygon,
+// positive if its vertices run counter-clockwise.
+func signedArea(vertex []r2.Vec) float64 {
+	area := 0.0
+	for i, a := range vertex {
+		b := vertex[(i+1)%len(vertex)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
+
+// union2 returns the union of s, the only element of s or nil if s is empty.
+func union2(s []sdf.SDF2) sdf.SDF2 {
+	switch len(s) {
+	case 0:
+		return nil
+	case 1:
+		return s[0]
+	}
+	return sdf.Union2D(s...)
+}
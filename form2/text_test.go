@@ -0,0 +1,59 @@
+package form2_test
+
+import (
+	"testing"
+
+	"github.com/soypat/sdf/form2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestText(t *testing.T) {
+	f, err := form2.LoadFont("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const height = 10
+	o, err := form2.Text(f, "o", height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := o.Bounds()
+	// Lowercase letters sit on the baseline and are shorter than the em.
+	if bb.Min.Y > 0 || bb.Min.Y < -0.5 || bb.Max.Y > 0.7*height {
+		t.Errorf("got bounds %v", bb)
+	}
+	mid := (bb.Min.Y + bb.Max.Y) / 2
+	for _, test := range []struct {
+		p      r2.Vec
+		inside bool
+	}{
+		{p: r2.Vec{X: (bb.Min.X + bb.Max.X) / 2, Y: mid}, inside: false}, // the hole
+		{p: r2.Vec{X: bb.Min.X + 0.2, Y: mid}, inside: true},
+		{p: r2.Vec{X: bb.Max.X - 0.2, Y: mid}, inside: true},
+		{p: r2.Vec{X: bb.Max.X + 0.2, Y: mid}, inside: false},
+	} {
+		if got := o.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("point %v: got inside %t, want %t", test.p, got, test.inside)
+		}
+	}
+	// Text is centered by its advance, so a longer line extends both ways.
+	line, err := form2.Text(f, "ooo", height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lb := line.Bounds(); lb.Min.X > bb.Min.X-height/2 || lb.Max.X < bb.Max.X+height/2 {
+		t.Errorf("got line bounds %v, letter bounds %v", lb, bb)
+	}
+	if _, err := form2.Text(nil, "o", height); err == nil {
+		t.Error("expected error for nil font")
+	}
+	if _, err := form2.Text(f, "o", 0); err == nil {
+		t.Error("expected error for zero height")
+	}
+	if _, err := form2.Text(f, " ", height); err == nil {
+		t.Error("expected error for text without outlines")
+	}
+	if _, err := form2.LoadFont("nonexistent.ttf"); err == nil {
+		t.Error("expected error for missing font file")
+	}
+}
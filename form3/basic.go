@@ -8,6 +8,7 @@ import (
 	"github.com/soypat/sdf"
 	form2 "github.com/soypat/sdf/form2/must2"
 	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r2"
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
@@ -46,6 +47,20 @@ func Sphere(radius float64) (s sdf.SDF3, err error) {
 	return must3.Sphere(radius), err
 }
 
+// ModulatedSphere returns an SDF3 for a sphere with a radius which varies with direction.
+// See must3.ModulatedSphere for the meaning of arguments and accuracy of the field.
+func ModulatedSphere(baseRadius float64, radial func(theta, phi float64) float64, maxRadius float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.ModulatedSphere(baseRadius, radial, maxRadius), err
+}
+
 // Cylinder return an SDF3 for a cylinder (rounded edges with round > 0).
 func Cylinder(height, radius, round float64) (s sdf.SDF3, err error) {
 	defer func() {
@@ -77,6 +92,257 @@ func Cone(height, r0, r1, round float64) (s sdf.SDF3, err error) {
 	return must3.Cone(height, r0, r1, round), err
 }
 
+// Ellipsoid returns the SDF3 for an ellipsoid centered on the origin with the
+// semi-axes radii along X, Y and Z. The distance field is a bounded approximation
+// which vanishes exactly on the surface but is not exact away from it.
+func Ellipsoid(radii r3.Vec) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Ellipsoid(radii), err
+}
+
+// Torus returns the SDF3 for a torus centered on the origin about the Z axis.
+// The tube of radius minorRadius follows a circle of radius majorRadius on the XY
+// plane. minorRadius must be smaller than majorRadius so the torus has a hole.
+func Torus(majorRadius, minorRadius float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Torus(majorRadius, minorRadius), err
+}
+
+// TorusLp returns the SDF3 for a torus centered on the origin about the Z axis whose tube
+// cross section is the ball of radius minorRadius of the Lp norm with exponent p >= 1.
+// See must3.TorusLp for the shapes given by p and the accuracy of the field.
+func TorusLp(majorRadius, minorRadius, p float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.TorusLp(majorRadius, minorRadius, p), err
+}
+
+// Link returns the SDF3 for a chain link: a torus, as returned by Torus, cut in half
+// across the Y axis with its halves moved apart along Y by length and joined by straight tubes.
+func Link(length, majorRadius, minorRadius float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Link(length, majorRadius, minorRadius), err
+}
+
+// CappedTorus returns the SDF3 for the part of a torus, as returned by Torus, within
+// angle radians to both sides of the +Y axis. The open ends of the tube are closed by
+// hemispherical caps. angle must be in (0, π]. The bounding box is that of the full torus.
+func CappedTorus(majorRadius, minorRadius, angle float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.CappedTorus(majorRadius, minorRadius, angle), err
+}
+
+// Octahedron returns the SDF3 for a regular octahedron centered on the origin
+// with its vertices on the axes at distance s from the center.
+func Octahedron(s float64) (o sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Octahedron(s), err
+}
+
+// Tetrahedron returns the SDF3 for a regular tetrahedron centered on the origin
+// inscribed in a cube of half size s, with vertices at (s,s,s), (s,-s,-s),
+// (-s,s,-s) and (-s,-s,s).
+func Tetrahedron(s float64) (t sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Tetrahedron(s), err
+}
+
+// Pyramid returns the SDF3 for a square based pyramid with base edges of length
+// baseWidth centered on the XY plane and its apex at height on the Z axis.
+func Pyramid(baseWidth, height float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Pyramid(baseWidth, height), err
+}
+
+// HexagonalPrism returns the SDF3 for a regular hexagonal prism of the given height
+// centered on the origin along the Z axis. acrossFlats is the distance between
+// opposite faces. Two of its vertices lie on the X axis.
+func HexagonalPrism(acrossFlats, height float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.HexagonalPrism(acrossFlats, height), err
+}
+
+// TriangularPrism returns the SDF3 for an equilateral triangular prism of the given height
+// along the Z axis with its cross section centroid on the origin. The triangle has sides
+// of length side, one of them parallel to the X axis, and a vertex on +Y.
+func TriangularPrism(side, height float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.TriangularPrism(side, height), err
+}
+
+// Wedge returns the SDF3 for a wedge, or ramp, lying on the XY plane with a right
+// triangle cross section of legs run along +X and rise along +Z, extruded width
+// along Y centered on the origin.
+func Wedge(run, rise, width float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Wedge(run, rise, width), err
+}
+
+// SolidAngle returns the SDF3 for the region of a sphere of the given radius centered on
+// the origin within a cone of half angle angle, in radians, with its apex on the origin
+// opening along +Z. angle must be in (0, π).
+func SolidAngle(angle, radius float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.SolidAngle(angle, radius), err
+}
+
+// BoxFrame returns the SDF3 for the frame of a box of the given size centered on the
+// origin, made of bars of square cross section of width thickness along its 12 edges.
+// thickness must be smaller than half the smallest side of the box.
+func BoxFrame(size r3.Vec, thickness float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.BoxFrame(size, thickness), err
+}
+
+// Plane returns the SDF3 for the half-space behind the plane through point with the
+// given normal. Distances are positive on the side the normal points to. See
+// must3.Plane for its effectively infinite bounding box.
+func Plane(normal, point r3.Vec) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Plane(normal, point), err
+}
+
+// Frustum returns the SDF3 for a truncated cone of the given height centered on the
+// origin along axis, with radius r0 at its base, towards -axis, and r1 at its top.
+// Cylinders (r0 == r1) and cones (r0 or r1 zero) are special cases. Edges are rounded
+// with radius round.
+func Frustum(axis r3.Vec, height, r0, r1, round float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Frustum(axis, height, r0, r1, round), err
+}
+
+// RevolveProfile revolves a lathe profile of (r,z) points about the Z axis by theta radians.
+// The points must have non-negative r and form a closed polygon, usually starting and ending on the axis.
+func RevolveProfile(points []r2.Vec, theta float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.RevolveProfile(points, theta), err
+}
+
+// Nozzle returns a cylinder of height cylHeight and radius cylRadius topped
+// by a cone of height coneHeight which tapers to tipRadius, centered on the origin.
+func Nozzle(cylHeight, cylRadius, coneHeight, tipRadius float64) (s sdf.SDF3, err error) {
+	defer func() {
+		if a := recover(); a != nil {
+			err = &shapeErr{
+				panicObj: a,
+				stack:    string(debug.Stack()),
+			}
+		}
+	}()
+	return must3.Nozzle(cylHeight, cylRadius, coneHeight, tipRadius), err
+}
+
 // ChamferedCylinder intersects a chamfered cylinder with an SDF3.
 func ChamferedCylinder(s sdf.SDF3, kb, kt float64) (sdf.SDF3, error) {
 	// get the length and radius from the bounding box
@@ -0,0 +1,953 @@
+package form3_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestConeBounds(t *testing.T) {
+	for _, test := range []struct {
+		height, r0, r1, round float64
+	}{
+		{height: 2, r0: 1, r1: 0.5, round: 0.4},
+		{height: 2, r0: 0.2, r1: 1, round: 0.2},
+		{height: 4, r0: 1, r1: 1, round: 1},
+		{height: 1, r0: 1, r1: 0, round: 0},
+		{height: 1, r0: 1, r1: 0, round: 0.3},
+		{height: 2, r0: 0, r1: 1, round: 0.3},
+	} {
+		cone, err := form3.Cone(test.height, test.r0, test.r1, test.round)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bb := cone.Bounds()
+		const eps = 1e-9
+		// Points just outside the reported box must lie outside the solid.
+		for z := bb.Min.Z - eps; z <= bb.Max.Z+eps; z += test.height / 64 {
+			for theta := 0.; theta < 2*math.Pi; theta += math.Pi / 32 {
+				r := bb.Max.X + eps
+				p := r3.Vec{X: r * math.Cos(theta), Y: r * math.Sin(theta), Z: z}
+				if d := cone.Evaluate(p); d < 0 {
+					t.Errorf("%+v: point %v outside bounds is inside solid (d=%g)", test, p, d)
+				}
+			}
+		}
+		for x := bb.Min.X; x <= bb.Max.X; x += (bb.Max.X - bb.Min.X) / 64 {
+			for _, z := range []float64{bb.Min.Z - eps, bb.Max.Z + eps} {
+				p := r3.Vec{X: x, Z: z}
+				if d := cone.Evaluate(p); d < 0 {
+					t.Errorf("%+v: point %v beyond caps is inside solid (d=%g)", test, p, d)
+				}
+			}
+		}
+	}
+}
+
+func TestConeRoundTooLarge(t *testing.T) {
+	// A steep cone can not be rounded by more than its inset radii allow.
+	for _, test := range []struct {
+		height, r0, r1, round float64
+	}{
+		{height: 10, r0: 1, r1: 0.5, round: 2},
+		{height: 10, r0: 1, r1: 0, round: 2},
+	} {
+		if _, err := form3.Cone(test.height, test.r0, test.r1, test.round); err == nil {
+			t.Errorf("%+v: expected error for oversized round", test)
+		}
+	}
+	if _, err := form3.Cone(10, 1, 0.5, 0.4); err != nil {
+		t.Error(err)
+	}
+
+	// Rounded pointed cones end in a sphere of radius round within the sharp cone.
+	const round = 0.3
+	sharp, _ := form3.Cone(2, 1, 0, 0)
+	rounded, err := form3.Cone(2, 1, 0, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tip := r3.Vec{Z: 5 - round - rounded.Evaluate(r3.Vec{Z: 5})}
+	if tip.Z+round > 1 {
+		t.Errorf("tip at %g above the sharp cone", tip.Z+round)
+	}
+	for _, p := range []r3.Vec{{X: 0.1, Z: tip.Z + 1}, {X: -0.2, Y: 0.1, Z: tip.Z + 0.5}} {
+		if got, want := rounded.Evaluate(p), r3.Norm(r3.Sub(p, tip))-round; math.Abs(got-want) > 1e-9 {
+			t.Errorf("at %v got %g, want %g", p, got, want)
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		p := r3.Vec{X: 2*rng.Float64() - 1, Y: 2*rng.Float64() - 1, Z: 2*rng.Float64() - 1}
+		if rounded.Evaluate(p) < 0 && sharp.Evaluate(p) > 1e-9 {
+			t.Fatalf("%v inside rounded cone but outside sharp cone", p)
+		}
+	}
+}
+
+func TestNozzle(t *testing.T) {
+	nozzle, err := form3.Nozzle(2, 1, 1, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := nozzle.Bounds()
+	if bb.Min.Z != -1.5 || bb.Max.Z != 1.5 || bb.Max.X != 1 {
+		t.Errorf("unexpected bounds %+v", bb)
+	}
+	for _, test := range []struct {
+		p    r3.Vec
+		want float64
+	}{
+		{p: r3.Vec{X: 1.5}, want: 0.5},                             // beside cylinder
+		{p: r3.Vec{Z: 2}, want: 0.5},                               // above tip
+		{p: r3.Vec{Z: -1.7}, want: 0.2},                            // below base
+		{p: r3.Vec{Y: 0.25, Z: 1.5}, want: 0},                      // tip edge
+		{p: r3.Vec{X: 1, Z: 0.5}, want: 0},                         // cylinder to cone transition
+		{p: r3.Vec{Z: -0.5}, want: -1},                             // inside cylinder
+		{p: r3.Vec{X: 0.625, Z: 1}, want: 0},                       // on cone slope
+		{p: r3.Vec{X: 0.625 + 0.3*0.8, Z: 1 + 0.3*0.6}, want: 0.3}, // normal to slope
+	} {
+		if got := nozzle.Evaluate(test.p); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("distance at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+	for _, args := range [][4]float64{{0, 1, 1, 0}, {1, -1, 1, 0}, {1, 1, 0, 0}, {1, 1, 1, -1}} {
+		if _, err := form3.Nozzle(args[0], args[1], args[2], args[3]); err == nil {
+			t.Errorf("expected error for dimensions %v", args)
+		}
+	}
+	if _, err := form3.Nozzle(1, 1, 1, 0); err != nil {
+		t.Errorf("sharp tip: %s", err)
+	}
+}
+
+func TestRevolveProfile(t *testing.T) {
+	// Washer profile not touching the axis.
+	washer, err := form3.RevolveProfile([]r2.Vec{{X: 1, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 0.5}, {X: 1, Y: 0.5}}, 2*math.Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Stepped shaft profile starting and ending on the axis.
+	shaft, err := form3.RevolveProfile([]r2.Vec{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 2}, {X: 0.5, Y: 2}, {X: 0.5, Y: 4}, {X: 0, Y: 4}}, 2*math.Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same shaft profile closed explicitly.
+	closed, err := form3.RevolveProfile([]r2.Vec{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 2}, {X: 0.5, Y: 2}, {X: 0.5, Y: 4}, {X: 0, Y: 4}, {X: 0, Y: 0}}, 2*math.Pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		s    sdf.SDF3
+		p    r3.Vec
+		want float64
+	}{
+		{s: closed, p: r3.Vec{Z: 1}, want: -1},
+		{s: closed, p: r3.Vec{Z: 3.5}, want: -0.5},
+		{s: closed, p: r3.Vec{Y: 0.75, Z: 3}, want: 0.25},
+		{s: washer, p: r3.Vec{Z: 0.25}, want: 1},
+		{s: washer, p: r3.Vec{Y: 1.5, Z: 0.25}, want: -0.25},
+		{s: washer, p: r3.Vec{X: -3, Z: 0.25}, want: 1},
+		{s: shaft, p: r3.Vec{Z: 1}, want: -1},     // on the axis inside the wide step
+		{s: shaft, p: r3.Vec{Z: 3.5}, want: -0.5}, // on the axis inside the narrow step
+		{s: shaft, p: r3.Vec{Z: 5}, want: 1},
+		{s: shaft, p: r3.Vec{Y: 0.75, Z: 3}, want: 0.25},
+	} {
+		if got := test.s.Evaluate(test.p); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("distance at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+	if bb := shaft.Bounds(); bb.Max.X != 1 || bb.Min.Z != 0 || bb.Max.Z != 4 {
+		t.Errorf("unexpected shaft bounds %v", bb)
+	}
+	if _, err := form3.RevolveProfile([]r2.Vec{{X: 0}, {X: 1}}, math.Pi); err == nil {
+		t.Error("expected error for 2 point profile")
+	}
+	if _, err := form3.RevolveProfile([]r2.Vec{{X: 0}, {X: -1}, {X: 1, Y: 1}}, math.Pi); err == nil {
+		t.Error("expected error for negative radius")
+	}
+}
+
+func TestModulatedSphere(t *testing.T) {
+	// Five lobes around the equator.
+	radial := func(theta, phi float64) float64 { return 0.2 * math.Cos(5*phi) * math.Sin(theta) }
+	s, err := form3.ModulatedSphere(1, radial, 1.2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p    r3.Vec
+		want float64
+	}{
+		{p: r3.Vec{X: 1.2}, want: 0},                 // tip of a lobe
+		{p: r3.Vec{X: -0.8}, want: 0},                // between lobes
+		{p: r3.Vec{Z: 2}, want: 1},                   // pole is unmodulated
+		{p: r3.Vec{X: 0.5, Y: 0.5}, want: -0.151472}, // inside
+	} {
+		if got := s.Evaluate(test.p); math.Abs(got-test.want) > 1e-6 {
+			t.Errorf("Evaluate(%v) = %g, want %g", test.p, got, test.want)
+		}
+	}
+	if _, err := form3.ModulatedSphere(1, radial, 0.5); err == nil {
+		t.Error("expected error for maxRadius smaller than baseRadius")
+	}
+}
+
+func TestFrustum(t *testing.T) {
+	cylinder, _ := form3.Cylinder(2, 1, 0.1)
+	cone, _ := form3.Cone(2, 1, 0.5, 0.1)
+	axis := r3.Unit(r3.Vec{X: 1, Y: 2, Z: 2})
+	// Frame with axis in place of Z.
+	u := r3.Unit(r3.Cross(axis, r3.Vec{Z: 1}))
+	v := r3.Cross(axis, u)
+	for _, test := range []struct {
+		z      sdf.SDF3
+		r0, r1 float64
+	}{
+		{cylinder, 1, 1},
+		{cone, 1, 0.5},
+	} {
+		alongZ, err := form3.Frustum(r3.Vec{Z: 3}, 2, test.r0, test.r1, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tilted, err := form3.Frustum(axis, 2, test.r0, test.r1, 0.1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alongZ.Bounds() != test.z.Bounds() {
+			t.Errorf("got bounds %v along Z, want %v", alongZ.Bounds(), test.z.Bounds())
+		}
+		for _, p := range []r3.Vec{{}, {X: 0.7, Z: 0.9}, {X: 1.2, Y: 0.3, Z: -1.1}, {Y: -0.4, Z: 2}} {
+			want := test.z.Evaluate(p)
+			if got := alongZ.Evaluate(p); math.Abs(got-want) > 1e-12 {
+				t.Errorf("got %g at %v along Z, want %g", got, p, want)
+			}
+			q := r3.Add(r3.Add(r3.Scale(p.X, u), r3.Scale(p.Y, v)), r3.Scale(p.Z, axis))
+			if got := tilted.Evaluate(q); math.Abs(got-want) > 1e-12 {
+				t.Errorf("got %g at %v along %v, want %g", got, q, axis, want)
+			}
+		}
+		// The bounding box touches the surface: the solid reaches
+		// each face of the box but not a bit beyond it.
+		bb := tilted.Bounds()
+		const n = 200
+		lo, hi := [3]float64{bb.Min.X, bb.Min.Y, bb.Min.Z}, [3]float64{bb.Max.X, bb.Max.Y, bb.Max.Z}
+		vec := func(c [3]float64) r3.Vec { return r3.Vec{X: c[0], Y: c[1], Z: c[2]} }
+		for axis := 0; axis < 3; axis++ {
+			a, b := (axis+1)%3, (axis+2)%3
+			onFace, beyond := math.Inf(1), math.Inf(1)
+			for i := 0; i <= n; i++ {
+				for j := 0; j <= n; j++ {
+					var c [3]float64
+					c[axis] = hi[axis]
+					c[a] = lo[a] + (hi[a]-lo[a])*float64(i)/n
+					c[b] = lo[b] + (hi[b]-lo[b])*float64(j)/n
+					onFace = math.Min(onFace, tilted.Evaluate(vec(c)))
+					c[axis] += 0.01
+					beyond = math.Min(beyond, tilted.Evaluate(vec(c)))
+				}
+			}
+			if onFace > 0.01 || beyond <= 0 {
+				t.Errorf("axis %d: got minimum %g on box face and %g beyond, want about 0 and positive", axis, onFace, beyond)
+			}
+		}
+	}
+	if _, err := form3.Frustum(r3.Vec{}, 2, 1, 1, 0); err == nil {
+		t.Error("expected error for zero axis")
+	}
+}
+
+func TestTorus(t *testing.T) {
+	const major, minor = 2, 0.5
+	torus, err := form3.Torus(major, minor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := r3.Box{Min: r3.Vec{X: -2.5, Y: -2.5, Z: -0.5}, Max: r3.Vec{X: 2.5, Y: 2.5, Z: 0.5}}
+	if torus.Bounds() != want {
+		t.Errorf("got bounds %v, want %v", torus.Bounds(), want)
+	}
+	// Sample the surface densely and compare against the nearest sample.
+	const n = 400
+	surface := make([]r3.Vec, 0, n*n/4)
+	for i := 0; i < n; i++ {
+		theta := 2 * math.Pi * float64(i) / n
+		for j := 0; j < n/4; j++ {
+			phi := 2 * math.Pi * float64(j) / (n / 4)
+			r := major + minor*math.Cos(phi)
+			surface = append(surface, r3.Vec{X: r * math.Cos(theta), Y: r * math.Sin(theta), Z: minor * math.Sin(phi)})
+		}
+	}
+	rng := rand.New(rand.NewSource(1))
+	for k := 0; k < 50; k++ {
+		p := r3.Vec{X: 6*rng.Float64() - 3, Y: 6*rng.Float64() - 3, Z: 2*rng.Float64() - 1}
+		nearest := math.Inf(1)
+		for _, q := range surface {
+			nearest = math.Min(nearest, r3.Norm(r3.Sub(p, q)))
+		}
+		got := torus.Evaluate(p)
+		if math.Abs(math.Abs(got)-nearest) > 0.02 {
+			t.Errorf("point %v: got %g, want distance %g to sampled surface", p, got, nearest)
+		}
+	}
+	for _, radii := range [][2]float64{{0, 1}, {2, 0}, {1, 1}, {1, 2}} {
+		if _, err := form3.Torus(radii[0], radii[1]); err == nil {
+			t.Errorf("radii %v: expected error", radii)
+		}
+	}
+}
+
+func TestTorusLp(t *testing.T) {
+	const major, minor = 2, 0.5
+	for _, p := range []float64{0.5, math.NaN(), math.Inf(1)} {
+		if _, err := form3.TorusLp(major, minor, p); err == nil {
+			t.Errorf("p=%g: expected error", p)
+		}
+	}
+	if _, err := form3.TorusLp(1, 2, 2); err == nil {
+		t.Error("expected error for minor radius larger than major")
+	}
+	torus, _ := form3.Torus(major, minor)
+	round, _ := form3.TorusLp(major, minor, 2)
+	below, _ := form3.TorusLp(major, minor, 2-1e-9)
+	above, _ := form3.TorusLp(major, minor, 2+1e-9)
+	if round.Bounds() != torus.Bounds() {
+		t.Errorf("got bounds %v, want %v", round.Bounds(), torus.Bounds())
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := r3.Vec{X: 6*rng.Float64() - 3, Y: 6*rng.Float64() - 3, Z: 2*rng.Float64() - 1}
+		want := torus.Evaluate(p)
+		if got := round.Evaluate(p); got != want {
+			t.Fatalf("p=2 at %v: got %g, want torus %g", p, got, want)
+		}
+		// Continuous in p on both sides of 2.
+		if got := below.Evaluate(p); math.Abs(got-want) > 1e-8 {
+			t.Fatalf("p=2-1e-9 at %v: got %g, want torus %g", p, got, want)
+		}
+		if got := above.Evaluate(p); math.Abs(got-want) > 1e-8 {
+			t.Fatalf("p=2+1e-9 at %v: got %g, want torus %g", p, got, want)
+		}
+	}
+	// Large exponents square the tube, filling the corners of its cross section.
+	square, _ := form3.TorusLp(major, minor, 8)
+	corner := r3.Vec{X: major + 0.9*minor, Z: 0.9 * minor}
+	if square.Evaluate(corner) >= 0 || torus.Evaluate(corner) <= 0 {
+		t.Errorf("cross section corner %v not filled by square tube", corner)
+	}
+	// The field never overestimates distances: its gradient is at most 1.
+	for _, p := range []float64{1, 1.5, 8, 100} {
+		s, err := form3.TorusLp(major, minor, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			a := r3.Vec{X: 6*rng.Float64() - 3, Y: 6*rng.Float64() - 3, Z: 2*rng.Float64() - 1}
+			b := r3.Add(a, r3.Scale(0.01, r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}))
+			if diff, dist := math.Abs(s.Evaluate(a)-s.Evaluate(b)), r3.Norm(r3.Sub(a, b)); diff > dist*(1+1e-9) {
+				t.Fatalf("p=%g: field changes by %g over %g between %v and %v", p, diff, dist, a, b)
+			}
+		}
+	}
+}
+
+func TestEllipsoid(t *testing.T) {
+	radii := r3.Vec{X: 3, Y: 1, Z: 0.5}
+	e, err := form3.Ellipsoid(radii)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := e.Bounds(); bb.Max != radii || bb.Min != r3.Scale(-1, radii) {
+		t.Errorf("got bounds %v", bb)
+	}
+	// Bisect the zero crossing along many directions and compare
+	// it against the true surface along the same direction.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		dir := r3.Unit(r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()})
+		q := r3.Vec{X: dir.X / radii.X, Y: dir.Y / radii.Y, Z: dir.Z / radii.Z}
+		want := 1 / r3.Norm(q)
+		lo, hi := 0.0, 4.0
+		for j := 0; j < 60; j++ {
+			mid := (lo + hi) / 2
+			if e.Evaluate(r3.Scale(mid, dir)) < 0 {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		if math.Abs(lo-want) > 1e-9 {
+			t.Errorf("direction %v: got surface at %g, want %g", dir, lo, want)
+		}
+	}
+	if d := e.Evaluate(r3.Vec{}); d != -0.5 {
+		t.Errorf("got %g at center, want -0.5", d)
+	}
+	if _, err := form3.Ellipsoid(r3.Vec{X: 1, Y: 0, Z: 1}); err == nil {
+		t.Error("expected error for zero radius")
+	}
+}
+
+func TestOctahedron(t *testing.T) {
+	const s, tol = 2.0, 1e-12
+	o, err := form3.Octahedron(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := o.Bounds(); bb.Max != (r3.Vec{X: s, Y: s, Z: s}) || bb.Min != (r3.Vec{X: -s, Y: -s, Z: -s}) {
+		t.Errorf("got bounds %v", bb)
+	}
+	if d := o.Evaluate(r3.Vec{}); math.Abs(d+s/math.Sqrt(3)) > tol {
+		t.Errorf("got %g at center, want %g", d, -s/math.Sqrt(3))
+	}
+	// Moving away from a surface point along a direction within the
+	// normal cone of its feature increases the distance by the same amount.
+	for _, test := range []struct {
+		name       string
+		point, dir r3.Vec
+	}{
+		{name: "face center", point: r3.Vec{X: s / 3, Y: s / 3, Z: s / 3}, dir: r3.Vec{X: 1, Y: 1, Z: 1}},
+		{name: "face center", point: r3.Vec{X: -s / 3, Y: s / 3, Z: -s / 3}, dir: r3.Vec{X: -1, Y: 1, Z: -1}},
+		{name: "edge midpoint", point: r3.Vec{X: s / 2, Y: s / 2}, dir: r3.Vec{X: 1, Y: 1}},
+		{name: "edge midpoint", point: r3.Vec{Y: -s / 2, Z: s / 2}, dir: r3.Vec{Y: -1, Z: 1}},
+		{name: "vertex", point: r3.Vec{X: s}, dir: r3.Vec{X: 1}},
+		{name: "vertex", point: r3.Vec{Z: -s}, dir: r3.Vec{X: 0.1, Y: 0.2, Z: -1}},
+	} {
+		for _, d := range []float64{0, 0.1, 1, 5} {
+			p := r3.Add(test.point, r3.Scale(d, r3.Unit(test.dir)))
+			if got := o.Evaluate(p); math.Abs(got-d) > tol {
+				t.Errorf("%s %v: got %g at distance %g", test.name, test.point, got, d)
+			}
+		}
+	}
+	// Inside, the distance to the face is exact near face centers.
+	n := r3.Unit(r3.Vec{X: 1, Y: 1, Z: 1})
+	if got := o.Evaluate(r3.Sub(r3.Vec{X: s / 3, Y: s / 3, Z: s / 3}, r3.Scale(0.2, n))); math.Abs(got+0.2) > tol {
+		t.Errorf("got %g inside face, want -0.2", got)
+	}
+	if _, err := form3.Octahedron(0); err == nil {
+		t.Error("expected error for s = 0")
+	}
+}
+
+func TestTetrahedron(t *testing.T) {
+	if _, err := form3.Tetrahedron(0); err == nil {
+		t.Error("expected error for s = 0")
+	}
+	const s = 2
+	tet, err := form3.Tetrahedron(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verts := []r3.Vec{{X: s, Y: s, Z: s}, {X: s, Y: -s, Z: -s}, {X: -s, Y: s, Z: -s}, {X: -s, Y: -s, Z: s}}
+	for i, v := range verts {
+		if got := tet.Evaluate(v); math.Abs(got) > 1e-12 {
+			t.Errorf("vertex %v: got %g, want 0", v, got)
+		}
+		// The centroid of the face opposite v is -v/3.
+		if got := tet.Evaluate(r3.Scale(-1.0/3, v)); math.Abs(got) > 1e-12 {
+			t.Errorf("face %d centroid: got %g, want 0", i, got)
+		}
+		// Beyond a vertex the nearest point is the vertex.
+		if got, want := tet.Evaluate(r3.Scale(2, v)), s*math.Sqrt(3); math.Abs(got-want) > 1e-12 {
+			t.Errorf("beyond vertex %v: got %g, want %g", v, got, want)
+		}
+	}
+	inradius := s / math.Sqrt(3)
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		{"centroid", r3.Vec{}, -inradius},
+		{"beyond face", r3.Scale(-2.0/3, verts[0]), inradius},
+		// Edge from verts[0] to verts[1] has its midpoint on +X.
+		{"beyond edge", r3.Vec{X: s + 1}, 1},
+	} {
+		if got := tet.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: got %g at %v, want %g", test.name, got, test.p, test.want)
+		}
+	}
+	want := r3.Box{Min: r3.Vec{X: -s, Y: -s, Z: -s}, Max: r3.Vec{X: s, Y: s, Z: s}}
+	if got := tet.Bounds(); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+}
+
+func TestPyramid(t *testing.T) {
+	const w, h, tol = 2.0, 3.0, 1e-12
+	p, err := form3.Pyramid(w, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := p.Bounds(); bb.Min != (r3.Vec{X: -w / 2, Y: -w / 2}) || bb.Max != (r3.Vec{X: w / 2, Y: w / 2, Z: h}) {
+		t.Errorf("got bounds %v", bb)
+	}
+	type probe struct {
+		name  string
+		point r3.Vec
+		want  float64
+	}
+	probes := []probe{
+		{name: "above apex", point: r3.Vec{Z: h + 0.5}, want: 0.5},
+		{name: "below base", point: r3.Vec{X: 0.3, Y: -0.2, Z: -0.4}, want: 0.4},
+		{name: "below base corner", point: r3.Vec{X: w/2 + 0.3, Y: w/2 + 0.4, Z: -1}, want: math.Sqrt(0.09 + 0.16 + 1)},
+		{name: "base center", point: r3.Vec{}, want: 0},
+		{name: "inside near base", point: r3.Vec{X: 0.1, Z: 0.05}, want: -0.05},
+	}
+	// Points just outside the center of each triangular face.
+	for _, dir := range []r3.Vec{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}} {
+		onFace := r3.Add(r3.Scale(w/3, dir), r3.Vec{Z: h / 3})
+		normal := r3.Unit(r3.Add(r3.Scale(h, dir), r3.Vec{Z: w / 2}))
+		for _, d := range []float64{0.01, 0.2, -0.1} {
+			probes = append(probes, probe{name: "face", point: r3.Add(onFace, r3.Scale(d, normal)), want: d})
+		}
+	}
+	for _, test := range probes {
+		if got := p.Evaluate(test.point); math.Abs(got-test.want) > tol {
+			t.Errorf("%s %v: got %g, want %g", test.name, test.point, got, test.want)
+		}
+	}
+	if _, err := form3.Pyramid(1, 0); err == nil {
+		t.Error("expected error for zero height")
+	}
+}
+
+func TestHexagonalPrism(t *testing.T) {
+	const flats, height = 2.0, 1.5
+	hex, err := form3.HexagonalPrism(flats, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circumradius := flats / math.Sqrt(3)
+	bb := hex.Bounds()
+	if math.Abs(bb.Max.X-circumradius) > 1e-12 || bb.Max.Y != flats/2 || bb.Max.Z != height/2 {
+		t.Errorf("got bounds %v", bb)
+	}
+	// Sample the six faces and two caps densely and compare against
+	// the distance to the nearest sample.
+	var vertices [6]r2.Vec
+	for i := range vertices {
+		sin, cos := math.Sincos(float64(i) * math.Pi / 3)
+		vertices[i] = r2.Vec{X: circumradius * cos, Y: circumradius * sin}
+	}
+	const n = 60
+	var surface []r3.Vec
+	for i := 0; i < 6; i++ {
+		a, b := vertices[i], vertices[(i+1)%6]
+		for j := 0; j <= n; j++ {
+			e := r2.Add(a, r2.Scale(float64(j)/n, r2.Sub(b, a)))
+			for k := 0; k <= n; k++ {
+				surface = append(surface, r3.Vec{X: e.X, Y: e.Y, Z: height * (float64(k)/n - 0.5)})
+			}
+		}
+	}
+	for j := 0; j <= n; j++ {
+		for k := 0; k <= n; k++ {
+			p := r2.Vec{X: circumradius * (2*float64(j)/n - 1), Y: flats / 2 * (2*float64(k)/n - 1)}
+			if math.Abs(p.Y) > math.Sqrt(3)*(circumradius-math.Abs(p.X)) {
+				continue // outside hexagon.
+			}
+			surface = append(surface, r3.Vec{X: p.X, Y: p.Y, Z: height / 2}, r3.Vec{X: p.X, Y: p.Y, Z: -height / 2})
+		}
+	}
+	// Largest spacing between samples bounds the brute force error.
+	tol := circumradius / n
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		p := r3.Vec{X: 4*rng.Float64() - 2, Y: 4*rng.Float64() - 2, Z: 3*rng.Float64() - 1.5}
+		want := math.Inf(1)
+		for _, q := range surface {
+			want = math.Min(want, r3.Norm(r3.Sub(p, q)))
+		}
+		got := hex.Evaluate(p)
+		if math.Abs(math.Abs(got)-want) > tol {
+			t.Errorf("point %v: got distance %g, want %g", p, got, want)
+		}
+		inside := math.Abs(p.Z) < height/2 && math.Abs(p.Y) < flats/2 &&
+			math.Abs(p.Y) < math.Sqrt(3)*(circumradius-math.Abs(p.X))
+		if (got < 0) != inside && want > tol {
+			t.Errorf("point %v: got distance %g, wrong sign", p, got)
+		}
+	}
+	if _, err := form3.HexagonalPrism(-1, 1); err == nil {
+		t.Error("expected error for negative acrossFlats")
+	}
+}
+
+func TestTriangularPrism(t *testing.T) {
+	const side, height, tol = 2.0, 3.0, 1e-12
+	tri, err := form3.TriangularPrism(side, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	circumradius := side / math.Sqrt(3)
+	inradius := circumradius / 2
+	bb := tri.Bounds()
+	if bb.Min.X != -side/2 || bb.Max.X != side/2 || math.Abs(bb.Min.Y+inradius) > tol ||
+		math.Abs(bb.Max.Y-circumradius) > tol || bb.Max.Z != height/2 {
+		t.Errorf("got bounds %v", bb)
+	}
+	if d := tri.Evaluate(r3.Vec{}); math.Abs(d+inradius) > tol {
+		t.Errorf("got %g at centroid, want %g", d, -inradius)
+	}
+	if d := tri.Evaluate(r3.Vec{Z: height/2 + 0.5}); math.Abs(d-0.5) > tol {
+		t.Errorf("got %g above centroid, want 0.5", d)
+	}
+	for i := 0; i < 3; i++ {
+		sin, cos := math.Sincos(math.Pi/2 + float64(i)*2*math.Pi/3)
+		dir := r3.Vec{X: cos, Y: sin}
+		vertex := r3.Scale(circumradius, dir)
+		for _, test := range []struct {
+			p    r3.Vec
+			want float64
+		}{
+			{p: vertex, want: 0},
+			{p: r3.Add(vertex, r3.Vec{Z: height / 2}), want: 0},
+			{p: r3.Add(vertex, r3.Scale(0.3, dir)), want: 0.3},
+			{p: r3.Add(vertex, r3.Vec{Z: -height/2 - 0.4}), want: 0.4},
+			// Towards the centroid the nearest sides are at 30 degrees.
+			{p: r3.Scale(circumradius-0.2, dir), want: -0.1},
+		} {
+			if got := tri.Evaluate(test.p); math.Abs(got-test.want) > tol {
+				t.Errorf("vertex %d %v: got %g, want %g", i, test.p, got, test.want)
+			}
+		}
+	}
+	if _, err := form3.TriangularPrism(1, 0); err == nil {
+		t.Error("expected error for zero height")
+	}
+}
+
+func TestBoxFrame(t *testing.T) {
+	const thick, tol = 0.4, 1e-12
+	size := r3.Vec{X: 4, Y: 3, Z: 2}
+	half := r3.Scale(0.5, size)
+	frame, err := form3.BoxFrame(size, thick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := frame.Bounds(); bb.Max != half || bb.Min != r3.Scale(-1, half) {
+		t.Errorf("got bounds %v", bb)
+	}
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		{name: "corner", p: half, want: 0},
+		{name: "outside corner", p: r3.Add(half, r3.Vec{X: 1, Y: 1, Z: 1}), want: math.Sqrt(3)},
+		{name: "bar center", p: r3.Vec{Y: half.Y - thick/2, Z: half.Z - thick/2}, want: -thick / 2},
+		{name: "outside bar", p: r3.Vec{Y: half.Y + 0.5, Z: half.Z}, want: 0.5},
+		// Nearest bars are those along X on the Z face, at the smaller distance along Y.
+		{name: "face center", p: r3.Vec{Z: half.Z + 1}, want: math.Hypot(half.Y-thick, 1)},
+		{name: "center", p: r3.Vec{}, want: math.Hypot(half.Y-thick, half.Z-thick)},
+		{name: "inside face", p: r3.Vec{Z: half.Z - thick/2}, want: half.Y - thick},
+	} {
+		if got := frame.Evaluate(test.p); math.Abs(got-test.want) > tol {
+			t.Errorf("%s %v: got %g, want %g", test.name, test.p, got, test.want)
+		}
+	}
+	// Faces of the box are open and members are thick at the midpoints of all 12 edges.
+	for axis := 0; axis < 3; axis++ {
+		for _, sign := range []float64{-1, 1} {
+			face := r3Component(axis, sign*r3Index(half, axis))
+			if d := frame.Evaluate(face); d <= 0 {
+				t.Errorf("face center %v is not empty, got %g", face, d)
+			}
+			for _, sign2 := range []float64{-1, 1} {
+				// Midpoint of the edge between this face and the next along axis+1.
+				other := (axis + 1) % 3
+				mid := r3.Add(face, r3Component(other, sign2*r3Index(half, other)))
+				inward := r3.Scale(-1, r3.Add(r3Component(axis, sign), r3Component(other, sign2)))
+				for _, test := range []struct {
+					depth, want float64
+				}{
+					{depth: thick / 2, want: -thick / 2},
+					{depth: thick, want: 0},
+					{depth: thick + 0.1, want: 0.1 * math.Sqrt2},
+				} {
+					// Move inward along both faces by depth.
+					p := r3.Add(mid, r3.Scale(test.depth, inward))
+					if got := frame.Evaluate(p); math.Abs(got-test.want) > tol {
+						t.Errorf("edge midpoint %v at depth %g: got %g, want %g", mid, test.depth, got, test.want)
+					}
+				}
+			}
+		}
+	}
+	if _, err := form3.BoxFrame(size, 1); err == nil {
+		t.Error("expected error for thickness of half the smallest side")
+	}
+}
+
+func TestCappedTorus(t *testing.T) {
+	const major, minor, tol = 2.0, 0.5, 1e-12
+	angle := math.Pi / 3
+	ct, err := form3.CappedTorus(major, minor, angle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Centers of the caps at the ends of the arc.
+	sin, cos := math.Sincos(angle)
+	caps := []r3.Vec{{X: major * sin, Y: major * cos}, {X: -major * sin, Y: major * cos}}
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		{name: "tube center", p: r3.Vec{Y: major}, want: -minor},
+		{name: "above tube", p: r3.Vec{Y: major, Z: 1}, want: 1 - minor},
+		{name: "inside arc", p: r3.Vec{X: major * math.Sin(angle/2), Y: major * math.Cos(angle/2), Z: 0.2}, want: 0.2 - minor},
+		{name: "cap center", p: caps[0], want: -minor},
+		// Rounded caps contain points past the end of the arc.
+		{name: "inside rounded cap", p: r3.Add(caps[1], r3.Vec{X: -0.3 * cos, Y: -0.3 * sin}), want: 0.3 - minor},
+		{name: "outside cap", p: r3.Add(caps[0], r3.Vec{X: 0.7 * cos, Y: -0.7 * sin, Z: 0.4}), want: math.Hypot(0.7, 0.4) - minor},
+	} {
+		if got := ct.Evaluate(test.p); math.Abs(got-test.want) > tol {
+			t.Errorf("%s %v: got %g, want %g", test.name, test.p, got, test.want)
+		}
+	}
+	// Outside the swept arc the distance is that to the nearest cap.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		theta := angle + rng.Float64()*(2*math.Pi-2*angle) // from +Y.
+		r := 3 * rng.Float64()
+		p := r3.Vec{X: r * math.Sin(theta), Y: r * math.Cos(theta), Z: 2*rng.Float64() - 1}
+		want := math.Min(r3.Norm(r3.Sub(p, caps[0])), r3.Norm(r3.Sub(p, caps[1]))) - minor
+		if got := ct.Evaluate(p); math.Abs(got-want) > 1e-9 {
+			t.Errorf("point %v outside arc: got %g, want %g", p, got, want)
+		}
+	}
+	if full, _ := form3.CappedTorus(major, minor, math.Pi); math.Abs(full.Evaluate(r3.Vec{Y: -major})+minor) > tol {
+		t.Error("capped torus with angle π is not closed")
+	}
+	if _, err := form3.CappedTorus(major, minor, 4); err == nil {
+		t.Error("expected error for angle larger than π")
+	}
+}
+
+func TestLink(t *testing.T) {
+	const length, major, minor, pitch = 2.0, 1.0, 0.25, 3.0
+	a, err := form3.Link(length, major, minor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bb := a.Bounds(); bb.Max != (r3.Vec{X: major + minor, Y: length/2 + major + minor, Z: minor}) {
+		t.Errorf("got bounds %v", bb)
+	}
+	// The next link is turned a quarter turn about Y and moved along Y.
+	b := sdf.Transform3D(a, sdf.Translate3D(r3.Vec{Y: pitch}).Mul(sdf.RotateY(math.Pi/2)))
+	chain := sdf.Array3D(sdf.Union3D(a, b), sdf.V3i{1, 3, 1}, r3.Vec{Y: 2 * pitch})
+	// inHole returns true if p lies in the plane of a link within its hole.
+	inHole := func(p r3.Vec) bool {
+		return p.Z == 0 && math.Hypot(p.X, math.Max(math.Abs(p.Y)-length/2, 0)) < major-minor
+	}
+	// The end of each link passes through the hole of the other.
+	endB := r3.Vec{Y: pitch - length/2 - major}
+	if !inHole(endB) || a.Evaluate(endB) <= 0 || b.Evaluate(endB) >= 0 {
+		t.Errorf("end of second link at %v does not pass through first link", endB)
+	}
+	endA := r3.Vec{Y: length/2 + major}
+	if !inHole(r3.Vec{X: endA.Z, Y: endA.Y - pitch}) || b.Evaluate(endA) <= 0 || a.Evaluate(endA) >= 0 {
+		t.Errorf("end of first link at %v does not pass through second link", endA)
+	}
+	// The links do not touch.
+	bb := b.Bounds()
+	const n = 40
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			for k := 0; k <= n; k++ {
+				p := r3.Add(bb.Min, r3.Vec{
+					X: float64(i) / n * (bb.Max.X - bb.Min.X),
+					Y: float64(j) / n * (bb.Max.Y - bb.Min.Y),
+					Z: float64(k) / n * (bb.Max.Z - bb.Min.Z),
+				})
+				if a.Evaluate(p) < 0 && b.Evaluate(p) < 0 {
+					t.Fatalf("links intersect at %v", p)
+				}
+			}
+		}
+	}
+	// Every link of the chain is solid.
+	for i := 0; i < 6; i++ {
+		end := r3.Vec{Y: float64(i)*pitch + length/2 + major}
+		if i%2 == 1 {
+			end = r3.Vec{Y: float64(i)*pitch + length/2, Z: major}
+		}
+		if d := chain.Evaluate(end); d >= 0 {
+			t.Errorf("link %d of chain missing at %v, got %g", i, end, d)
+		}
+	}
+	if _, err := form3.Link(-1, major, minor); err == nil {
+		t.Error("expected error for negative length")
+	}
+}
+
+func TestWedge(t *testing.T) {
+	if _, err := form3.Wedge(0, 1, 1); err == nil {
+		t.Error("expected error for zero run")
+	}
+	const run, rise, width = 4, 3, 2
+	w, err := form3.Wedge(run, rise, width)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := r3.Box{Min: r3.Vec{Y: -width / 2}, Max: r3.Vec{X: run, Y: width / 2, Z: rise}}
+	if got := w.Bounds(); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	// Sample along the hypotenuse, on it and offset along its outward normal.
+	n := r3.Vec{X: rise / 5., Z: run / 5.}
+	for i := 1; i < 10; i++ {
+		f := float64(i) / 10
+		p := r3.Vec{X: f * run, Y: 0.3, Z: (1 - f) * rise}
+		for _, off := range []float64{0, 0.05, 0.5, -0.05} {
+			q := r3.Add(p, r3.Scale(off, n))
+			if got := w.Evaluate(q); math.Abs(got-off) > 1e-12 {
+				t.Errorf("got %g at %v, want %g", got, q, off)
+			}
+		}
+	}
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		{"above apex", r3.Vec{Z: rise + 1}, 1},
+		{"past toe", r3.Vec{X: run + 1, Z: -1}, math.Sqrt2},
+		{"below base", r3.Vec{X: 1, Z: -0.5}, 0.5},
+		{"behind back", r3.Vec{X: -0.5, Z: 1}, 0.5},
+		{"beside", r3.Vec{X: 1, Y: width/2 + 0.25, Z: 1}, 0.25},
+		{"inside", r3.Vec{X: 1, Y: 0, Z: 0.5}, -0.5},
+	} {
+		if got := w.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: got %g at %v, want %g", test.name, got, test.p, test.want)
+		}
+	}
+}
+
+func TestSolidAngle(t *testing.T) {
+	for _, angle := range []float64{0, math.Pi} {
+		if _, err := form3.SolidAngle(angle, 1); err == nil {
+			t.Errorf("expected error for angle %g", angle)
+		}
+	}
+	if _, err := form3.SolidAngle(1, 0); err == nil {
+		t.Error("expected error for zero radius")
+	}
+	const angle, radius = math.Pi / 6, 2
+	s, err := form3.SolidAngle(angle, radius)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sin, cos := math.Sincos(angle)
+	rim := r3.Vec{X: radius * sin, Z: radius * cos}
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		// On the axis the cone side is nearer than the cap.
+		{"inside", r3.Vec{Z: 1}, -sin},
+		{"inside near cap", r3.Vec{Z: 1.9}, -0.1},
+		{"apex", r3.Vec{}, 0},
+		// Outside the cone but within the radius the nearest point is on the side.
+		{"outside cone", r3.Vec{X: 1}, cos},
+		{"outside cone rotated", r3.Vec{Y: -1}, cos},
+		{"beyond cap", r3.Vec{Z: 3}, 1},
+		{"beyond cap off axis", r3.Scale(3, r3.Vec{X: math.Sin(angle / 2), Z: math.Cos(angle / 2)}), 1},
+		{"beyond rim", r3.Scale(1.5, rim), 1},
+		{"below apex", r3.Vec{Z: -1}, 1},
+	} {
+		if got := s.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: got %g at %v, want %g", test.name, got, test.p, test.want)
+		}
+	}
+	want := r3.Box{Min: r3.Vec{X: -rim.X, Y: -rim.X}, Max: r3.Vec{X: rim.X, Y: rim.X, Z: radius}}
+	if got := s.Bounds(); r3.Norm(r3.Sub(got.Min, want.Min)) > 1e-12 || r3.Norm(r3.Sub(got.Max, want.Max)) > 1e-12 {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	// Wide angles wrap around the sphere below the apex.
+	wide, _ := form3.SolidAngle(2*math.Pi/3, radius)
+	if got := wide.Bounds(); math.Abs(got.Min.Z+radius/2) > 1e-12 || got.Max.X != radius {
+		t.Errorf("got wide bounds %v", got)
+	}
+}
+
+func TestPlane(t *testing.T) {
+	if _, err := form3.Plane(r3.Vec{}, r3.Vec{}); err == nil {
+		t.Error("expected error for zero normal")
+	}
+	// A regular tetrahedron with vertices at alternate corners of a cube. Each face
+	// lies opposite a vertex v with outward normal -v at distance 1/sqrt(3) from the origin.
+	verts := []r3.Vec{{X: 1, Y: 1, Z: 1}, {X: 1, Y: -1, Z: -1}, {X: -1, Y: 1, Z: -1}, {X: -1, Y: -1, Z: 1}}
+	var tetra sdf.SDF3
+	for _, v := range verts {
+		p, err := form3.Plane(r3.Scale(-2, v), r3.Scale(-1.0/3, v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tetra == nil {
+			tetra = p
+		} else {
+			tetra = sdf.Intersect3D(tetra, p)
+		}
+	}
+	inradius := 1 / math.Sqrt(3)
+	for _, test := range []struct {
+		name string
+		p    r3.Vec
+		want float64
+	}{
+		{"center", r3.Vec{}, -inradius},
+		{"vertex", verts[0], 0},
+		{"edge", r3.Vec{X: 1}, 0},
+		// The inside distance is the distance to the nearest face.
+		{"inside", r3.Scale(0.5, verts[0]), -inradius / 2},
+		// Along a face normal outside the face the nearest point is on the face.
+		{"outside face", r3.Scale(-2*inradius/math.Sqrt(3), verts[0]), inradius},
+	} {
+		if got := tetra.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: got %g at %v, want %g", test.name, got, test.p, test.want)
+		}
+	}
+	// Plane bounds are unbounded, so the tetrahedron box must be found by sampling.
+	bb := sdf.AutoBox3D(tetra, r3.Box{Min: r3.Vec{X: -2, Y: -2, Z: -2}, Max: r3.Vec{X: 2, Y: 2, Z: 2}}, 40).Bounds()
+	for _, v := range verts {
+		if v.X < bb.Min.X || v.Y < bb.Min.Y || v.Z < bb.Min.Z || v.X > bb.Max.X || v.Y > bb.Max.Y || v.Z > bb.Max.Z {
+			t.Errorf("vertex %v outside bounds %v", v, bb)
+		}
+	}
+	if bb.Max.X > 1.2 || bb.Min.X < -1.2 {
+		t.Errorf("got loose bounds %v", bb)
+	}
+}
+
+// r3Component returns the vector with component axis set to v.
+func r3Component(axis int, v float64) r3.Vec {
+	switch axis {
+	case 0:
+		return r3.Vec{X: v}
+	case 1:
+		return r3.Vec{Y: v}
+	}
+	return r3.Vec{Z: v}
+}
+
+// r3Index returns component axis of v.
+func r3Index(v r3.Vec, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	}
+	return v.Z
+}
@@ -45,6 +45,20 @@ func (s *box) Bounds() r3.Box {
 	return s.bb
 }
 
+// Describe returns kind "box" with the full box size
+// "size_x", "size_y" and "size_z" and the "round" radius.
+func (s *box) Describe() (string, map[string]float64) {
+	return "box", map[string]float64{
+		"size_x": 2 * (s.size.X + s.round),
+		"size_y": 2 * (s.size.Y + s.round),
+		"size_z": 2 * (s.size.Z + s.round),
+		"round":  s.round,
+	}
+}
+
+// IsExact returns true since the distance to a box is exact.
+func (s *box) IsExact() bool { return true }
+
 // Sphere (exact distance field)
 
 // sphere is a sphere.
@@ -76,111 +90,183 @@ func (s *sphere) Bounds() r3.Box {
 	return s.bb
 }
 
-// Cylinder (exact distance field)
+// Describe returns kind "sphere" with its "radius".
+func (s *sphere) Describe() (string, map[string]float64) {
+	return "sphere", map[string]float64{"radius": s.radius}
+}
 
-// cylinder is a cylinder.
-type cylinder struct {
-	height float64
+// IsExact returns true since the distance to a sphere is exact.
+func (s *sphere) IsExact() bool { return true }
+
+// Modulated Sphere (approximate distance field)
+
+// modulatedSphere is a sphere with a radius which varies with direction.
+type modulatedSphere struct {
 	radius float64
-	round  float64
+	radial func(theta, phi float64) float64
 	bb     r3.Box
 }
 
-// Cylinder return an SDF3 for a cylinder (rounded edges with round > 0).
-func Cylinder(height, radius, round float64) *cylinder {
-	if radius <= 0 {
-		panic("radius <= 0")
+// ModulatedSphere returns an SDF3 for a sphere of radius baseRadius+radial(theta, phi)
+// in the direction with polar angle theta from the Z axis and azimuth phi from
+// the X axis, for example a star or a spherical harmonic. maxRadius must be the
+// largest radius and is used for the bounding box.
+//
+// The field is |p|-radius, which is only an approximate distance. Where the radius
+// changes quickly with direction the field overestimates the distance by a factor of
+// sqrt(1 + |∇radius|²/radius²), where ∇radius is the angular gradient
+// (∂radius/∂theta, ∂radius/∂phi / sin(theta)). Keep the radius variation
+// smooth, or scale the SDF3 down by that factor, for reliable rendering.
+func ModulatedSphere(baseRadius float64, radial func(theta, phi float64) float64, maxRadius float64) *modulatedSphere {
+	if radial == nil {
+		panic("nil radial function")
 	}
-	if round < 0 {
-		panic("round < 0")
+	if maxRadius <= 0 || maxRadius < baseRadius {
+		panic("maxRadius must be positive and not smaller than baseRadius")
 	}
-	if round > radius {
-		panic("round > radius")
-	}
-	if height < 2.0*round {
-		panic("height < 2 * round")
+	d := r3.Vec{X: maxRadius, Y: maxRadius, Z: maxRadius}
+	return &modulatedSphere{
+		radius: baseRadius,
+		radial: radial,
+		bb:     r3.Box{Min: r3.Scale(-1, d), Max: d},
 	}
-	s := cylinder{}
-	s.height = (height / 2) - round
-	s.radius = radius - round
-	s.round = round
-	d := r3.Vec{radius, radius, height / 2}
-	s.bb = r3.Box{r3.Scale(-1, d), d}
-	return &s
 }
 
-// Evaluate returns the minimum distance to a cylinder.
-func (s *cylinder) Evaluate(p r3.Vec) float64 {
-	d := sdfBox2d(r2.Vec{math.Hypot(p.X, p.Y), p.Z}, r2.Vec{s.radius, s.height})
-	return d - s.round
+// Evaluate returns the approximate minimum distance to a modulated sphere.
+func (s *modulatedSphere) Evaluate(p r3.Vec) float64 {
+	r := r3.Norm(p)
+	var theta, phi float64
+	if r > 0 {
+		theta = math.Acos(p.Z / r)
+		phi = math.Atan2(p.Y, p.X)
+	}
+	return r - s.radius - s.radial(theta, phi)
 }
 
-// BoundingBox returns the bounding box for a cylinder.
-func (s *cylinder) Bounds() r3.Box {
+// BoundingBox returns the bounding box for a modulated sphere.
+func (s *modulatedSphere) Bounds() r3.Box {
 	return s.bb
 }
 
-// Truncated Cone (exact distance field)
+// Describe returns kind "modulated_sphere" with its "base_radius" and "max_radius".
+func (s *modulatedSphere) Describe() (string, map[string]float64) {
+	return "modulated_sphere", map[string]float64{
+		"base_radius": s.radius,
+		"max_radius":  s.bb.Max.X,
+	}
+}
 
-// cone is a truncated cone.
-type cone struct {
+// Frustum (exact distance field)
+
+// frustum is a truncated cone along an arbitrary axis. It is the shared
+// implementation of cylinders and cones, which are frustums along Z.
+type frustum struct {
+	a      r3.Vec  // unit axis
 	r0     float64 // base radius
 	r1     float64 // top radius
 	height float64 // half height
+	z0, z1 float64 // inset base and top heights, within ±height for pointed ends
 	round  float64 // rounding offset
-	u      r2.Vec  // normalized cone slope vector
-	n      r2.Vec  // normal to cone slope (points outward)
-	l      float64 // length of cone slope
+	u      r2.Vec  // normalized slope vector
+	n      r2.Vec  // normal to slope (points outward)
+	l      float64 // length of slope
 	bb     r3.Box  // bounding box
 }
 
-// Cone returns the SDF3 for a trucated cone (round > 0 gives rounded edges).
-func Cone(height, r0, r1, round float64) *cone {
-	if height <= 0 {
-		panic("height <= 0")
-	}
+// newFrustum returns a frustum of the given height along the unit axis a with
+// radius r0 at its base and r1 at its top, rounded with radius round.
+func newFrustum(a r3.Vec, height, r0, r1, round float64) frustum {
 	if round < 0 {
 		panic("round < 0")
 	}
 	if height < 2.0*round {
 		panic("height < 2 * round")
 	}
-	s := cone{}
+	s := frustum{a: a}
 	s.height = (height / 2) - round
 	s.round = round
-	// cone slope vector and normal
+	// slope vector and normal
 	s.u = r2.Unit(r2.Vec{r1, height / 2}.Sub(r2.Vec{r0, -height / 2}))
 	s.n = r2.Vec{s.u.Y, -s.u.X}
 	// inset the radii for the rounding
 	ofs := round / s.n.X
 	s.r0 = r0 - (1+s.n.Y)*ofs
 	s.r1 = r1 - (1-s.n.Y)*ofs
-	// cone slope length
-	s.l = r2.Norm(r2.Vec{s.r1, s.height}.Sub(r2.Vec{s.r0, -s.height}))
-	// work out the bounding box
-	r := math.Max(s.r0+round, s.r1+round)
-	s.bb = r3.Box{r3.Vec{-r, -r, -height / 2}, r3.Vec{r, r, height / 2}}
+	s.z0, s.z1 = -s.height, s.height
+	// The inset slope of a pointed end may cross the axis before reaching
+	// its cap. The inset then ends at a point where it crosses the axis.
+	switch {
+	case r1 == 0 && s.r1 < 0 && s.r0 > 0:
+		s.z1 = -s.height + 2*s.height*s.r0/(s.r0-s.r1)
+		s.r1 = 0
+	case r0 == 0 && s.r0 < 0 && s.r1 > 0:
+		s.z0 = s.height - 2*s.height*s.r1/(s.r1-s.r0)
+		s.r0 = 0
+	}
+	if s.r0 < 0 || s.r1 < 0 {
+		panic("round too large for cone radii and slope")
+	}
+	// slope length
+	s.l = r2.Norm(r2.Vec{s.r1, s.z1}.Sub(r2.Vec{s.r0, s.z0}))
+	// A circle of radius r normal to the axis extends r*sqrt(1-a_i^2)
+	// along axis i from its center. The inset solid lies within its cap
+	// circles and is enlarged by round. The caps are inset by round so the
+	// rounded solid does not extend beyond height/2 along the axis.
+	ext := r3.Vec{
+		X: math.Sqrt(math.Max(0, 1-a.X*a.X)),
+		Y: math.Sqrt(math.Max(0, 1-a.Y*a.Y)),
+		Z: math.Sqrt(math.Max(0, 1-a.Z*a.Z)),
+	}
+	c0, c1 := r3.Scale(s.z0, a), r3.Scale(s.z1, a)
+	bb := d3.Box{
+		Min: d3.MinElem(r3.Sub(c0, r3.Scale(s.r0, ext)), r3.Sub(c1, r3.Scale(s.r1, ext))),
+		Max: d3.MaxElem(r3.Add(c0, r3.Scale(s.r0, ext)), r3.Add(c1, r3.Scale(s.r1, ext))),
+	}
+	s.bb = r3.Box(bb.Enlarge(d3.Elem(2 * round)))
+	return s
+}
+
+// Frustum returns the SDF3 for a truncated cone of the given height centered on the
+// origin along axis, with radius r0 at its base, towards -axis, and r1 at its top.
+// Cylinders (r0 == r1) and cones (r0 or r1 zero) are special cases. Edges are rounded
+// with radius round. It is validated as Cylinder or Cone are and its bounding box
+// is tight for any axis.
+func Frustum(axis r3.Vec, height, r0, r1, round float64) sdf.SDF3 {
+	if r3.Norm(axis) == 0 {
+		panic("zero axis")
+	}
+	if r0 < 0 || r1 < 0 {
+		panic("negative radius")
+	}
+	if r0 == r1 && r0 <= 0 {
+		panic("radius <= 0")
+	}
+	if height <= 0 {
+		panic("height <= 0")
+	}
+	s := newFrustum(r3.Unit(axis), height, r0, r1, round)
 	return &s
 }
 
-// Evaluate returns the minimum distance to a trucated cone.
-func (s *cone) Evaluate(p r3.Vec) float64 {
+// Evaluate returns the minimum distance to the frustum.
+func (s *frustum) Evaluate(p r3.Vec) float64 {
 	// convert to SoR 2d coordinates
-	p2 := r2.Vec{math.Hypot(p.X, p.Y), p.Z}
-	// is p2 above the cone?
-	if p2.Y >= s.height && p2.X <= s.r1 {
-		return p2.Y - s.height - s.round
+	z := r3.Dot(p, s.a)
+	p2 := r2.Vec{r3.Norm(r3.Sub(p, r3.Scale(z, s.a))), z}
+	// is p2 above the frustum?
+	if p2.Y >= s.z1 && p2.X <= s.r1 {
+		return p2.Y - s.z1 - s.round
 	}
-	// is p2 below the cone?
-	if p2.Y <= -s.height && p2.X <= s.r0 {
-		return -p2.Y - s.height - s.round
+	// is p2 below the frustum?
+	if p2.Y <= s.z0 && p2.X <= s.r0 {
+		return s.z0 - p2.Y - s.round
 	}
 	// distance to slope line
-	v := p2.Sub(r2.Vec{s.r0, -s.height})
+	v := p2.Sub(r2.Vec{s.r0, s.z0})
 	dSlope := v.Dot(s.n)
-	// is p2 inside the cone?
-	if dSlope < 0 && math.Abs(p2.Y) < s.height {
-		return -math.Min(-dSlope, s.height-math.Abs(p2.Y)) - s.round
+	// is p2 inside the frustum?
+	if dSlope < 0 && p2.Y > s.z0 && p2.Y < s.z1 {
+		return -math.Min(-dSlope, math.Min(s.z1-p2.Y, p2.Y-s.z0)) - s.round
 	}
 	// is p2 closest to the slope line?
 	t := v.Dot(s.u)
@@ -192,14 +278,995 @@ func (s *cone) Evaluate(p r3.Vec) float64 {
 		return r2.Norm(v) - s.round
 	}
 	// p2 is closest to the top radius vertex
-	return r2.Norm(p2.Sub(r2.Vec{s.r1, s.height})) - s.round
+	return r2.Norm(p2.Sub(r2.Vec{s.r1, s.z1})) - s.round
+}
+
+// Bounds returns the bounding box of the frustum.
+func (s *frustum) Bounds() r3.Box {
+	return s.bb
+}
+
+// radii returns the base and top radii of the frustum before
+// they were inset for the rounding.
+func (s *frustum) radii() (r0, r1 float64) {
+	ofs := s.round / s.n.X
+	r0, r1 = s.r0+(1+s.n.Y)*ofs, s.r1+(1-s.n.Y)*ofs
+	// Pointed ends inset past the axis.
+	if s.z0 != -s.height {
+		r0 = 0
+	}
+	if s.z1 != s.height {
+		r1 = 0
+	}
+	return r0, r1
+}
+
+// Describe returns kind "frustum" with its unit "axis", "height",
+// base radius "r0", top radius "r1" and "round" radius.
+func (s *frustum) Describe() (string, map[string]float64) {
+	r0, r1 := s.radii()
+	return "frustum", map[string]float64{
+		"axis_x": s.a.X,
+		"axis_y": s.a.Y,
+		"axis_z": s.a.Z,
+		"height": 2 * (s.height + s.round),
+		"r0":     r0,
+		"r1":     r1,
+		"round":  s.round,
+	}
+}
+
+// IsExact returns true since the distance to a frustum is exact.
+func (s *frustum) IsExact() bool { return true }
+
+// Cylinder (exact distance field)
+
+// cylinder is a cylinder, a frustum along Z with equal radii.
+type cylinder struct {
+	frustum
+}
+
+// Cylinder return an SDF3 for a cylinder (rounded edges with round > 0).
+func Cylinder(height, radius, round float64) *cylinder {
+	if radius <= 0 {
+		panic("radius <= 0")
+	}
+	if round > radius {
+		panic("round > radius")
+	}
+	return &cylinder{newFrustum(r3.Vec{Z: 1}, height, radius, radius, round)}
+}
+
+// Describe returns kind "cylinder" with its "height", "radius" and "round" radius.
+func (s *cylinder) Describe() (string, map[string]float64) {
+	return "cylinder", map[string]float64{
+		"height": 2 * (s.height + s.round),
+		"radius": s.r0 + s.round,
+		"round":  s.round,
+	}
+}
+
+// Truncated Cone (exact distance field)
+
+// cone is a truncated cone, a frustum along Z.
+type cone struct {
+	frustum
+}
+
+// Cone returns the SDF3 for a trucated cone (round > 0 gives rounded edges).
+// Pointed ends (r0 or r1 zero) are rounded to a sphere of radius round within the cone.
+func Cone(height, r0, r1, round float64) *cone {
+	if height <= 0 {
+		panic("height <= 0")
+	}
+	return &cone{newFrustum(r3.Vec{Z: 1}, height, r0, r1, round)}
+}
+
+// Describe returns kind "cone" with its "height", base radius "r0",
+// top radius "r1" and "round" radius.
+func (s *cone) Describe() (string, map[string]float64) {
+	r0, r1 := s.radii()
+	return "cone", map[string]float64{
+		"height": 2 * (s.height + s.round),
+		"r0":     r0,
+		"r1":     r1,
+		"round":  s.round,
+	}
+}
+
+// Ellipsoid (approximate distance field)
+
+// ellipsoid is a triaxial ellipsoid.
+type ellipsoid struct {
+	radii r3.Vec
+	bb    r3.Box
+}
+
+// Ellipsoid returns the SDF3 for an ellipsoid centered on the origin with the semi-axes
+// radii along X, Y and Z. The distance field is not exact: it is the bounded approximation
+// k0*(k0-1)/k1 with k0=|p/r| and k1=|p/r²|, which vanishes exactly on the surface but
+// slightly over or underestimates distances away from it, more so the more elongated
+// the ellipsoid. Meshes are accurate while sphere tracing may need smaller steps.
+func Ellipsoid(radii r3.Vec) *ellipsoid {
+	if radii.X <= 0 || radii.Y <= 0 || radii.Z <= 0 {
+		panic("ellipsoid radii must be positive")
+	}
+	return &ellipsoid{
+		radii: radii,
+		bb:    r3.Box{Min: r3.Scale(-1, radii), Max: radii},
+	}
+}
+
+// Evaluate returns the approximate minimum distance to an ellipsoid.
+func (s *ellipsoid) Evaluate(p r3.Vec) float64 {
+	k0 := r3.Norm(d3.DivElem(p, s.radii))
+	k1 := r3.Norm(d3.DivElem(p, d3.MulElem(s.radii, s.radii)))
+	if k1 == 0 {
+		// At the center the distance is the smallest radius.
+		return -math.Min(s.radii.X, math.Min(s.radii.Y, s.radii.Z))
+	}
+	return k0 * (k0 - 1) / k1
+}
+
+// Bounds returns the bounding box for an ellipsoid.
+func (s *ellipsoid) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "ellipsoid" with its "radii".
+func (s *ellipsoid) Describe() (string, map[string]float64) {
+	return "ellipsoid", map[string]float64{
+		"radii_x": s.radii.X,
+		"radii_y": s.radii.Y,
+		"radii_z": s.radii.Z,
+	}
+}
+
+// Torus (exact distance field)
+
+// torus is a torus about the Z axis.
+type torus struct {
+	major, minor float64
+	bb           r3.Box
+}
+
+// Torus returns the SDF3 for a torus centered on the origin about the Z axis.
+// The tube of radius minorRadius follows a circle of radius majorRadius on the XY
+// plane. minorRadius must be smaller than majorRadius so the torus has a hole.
+func Torus(majorRadius, minorRadius float64) *torus {
+	if majorRadius <= 0 || minorRadius <= 0 {
+		panic("torus radii must be positive")
+	}
+	if minorRadius >= majorRadius {
+		panic("minorRadius >= majorRadius")
+	}
+	r := majorRadius + minorRadius
+	return &torus{
+		major: majorRadius,
+		minor: minorRadius,
+		bb:    r3.Box{Min: r3.Vec{X: -r, Y: -r, Z: -minorRadius}, Max: r3.Vec{X: r, Y: r, Z: minorRadius}},
+	}
+}
+
+// Evaluate returns the minimum distance to a torus.
+func (s *torus) Evaluate(p r3.Vec) float64 {
+	return math.Hypot(math.Hypot(p.X, p.Y)-s.major, p.Z) - s.minor
+}
+
+// Bounds returns the bounding box for a torus.
+func (s *torus) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "torus" with its "major_radius" and "minor_radius".
+func (s *torus) Describe() (string, map[string]float64) {
+	return "torus", map[string]float64{
+		"major_radius": s.major,
+		"minor_radius": s.minor,
+	}
+}
+
+// IsExact returns true since the distance to a torus is exact.
+func (s *torus) IsExact() bool { return true }
+
+// Lp Torus (approximate distance field)
+
+// torusLp is a torus about the Z axis whose tube cross section is an Lp norm ball.
+type torusLp struct {
+	major, minor, p float64
+	lipschitz       float64 // largest gradient of the Lp norm.
+	bb              r3.Box
+}
+
+// TorusLp returns the SDF3 for a torus centered on the origin about the Z axis whose
+// tube cross section is the ball of radius minorRadius of the Lp norm with exponent p,
+// as the torus82 and torus88 family of shapes. p = 2 gives a round tube, equal to Torus,
+// p = 1 a diamond and larger p rounded squares with sharper corners as p grows.
+// The ring followed by the tube is a circle of radius majorRadius on the XY plane.
+//
+// The field is exact for p = 2 and otherwise a bound of the distance, scaled for
+// p < 2 so that it never overestimates distances.
+func TorusLp(majorRadius, minorRadius, p float64) *torusLp {
+	if majorRadius <= 0 || minorRadius <= 0 {
+		panic("torus radii must be positive")
+	}
+	if minorRadius >= majorRadius {
+		panic("minorRadius >= majorRadius")
+	}
+	if !(p >= 1) || math.IsInf(p, 1) {
+		panic("p must be finite and >= 1")
+	}
+	// The gradient of the Lp norm is a unit vector in the dual norm, whose
+	// Euclidean length is at most 2^(1/p-1/2) in two dimensions for p < 2.
+	lipschitz := 1.0
+	if p < 2 {
+		lipschitz = math.Pow(2, 1/p-0.5)
+	}
+	r := majorRadius + minorRadius
+	return &torusLp{
+		major:     majorRadius,
+		minor:     minorRadius,
+		p:         p,
+		lipschitz: lipschitz,
+		bb:        r3.Box{Min: r3.Vec{X: -r, Y: -r, Z: -minorRadius}, Max: r3.Vec{X: r, Y: r, Z: minorRadius}},
+	}
+}
+
+// Evaluate returns the approximate minimum distance to an Lp torus.
+func (s *torusLp) Evaluate(p r3.Vec) float64 {
+	a, b := math.Abs(math.Hypot(p.X, p.Y)-s.major), math.Abs(p.Z)
+	// Scale by the largest component so large exponents do not overflow.
+	m := math.Max(a, b)
+	if m == 0 {
+		return -s.minor / s.lipschitz
+	}
+	norm := m * math.Pow(math.Pow(a/m, s.p)+math.Pow(b/m, s.p), 1/s.p)
+	return (norm - s.minor) / s.lipschitz
+}
+
+// Bounds returns the bounding box for an Lp torus.
+func (s *torusLp) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "torus_lp" with its "major_radius", "minor_radius"
+// and the exponent "p" of the norm of its cross section.
+func (s *torusLp) Describe() (string, map[string]float64) {
+	return "torus_lp", map[string]float64{
+		"major_radius": s.major,
+		"minor_radius": s.minor,
+		"p":            s.p,
+	}
+}
+
+// IsExact returns true if the cross section is round, for p = 2.
+func (s *torusLp) IsExact() bool { return s.p == 2 }
+
+// Link (exact distance field)
+
+// link is a torus about the Z axis elongated along Y.
+type link struct {
+	length       float64
+	major, minor float64
+	bb           r3.Box
+}
+
+// Link returns the SDF3 for a chain link: a torus, as returned by Torus, cut in half
+// across the Y axis with its halves moved apart along Y by length and joined by straight
+// tubes. Chains are built by linking copies rotated a quarter turn about the Y axis.
+func Link(length, majorRadius, minorRadius float64) *link {
+	if length < 0 {
+		panic("length < 0")
+	}
+	if majorRadius <= 0 || minorRadius <= 0 {
+		panic("link radii must be positive")
+	}
+	if minorRadius >= majorRadius {
+		panic("minorRadius >= majorRadius")
+	}
+	r := majorRadius + minorRadius
+	return &link{
+		length: length,
+		major:  majorRadius,
+		minor:  minorRadius,
+		bb: r3.Box{
+			Min: r3.Vec{X: -r, Y: -r - length/2, Z: -minorRadius},
+			Max: r3.Vec{X: r, Y: r + length/2, Z: minorRadius},
+		},
+	}
+}
+
+// Evaluate returns the minimum distance to a link.
+func (s *link) Evaluate(p r3.Vec) float64 {
+	y := math.Max(math.Abs(p.Y)-s.length/2, 0)
+	return math.Hypot(math.Hypot(p.X, y)-s.major, p.Z) - s.minor
+}
+
+// Bounds returns the bounding box for a link.
+func (s *link) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "link" with its "length", "major_radius" and "minor_radius".
+func (s *link) Describe() (string, map[string]float64) {
+	return "link", map[string]float64{
+		"length":       s.length,
+		"major_radius": s.major,
+		"minor_radius": s.minor,
+	}
+}
+
+// IsExact returns true since the distance to a link is exact.
+func (s *link) IsExact() bool { return true }
+
+// Capped Torus (exact distance field)
+
+// cappedTorus is a part of a torus about the Z axis with rounded ends.
+type cappedTorus struct {
+	major, minor float64
+	angle        float64
+	sin, cos     float64
+	bb           r3.Box
+}
+
+// CappedTorus returns the SDF3 for the part of a torus, as returned by Torus, within angle
+// radians to both sides of the +Y axis, as for hooks and partial rings. The open ends of the
+// tube are closed by hemispherical caps. angle must be in (0, π], where π gives the full torus.
+// The bounding box is that of the full torus.
+func CappedTorus(majorRadius, minorRadius, angle float64) *cappedTorus {
+	if majorRadius <= 0 || minorRadius <= 0 {
+		panic("torus radii must be positive")
+	}
+	if minorRadius >= majorRadius {
+		panic("minorRadius >= majorRadius")
+	}
+	if angle <= 0 || angle > math.Pi {
+		panic("angle must be in (0, π]")
+	}
+	sin, cos := math.Sincos(angle)
+	r := majorRadius + minorRadius
+	return &cappedTorus{
+		major: majorRadius,
+		minor: minorRadius,
+		angle: angle,
+		sin:   sin,
+		cos:   cos,
+		bb:    r3.Box{Min: r3.Vec{X: -r, Y: -r, Z: -minorRadius}, Max: r3.Vec{X: r, Y: r, Z: minorRadius}},
+	}
+}
+
+// Evaluate returns the minimum distance to a capped torus.
+func (s *cappedTorus) Evaluate(p r3.Vec) float64 {
+	x := math.Abs(p.X)
+	// k is the projection of p on the XY plane onto the direction of the
+	// nearest point of the tube's center circle, which is the end of the arc
+	// if p lies outside it.
+	k := math.Hypot(x, p.Y)
+	if s.cos*x > s.sin*p.Y {
+		k = x*s.sin + p.Y*s.cos
+	}
+	return math.Sqrt(math.Max(0, r3.Norm2(p)+s.major*s.major-2*s.major*k)) - s.minor
 }
 
-// BoundingBox return the bounding box for the trucated cone..
-func (s *cone) Bounds() r3.Box {
+// Bounds returns the bounding box for the full torus.
+func (s *cappedTorus) Bounds() r3.Box {
 	return s.bb
 }
 
+// Describe returns kind "capped_torus" with its "major_radius", "minor_radius"
+// and the half "angle" of the arc in radians.
+func (s *cappedTorus) Describe() (string, map[string]float64) {
+	return "capped_torus", map[string]float64{
+		"major_radius": s.major,
+		"minor_radius": s.minor,
+		"angle":        s.angle,
+	}
+}
+
+// IsExact returns true since the distance to a capped torus is exact.
+func (s *cappedTorus) IsExact() bool { return true }
+
+// Octahedron (exact distance field)
+
+// octahedron is a regular octahedron with vertices on the axes.
+type octahedron struct {
+	s  float64
+	bb r3.Box
+}
+
+// Octahedron returns the SDF3 for a regular octahedron centered on the origin
+// with its vertices on the axes at distance s from the center.
+func Octahedron(s float64) *octahedron {
+	if s <= 0 {
+		panic("s <= 0")
+	}
+	d := r3.Vec{X: s, Y: s, Z: s}
+	return &octahedron{
+		s:  s,
+		bb: r3.Box{Min: r3.Scale(-1, d), Max: d},
+	}
+}
+
+// Evaluate returns the minimum distance to an octahedron.
+func (s *octahedron) Evaluate(p r3.Vec) float64 {
+	p = d3.AbsElem(p)
+	m := p.X + p.Y + p.Z - s.s
+	// Permute coordinates so the nearest feature is found
+	// on the edge from (0,s,0) to (0,0,s) of the face.
+	var q r3.Vec
+	switch {
+	case 3*p.X < m:
+		q = p
+	case 3*p.Y < m:
+		q = r3.Vec{X: p.Y, Y: p.Z, Z: p.X}
+	case 3*p.Z < m:
+		q = r3.Vec{X: p.Z, Y: p.X, Z: p.Y}
+	default:
+		// Nearest point lies in the interior of the face.
+		return m / math.Sqrt(3)
+	}
+	k := math.Max(0, math.Min(s.s, 0.5*(q.Z-q.Y+s.s)))
+	return r3.Norm(r3.Vec{X: q.X, Y: q.Y - s.s + k, Z: q.Z - k})
+}
+
+// Bounds returns the bounding box for an octahedron.
+func (s *octahedron) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "octahedron" with its center to vertex distance "s".
+func (s *octahedron) Describe() (string, map[string]float64) {
+	return "octahedron", map[string]float64{"s": s.s}
+}
+
+// IsExact returns true since the distance to an octahedron is exact.
+func (s *octahedron) IsExact() bool { return true }
+
+// Tetrahedron (exact distance field)
+
+// tetrahedron is a regular tetrahedron with vertices on alternate corners of a cube.
+type tetrahedron struct {
+	s     float64
+	verts [4]r3.Vec
+	bb    r3.Box
+}
+
+// Tetrahedron returns the SDF3 for a regular tetrahedron centered on the origin inscribed
+// in a cube of half size s, with vertices at (s,s,s), (s,-s,-s), (-s,s,-s) and (-s,-s,s).
+// Each face lies opposite a vertex v and faces -v, so the face opposite (s,s,s) is
+// normal to (-1,-1,-1) and the solid points up at (s,s,s) and (-s,-s,s).
+func Tetrahedron(s float64) *tetrahedron {
+	if s <= 0 {
+		panic("s <= 0")
+	}
+	d := r3.Vec{X: s, Y: s, Z: s}
+	return &tetrahedron{
+		s: s,
+		verts: [4]r3.Vec{
+			{X: s, Y: s, Z: s}, {X: s, Y: -s, Z: -s}, {X: -s, Y: s, Z: -s}, {X: -s, Y: -s, Z: s},
+		},
+		bb: r3.Box{Min: r3.Scale(-1, d), Max: d},
+	}
+}
+
+// Evaluate returns the minimum distance to a tetrahedron.
+func (s *tetrahedron) Evaluate(p r3.Vec) float64 {
+	// The greatest distance to the face planes is exact within the
+	// tetrahedron and in the regions outside where a single face is nearest.
+	d := (math.Max(math.Abs(p.X+p.Y)-p.Z, math.Abs(p.X-p.Y)+p.Z) - s.s) / math.Sqrt(3)
+	if d <= 0 {
+		return d
+	}
+	// Outside the nearest point may lie on an edge or vertex of a face.
+	v := s.verts
+	return math.Min(math.Min(triangleDistance(p, v[1], v[2], v[3]), triangleDistance(p, v[0], v[3], v[2])),
+		math.Min(triangleDistance(p, v[0], v[1], v[3]), triangleDistance(p, v[0], v[2], v[1])))
+}
+
+// Bounds returns the bounding box for a tetrahedron.
+func (s *tetrahedron) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "tetrahedron" with the half size "s" of its cube.
+func (s *tetrahedron) Describe() (string, map[string]float64) {
+	return "tetrahedron", map[string]float64{"s": s.s}
+}
+
+// IsExact returns true since the distance to a tetrahedron is exact.
+func (s *tetrahedron) IsExact() bool { return true }
+
+// triangleDistance returns the unsigned distance from p to the triangle abc.
+func triangleDistance(p, a, b, c r3.Vec) float64 {
+	ba, pa := r3.Sub(b, a), r3.Sub(p, a)
+	cb, pb := r3.Sub(c, b), r3.Sub(p, b)
+	ac, pc := r3.Sub(a, c), r3.Sub(p, c)
+	n := r3.Cross(ba, ac)
+	inside := r3.Dot(r3.Cross(ba, n), pa) >= 0 && r3.Dot(r3.Cross(cb, n), pb) >= 0 && r3.Dot(r3.Cross(ac, n), pc) >= 0
+	if inside {
+		// p projects onto the interior of the triangle.
+		return math.Abs(r3.Dot(n, pa)) / r3.Norm(n)
+	}
+	edge := func(e, q r3.Vec) float64 {
+		t := math.Max(0, math.Min(1, r3.Dot(e, q)/r3.Norm2(e)))
+		return r3.Norm(r3.Sub(r3.Scale(t, e), q))
+	}
+	return math.Min(edge(ba, pa), math.Min(edge(cb, pb), edge(ac, pc)))
+}
+
+// Pyramid (exact distance field)
+
+// pyramid is a square based pyramid with its apex on +Z.
+type pyramid struct {
+	width, height float64
+	h, m2         float64 // height and squared slant height for a base of unit width.
+	bb            r3.Box
+}
+
+// Pyramid returns the SDF3 for a square based pyramid with base edges of length
+// baseWidth centered on the XY plane and its apex at height on the Z axis.
+func Pyramid(baseWidth, height float64) *pyramid {
+	if baseWidth <= 0 || height <= 0 {
+		panic("pyramid dimensions must be positive")
+	}
+	h := height / baseWidth
+	return &pyramid{
+		width:  baseWidth,
+		height: height,
+		h:      h,
+		m2:     h*h + 0.25,
+		bb: r3.Box{
+			Min: r3.Vec{X: -baseWidth / 2, Y: -baseWidth / 2},
+			Max: r3.Vec{X: baseWidth / 2, Y: baseWidth / 2, Z: height},
+		},
+	}
+}
+
+// Evaluate returns the minimum distance to a pyramid.
+func (s *pyramid) Evaluate(p r3.Vec) float64 {
+	// Work on a pyramid of unit base width in the first octant,
+	// mirrored so that x >= y, with the base edge on the x axis.
+	p = r3.Scale(1/s.width, p)
+	x, y, z := math.Abs(p.X), math.Abs(p.Y), p.Z
+	if y > x {
+		x, y = y, x
+	}
+	x -= 0.5
+	y -= 0.5
+	q := r3.Vec{X: y, Y: s.h*z - 0.5*x, Z: s.h*x + 0.5*z}
+	k := math.Max(-q.X, 0)
+	t := math.Max(0, math.Min(1, (q.Y-0.5*y)/(s.m2+0.25)))
+	a := s.m2*(q.X+k)*(q.X+k) + q.Y*q.Y
+	b := s.m2*(q.X+0.5*t)*(q.X+0.5*t) + (q.Y-s.m2*t)*(q.Y-s.m2*t)
+	var d2 float64
+	if math.Min(q.Y, -q.X*s.m2-q.Y*0.5) <= 0 {
+		d2 = math.Min(a, b)
+	}
+	// Distance to the sides, combined with the base plane.
+	d := math.Sqrt((d2 + q.Z*q.Z) / s.m2)
+	switch {
+	case q.Z < 0 && z > 0:
+		d = -math.Min(d, z)
+	case z <= 0 && x <= 0:
+		// Below the base.
+		d = -z
+	}
+	return d * s.width
+}
+
+// Bounds returns the bounding box for a pyramid.
+func (s *pyramid) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "pyramid" with its "base_width" and "height".
+func (s *pyramid) Describe() (string, map[string]float64) {
+	return "pyramid", map[string]float64{"base_width": s.width, "height": s.height}
+}
+
+// IsExact returns true since the distance to a pyramid is exact.
+func (s *pyramid) IsExact() bool { return true }
+
+// Hexagonal Prism (exact distance field)
+
+// hexPrism is a regular hexagonal prism along the Z axis.
+type hexPrism struct {
+	acrossFlats, height float64
+	bb                  r3.Box
+}
+
+// HexagonalPrism returns the SDF3 for a regular hexagonal prism of the given height
+// centered on the origin along the Z axis, as for hex bolt heads and standoffs.
+// acrossFlats is the distance between opposite faces, the diameter of the inscribed
+// circle. Two of its faces are parallel to the XZ plane and two vertices lie on the X axis.
+func HexagonalPrism(acrossFlats, height float64) *hexPrism {
+	if acrossFlats <= 0 || height <= 0 {
+		panic("hexagonal prism dimensions must be positive")
+	}
+	circumradius := acrossFlats / math.Sqrt(3)
+	return &hexPrism{
+		acrossFlats: acrossFlats,
+		height:      height,
+		bb: r3.Box{
+			Min: r3.Vec{X: -circumradius, Y: -acrossFlats / 2, Z: -height / 2},
+			Max: r3.Vec{X: circumradius, Y: acrossFlats / 2, Z: height / 2},
+		},
+	}
+}
+
+// Evaluate returns the minimum distance to a hexagonal prism.
+func (s *hexPrism) Evaluate(p r3.Vec) float64 {
+	// k is the unit normal of the face adjacent to the face on +Y
+	// and the tangent of 30 degrees.
+	const kx, ky, kz = -0.8660254037844386, 0.5, 0.5773502691896258
+	apothem := s.acrossFlats / 2
+	p = d3.AbsElem(p)
+	// Reflect about the plane between both faces to work on the face on +Y.
+	dot := 2 * math.Min(kx*p.X+ky*p.Y, 0)
+	p.X -= dot * kx
+	p.Y -= dot * ky
+	dx := math.Hypot(p.X-math.Max(-kz*apothem, math.Min(kz*apothem, p.X)), p.Y-apothem)
+	if p.Y < apothem {
+		dx = -dx
+	}
+	dz := p.Z - s.height/2
+	return math.Min(math.Max(dx, dz), 0) + math.Hypot(math.Max(dx, 0), math.Max(dz, 0))
+}
+
+// Bounds returns the bounding box for a hexagonal prism.
+func (s *hexPrism) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "hexagonal_prism" with its "across_flats" and "height".
+func (s *hexPrism) Describe() (string, map[string]float64) {
+	return "hexagonal_prism", map[string]float64{"across_flats": s.acrossFlats, "height": s.height}
+}
+
+// IsExact returns true since the distance to a hexagonal prism is exact.
+func (s *hexPrism) IsExact() bool { return true }
+
+// Triangular Prism (exact distance field)
+
+// triPrism is an equilateral triangular prism along the Z axis.
+type triPrism struct {
+	side, height float64
+	bb           r3.Box
+}
+
+// TriangularPrism returns the SDF3 for an equilateral triangular prism of the given height
+// along the Z axis with its cross section centroid on the origin. The triangle has sides
+// of length side, one of them parallel to the X axis below the origin, and a vertex on +Y.
+func TriangularPrism(side, height float64) *triPrism {
+	if side <= 0 || height <= 0 {
+		panic("triangular prism dimensions must be positive")
+	}
+	circumradius := side / math.Sqrt(3)
+	return &triPrism{
+		side:   side,
+		height: height,
+		bb: r3.Box{
+			Min: r3.Vec{X: -side / 2, Y: -circumradius / 2, Z: -height / 2},
+			Max: r3.Vec{X: side / 2, Y: circumradius, Z: height / 2},
+		},
+	}
+}
+
+// Evaluate returns the minimum distance to a triangular prism.
+func (s *triPrism) Evaluate(p r3.Vec) float64 {
+	const k = 1.7320508075688772 // sqrt(3)
+	// Distance to the triangle on the XY plane, reflected about the Y axis
+	// and about the bisector of the side on +X to work on the bottom side.
+	r := s.side / 2
+	x, y := math.Abs(p.X)-r, p.Y+r/k
+	if x+k*y > 0 {
+		x, y = (x-k*y)/2, (-k*x-y)/2
+	}
+	x -= math.Max(-2*r, math.Min(0, x))
+	dxy := math.Hypot(x, y)
+	if y > 0 {
+		dxy = -dxy
+	}
+	dz := math.Abs(p.Z) - s.height/2
+	return math.Min(math.Max(dxy, dz), 0) + math.Hypot(math.Max(dxy, 0), math.Max(dz, 0))
+}
+
+// Bounds returns the bounding box for a triangular prism.
+func (s *triPrism) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "triangular_prism" with its "side" and "height".
+func (s *triPrism) Describe() (string, map[string]float64) {
+	return "triangular_prism", map[string]float64{"side": s.side, "height": s.height}
+}
+
+// IsExact returns true since the distance to a triangular prism is exact.
+func (s *triPrism) IsExact() bool { return true }
+
+// Wedge (exact distance field)
+
+// wedge is a right triangular prism lying on the XY plane.
+type wedge struct {
+	run, rise, width float64
+	bb               r3.Box
+}
+
+// Wedge returns the SDF3 for a wedge, or ramp, lying on the XY plane, as for support
+// ramps and angled brackets. Its cross section is a right triangle with legs of
+// length run along +X and rise along +Z meeting at the Y axis, extruded width
+// along Y centered on the origin. The slope faces +X and +Z.
+func Wedge(run, rise, width float64) *wedge {
+	if run <= 0 || rise <= 0 || width <= 0 {
+		panic("wedge dimensions must be positive")
+	}
+	return &wedge{
+		run:   run,
+		rise:  rise,
+		width: width,
+		bb: r3.Box{
+			Min: r3.Vec{Y: -width / 2},
+			Max: r3.Vec{X: run, Y: width / 2, Z: rise},
+		},
+	}
+}
+
+// Evaluate returns the minimum distance to a wedge.
+func (s *wedge) Evaluate(p r3.Vec) float64 {
+	// Distance to the triangle on the XZ plane is the distance to its nearest side.
+	seg := func(a, b r2.Vec) float64 {
+		ab := r2.Sub(b, a)
+		t := math.Max(0, math.Min(1, r2.Dot(r2.Sub(r2.Vec{X: p.X, Y: p.Z}, a), ab)/r2.Norm2(ab)))
+		return r2.Norm(r2.Sub(r2.Vec{X: p.X, Y: p.Z}, r2.Add(a, r2.Scale(t, ab))))
+	}
+	run, rise := r2.Vec{X: s.run}, r2.Vec{Y: s.rise}
+	dxz := math.Min(seg(r2.Vec{}, run), math.Min(seg(run, rise), seg(rise, r2.Vec{})))
+	if p.X > 0 && p.Z > 0 && p.X/s.run+p.Z/s.rise < 1 {
+		dxz = -dxz
+	}
+	dy := math.Abs(p.Y) - s.width/2
+	return math.Min(math.Max(dxz, dy), 0) + math.Hypot(math.Max(dxz, 0), math.Max(dy, 0))
+}
+
+// Bounds returns the bounding box for a wedge.
+func (s *wedge) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "wedge" with its "run", "rise" and "width".
+func (s *wedge) Describe() (string, map[string]float64) {
+	return "wedge", map[string]float64{"run": s.run, "rise": s.rise, "width": s.width}
+}
+
+// IsExact returns true since the distance to a wedge is exact.
+func (s *wedge) IsExact() bool { return true }
+
+// Solid Angle (exact distance field)
+
+// solidAngle is a spherical cone with its apex on the origin opening along +Z.
+type solidAngle struct {
+	angle, radius float64
+	sin, cos      float64
+	bb            r3.Box
+}
+
+// SolidAngle returns the SDF3 for the region of a sphere of the given radius centered on
+// the origin within a cone of half angle angle, in radians, with its apex on the origin
+// opening along +Z, as for lamp reflectors and spotlight beams. Angles beyond π/2
+// give a sphere with a conical hollow along -Z.
+func SolidAngle(angle, radius float64) *solidAngle {
+	if radius <= 0 {
+		panic("radius <= 0")
+	}
+	if angle <= 0 || angle >= math.Pi {
+		panic("angle must be in (0, pi)")
+	}
+	sin, cos := math.Sincos(angle)
+	// The spherical cap spans the cone's rim, or the whole sphere above it.
+	r, zmin := radius*sin, 0.0
+	if angle > math.Pi/2 {
+		r, zmin = radius, radius*cos
+	}
+	return &solidAngle{
+		angle:  angle,
+		radius: radius,
+		sin:    sin,
+		cos:    cos,
+		bb:     r3.Box{Min: r3.Vec{X: -r, Y: -r, Z: zmin}, Max: r3.Vec{X: r, Y: r, Z: radius}},
+	}
+}
+
+// Evaluate returns the minimum distance to a solid angle.
+func (s *solidAngle) Evaluate(p r3.Vec) float64 {
+	q := r2.Vec{X: math.Hypot(p.X, p.Y), Y: p.Z}
+	c := r2.Vec{X: s.sin, Y: s.cos}
+	l := r2.Norm(q) - s.radius
+	// Distance to the side of the cone, signed by the side of q.
+	m := r2.Norm(r2.Sub(q, r2.Scale(math.Max(0, math.Min(s.radius, r2.Dot(q, c))), c)))
+	if c.Y*q.X-c.X*q.Y < 0 {
+		m = -m
+	}
+	return math.Max(l, m)
+}
+
+// Bounds returns the bounding box for a solid angle.
+func (s *solidAngle) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "solid_angle" with its half "angle" in radians and "radius".
+func (s *solidAngle) Describe() (string, map[string]float64) {
+	return "solid_angle", map[string]float64{"angle": s.angle, "radius": s.radius}
+}
+
+// IsExact returns true since the distance to a solid angle is exact.
+func (s *solidAngle) IsExact() bool { return true }
+
+// Box Frame (exact distance field)
+
+// boxFrame is the frame of bars along the edges of a box.
+type boxFrame struct {
+	size      r3.Vec
+	thickness float64
+	bb        r3.Box
+}
+
+// BoxFrame returns the SDF3 for the frame of a box of the given size centered on the
+// origin, made of bars of square cross section of width thickness along its 12 edges,
+// as for crates and frames. The outer faces of the bars are flush with the faces of the box.
+func BoxFrame(size r3.Vec, thickness float64) *boxFrame {
+	if size.X <= 0 || size.Y <= 0 || size.Z <= 0 {
+		panic("size must be positive")
+	}
+	if thickness <= 0 || thickness >= math.Min(size.X, math.Min(size.Y, size.Z))/2 {
+		panic("thickness must be positive and smaller than half the smallest side")
+	}
+	half := r3.Scale(0.5, size)
+	return &boxFrame{
+		size:      size,
+		thickness: thickness,
+		bb:        r3.Box{Min: r3.Scale(-1, half), Max: half},
+	}
+}
+
+// Evaluate returns the minimum distance to a box frame.
+func (s *boxFrame) Evaluate(p r3.Vec) float64 {
+	e := s.thickness / 2
+	p = r3.Sub(d3.AbsElem(p), s.bb.Max)
+	// Offset to the bars along each axis, centered on the bar.
+	q := r3.Sub(d3.AbsElem(r3.Add(p, d3.Elem(e))), d3.Elem(e))
+	bar := func(v r3.Vec) float64 {
+		return r3.Norm(d3.MaxElem(v, r3.Vec{})) + math.Min(d3.Max(v), 0)
+	}
+	return math.Min(bar(r3.Vec{X: p.X, Y: q.Y, Z: q.Z}),
+		math.Min(bar(r3.Vec{X: q.X, Y: p.Y, Z: q.Z}), bar(r3.Vec{X: q.X, Y: q.Y, Z: p.Z})))
+}
+
+// Bounds returns the bounding box for a box frame.
+func (s *boxFrame) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "box_frame" with its "size" and bar "thickness".
+func (s *boxFrame) Describe() (string, map[string]float64) {
+	return "box_frame", map[string]float64{
+		"size_x":    s.size.X,
+		"size_y":    s.size.Y,
+		"size_z":    s.size.Z,
+		"thickness": s.thickness,
+	}
+}
+
+// IsExact returns true since the distance to a box frame is exact.
+func (s *boxFrame) IsExact() bool { return true }
+
+// Plane (exact distance field)
+
+// planeExtent is the half size of the bounding box of a plane. Half-spaces
+// are unbounded but their box is kept finite so that its size and center,
+// and those of the boxes built from it, remain usable numbers.
+const planeExtent = 1e10
+
+// plane is the half-space behind a plane.
+type plane struct {
+	normal r3.Vec // unit normal pointing out of the solid.
+	point  r3.Vec
+	bb     r3.Box
+}
+
+// Plane returns the SDF3 for the half-space behind the plane through point with
+// the given normal, which need not be unit length. The distance is positive on the
+// side the normal points to. Half-spaces are meant to be intersected with each other
+// to build convex solids or combined with bounded solids. Their bounding box spans
+// planeExtent (1e10) in every direction, so solids built only from planes need a
+// bounding box set, for example with sdf.AutoBox3D, before rendering.
+func Plane(normal, point r3.Vec) *plane {
+	n := r3.Norm(normal)
+	if n == 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		panic("normal must be non-zero and finite")
+	}
+	return &plane{
+		normal: r3.Scale(1/n, normal),
+		point:  point,
+		bb:     r3.Box{Min: r3.Vec{X: -planeExtent, Y: -planeExtent, Z: -planeExtent}, Max: r3.Vec{X: planeExtent, Y: planeExtent, Z: planeExtent}},
+	}
+}
+
+// Evaluate returns the signed distance to a plane.
+func (s *plane) Evaluate(p r3.Vec) float64 {
+	return r3.Dot(r3.Sub(p, s.point), s.normal)
+}
+
+// Bounds returns the effectively infinite bounding box of a plane.
+func (s *plane) Bounds() r3.Box {
+	return s.bb
+}
+
+// Describe returns kind "plane" with its unit "normal" and a "point" on it.
+func (s *plane) Describe() (string, map[string]float64) {
+	return "plane", map[string]float64{
+		"normal_x": s.normal.X,
+		"normal_y": s.normal.Y,
+		"normal_z": s.normal.Z,
+		"point_x":  s.point.X,
+		"point_y":  s.point.Y,
+		"point_z":  s.point.Z,
+	}
+}
+
+// IsExact returns true since the distance to a plane is exact.
+func (s *plane) IsExact() bool { return true }
+
+// RevolveProfile revolves a lathe profile about the Z axis by theta radians.
+// points are (r,z) pairs on one side of the axis forming a closed polygon. Profiles
+// usually start and end on the axis (r=0), in which case the profile is mirrored
+// about the axis so the interior distance is not cut short by the edge on the axis.
+// The profile may be closed explicitly by repeating the first point at its end.
+func RevolveProfile(points []r2.Vec, theta float64) sdf.SDF3 {
+	if len(points) > 1 && points[len(points)-1] == points[0] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 3 {
+		panic("profile needs at least 3 points")
+	}
+	for _, p := range points {
+		if p.X < 0 {
+			panic("profile has negative radius")
+		}
+	}
+	profile := points
+	last := points[len(points)-1]
+	if points[0].X == 0 && last.X == 0 {
+		profile = make([]r2.Vec, 0, 2*len(points))
+		profile = append(profile, points...)
+		for i := len(points) - 2; i > 0; i-- {
+			profile = append(profile, r2.Vec{X: -points[i].X, Y: points[i].Y})
+		}
+	}
+	return sdf.Revolve3D(form2.Polygon(profile), theta)
+}
+
+// Nozzle returns a cylinder of height cylHeight and radius cylRadius topped
+// by a cone of height coneHeight which tapers to tipRadius. The profile is
+// revolved as a single polygon so there is no seam between cylinder and cone.
+// The nozzle is centered on the origin along the Z axis.
+func Nozzle(cylHeight, cylRadius, coneHeight, tipRadius float64) sdf.SDF3 {
+	if cylHeight <= 0 || cylRadius <= 0 || coneHeight <= 0 {
+		panic("nozzle dimensions must be positive")
+	}
+	if tipRadius < 0 {
+		panic("tipRadius < 0")
+	}
+	h := (cylHeight + coneHeight) / 2
+	return RevolveProfile([]r2.Vec{
+		{X: 0, Y: -h},
+		{X: cylRadius, Y: -h},
+		{X: cylRadius, Y: cylHeight - h},
+		{X: tipRadius, Y: h},
+		{X: 0, Y: h},
+	}, 2*math.Pi)
+}
+
 func sdfBox3d(p, s r3.Vec) float64 {
 	d := r3.Sub(d3.AbsElem(p), s)
 	if d.X > 0 && d.Y > 0 && d.Z > 0 {
@@ -0,0 +1,111 @@
+package obj3
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf"
+	form2 "github.com/soypat/sdf/form2/must2"
+	form3 "github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Shaft features for power transmission.
+
+// Keyway cuts a keyway into the bore of base, a rectangular slot of the given width
+// and length running along the bore axis, which passes through center along axis.
+// The slot is cut depth deep into the bore wall, measured from the bore surface,
+// and is centered on center along the axis. It is cut on the side of the bore
+// towards +X, or +Y for bores along the X axis.
+//
+// The bore radius and wall thickness are measured by marching base from center,
+// which must lie within the bore. An error is returned if the slot is wider than
+// the bore or would cut through the wall.
+func Keyway(base sdf.SDF3, center, axis r3.Vec, width, depth, length float64) (sdf.SDF3, error) {
+	if base == nil {
+		return nil, errors.New("nil base")
+	}
+	if width <= 0 || depth <= 0 || length <= 0 {
+		return nil, errors.New("keyway dimensions must be positive")
+	}
+	if r3.Norm(axis) == 0 {
+		return nil, errors.New("zero axis")
+	}
+	a := r3.Unit(axis)
+	// Radial direction of the slot.
+	u := r3.Sub(r3.Vec{X: 1}, r3.Scale(a.X, a))
+	if r3.Norm(u) < 1e-6 {
+		u = r3.Sub(r3.Vec{Y: 1}, r3.Scale(a.Y, a))
+	}
+	u = r3.Unit(u)
+	if base.Evaluate(center) <= 0 {
+		return nil, errors.New("center is not within a bore of base")
+	}
+	bb := base.Bounds()
+	tol := 1e-6 * r3.Norm(bb.Max.Sub(bb.Min))
+	bore, ok := march(base, center, u, tol, 1)
+	if !ok {
+		return nil, errors.New("no bore wall found")
+	}
+	if width >= 2*bore {
+		return nil, errors.New("keyway wider than bore")
+	}
+	// March through the wall from just inside the bore surface, far enough
+	// past the tolerance of the bore for the march not to stop at once.
+	wall, ok := march(base, r3.Add(center, r3.Scale(bore+2*tol, u)), u, tol, -1)
+	if ok && depth >= wall {
+		return nil, errors.New("keyway cuts through the bore wall")
+	}
+	// The slot runs from the bore axis to depth past the bore surface. The box
+	// is rotated so its X axis lies along u, Y across the slot and Z along the
+	// bore axis, first turning about Z so X ends along u then tilting Z onto the axis.
+	outer := bore + depth
+	mid := r3.Add(center, r3.Scale(outer/2, u))
+	theta := math.Acos(math.Max(-1, math.Min(1, a.Z)))
+	phi := math.Atan2(a.Y, a.X)
+	sinPhi, cosPhi := math.Sincos(phi)
+	sinTheta, cosTheta := math.Sincos(theta)
+	x0 := r3.Vec{X: cosPhi * cosTheta, Y: sinPhi * cosTheta, Z: -sinTheta}
+	y0 := r3.Vec{X: -sinPhi, Y: cosPhi}
+	psi := math.Atan2(r3.Dot(u, y0), r3.Dot(u, x0))
+	m := sdf.Translate3D(mid).Mul(sdf.RotateZ(phi)).Mul(sdf.RotateY(theta)).Mul(sdf.RotateZ(psi))
+	slot := sdf.Transform3D(form3.Box(r3.Vec{X: outer, Y: width, Z: length}, 0), m)
+	return sdf.Difference3D(base, slot), nil
+}
+
+// march returns the distance from p along unit direction dir to the surface of s,
+// marching outside s if sign is 1 and inside if -1, and whether it was found
+// within the bounding box of s.
+func march(s sdf.SDF3, p, dir r3.Vec, tol, sign float64) (float64, bool) {
+	bb := s.Bounds()
+	limit := r3.Norm(bb.Max.Sub(bb.Min)) + r3.Norm(p.Sub(bb.Min))
+	for t := 0.0; t < limit; {
+		d := sign * s.Evaluate(r3.Add(p, r3.Scale(t, dir)))
+		if d < tol {
+			return t, true
+		}
+		t += d
+	}
+	return 0, false
+}
+
+// SplineShaft returns a straight sided splined shaft of the given length centered on
+// the origin along the Z axis, with teeth of toothDepth running along the shaft to its
+// outer radius. Teeth and the gaps between them are equally wide at the root.
+func SplineShaft(radius float64, teeth int, toothDepth, length float64) (sdf.SDF3, error) {
+	if radius <= 0 || length <= 0 {
+		return nil, errors.New("shaft dimensions must be positive")
+	}
+	if teeth < 2 {
+		return nil, errors.New("spline shafts need at least 2 teeth")
+	}
+	if toothDepth <= 0 || toothDepth >= radius {
+		return nil, errors.New("tooth depth must be positive and smaller than radius")
+	}
+	root := radius - toothDepth
+	width := 2 * root * math.Sin(math.Pi/float64(2*teeth))
+	tooth := sdf.Transform2D(form2.Box(r2.Vec{X: radius, Y: width}, 0), sdf.Translate2D(r2.Vec{X: radius / 2}))
+	profile := sdf.Union2D(form2.Circle(root), sdf.RotateCopy2D(tooth, teeth))
+	return sdf.Extrude3D(sdf.Intersect2D(profile, form2.Circle(radius)), length), nil
+}
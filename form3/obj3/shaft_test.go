@@ -0,0 +1,143 @@
+package obj3_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	form3 "github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/form3/obj3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestKeyway(t *testing.T) {
+	// Hub of radius 10 with a bore of radius 3 along Z, a wall 7 thick.
+	hub := sdf.Difference3D(form3.Cylinder(10, 10, 0), form3.Cylinder(20, 3, 0))
+	const width, depth, length = 2, 1.5, 6
+	s, err := obj3.Keyway(hub, r3.Vec{}, r3.Vec{Z: 1}, width, depth, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		p      r3.Vec
+		inside bool
+	}{
+		{name: "slot at bore surface", p: r3.Vec{X: 3.1}},
+		{name: "slot at depth", p: r3.Vec{X: 3 + depth - 0.1, Y: width/2 - 0.1, Z: length/2 - 0.1}},
+		{name: "wall past depth", p: r3.Vec{X: 3 + depth + 0.1}, inside: true},
+		{name: "wall beside slot", p: r3.Vec{X: 4, Y: width/2 + 0.1}, inside: true},
+		{name: "wall past slot end", p: r3.Vec{X: 4, Z: length/2 + 0.1}, inside: true},
+		{name: "opposite wall", p: r3.Vec{X: -3.1}, inside: true},
+	} {
+		if got := s.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("%s: at %v got inside %t, want %t", test.name, test.p, got, test.inside)
+		}
+	}
+	// Within the slot the distance is exact, the nearest wall is beside the slot.
+	if got, want := s.Evaluate(r3.Vec{X: 3.1}), width/2.0; math.Abs(got-want) > 1e-9 {
+		t.Errorf("got %g in the slot, want %g", got, want)
+	}
+	// Slots along other axes are oriented the same relative to the bore.
+	axis := r3.Unit(r3.Vec{X: 1, Y: 1, Z: 1})
+	tilted := sdf.Transform3D(hub, sdf.Rotate3D(r3.Cross(r3.Vec{Z: 1}, axis), math.Acos(axis.Z)))
+	ts, err := obj3.Keyway(tilted, r3.Vec{}, axis, width, depth, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := r3.Unit(r3.Sub(r3.Vec{X: 1}, r3.Scale(axis.X, axis)))
+	w := r3.Cross(axis, u)
+	at := func(radial, across, along float64) r3.Vec {
+		return r3.Add(r3.Add(r3.Scale(radial, u), r3.Scale(across, w)), r3.Scale(along, axis))
+	}
+	for _, test := range []struct {
+		name   string
+		p      r3.Vec
+		inside bool
+	}{
+		{name: "tilted slot at depth", p: at(3+depth-0.1, width/2-0.1, length/2-0.1)},
+		{name: "tilted wall past depth", p: at(3+depth+0.1, 0, 0), inside: true},
+		{name: "tilted wall beside slot", p: at(4, width/2+0.1, 0), inside: true},
+		{name: "tilted wall past slot end", p: at(4, 0, length/2+0.1), inside: true},
+	} {
+		if got := ts.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("%s: at %v got inside %t, want %t", test.name, test.p, got, test.inside)
+		}
+	}
+	// The wall opposite the slot is untouched.
+	for _, r := range []float64{2.9, 3, 3.5, 5, 9.9} {
+		for _, angle := range []float64{math.Pi / 2, math.Pi, 3 * math.Pi / 2} {
+			p := r3.Vec{X: r * math.Cos(angle), Y: r * math.Sin(angle)}
+			if got, want := s.Evaluate(p), hub.Evaluate(p); got != want {
+				t.Errorf("at %v got %g, want unchanged %g", p, got, want)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		name                 string
+		base                 sdf.SDF3
+		center               r3.Vec
+		width, depth, length float64
+	}{
+		{name: "through wall", base: hub, width: width, depth: 7, length: length},
+		{name: "wider than bore", base: hub, width: 6, depth: depth, length: length},
+		{name: "center outside bore", base: hub, center: r3.Vec{X: 5}, width: width, depth: depth, length: length},
+		{name: "zero depth", base: hub, width: width, length: length},
+		{name: "nil base", width: width, depth: depth, length: length},
+	} {
+		if _, err := obj3.Keyway(test.base, test.center, r3.Vec{Z: 1}, test.width, test.depth, test.length); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestSplineShaft(t *testing.T) {
+	const radius, toothDepth = 5.0, 1.0
+	root := radius - toothDepth
+	for _, teeth := range []int{2, 6, 11} {
+		s, err := obj3.SplineShaft(radius, teeth, toothDepth, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Count the teeth crossed going around the shaft between root and tip.
+		const samples = 3600
+		inside := func(r float64, i int) bool {
+			angle := 2 * math.Pi * (float64(i) + 0.5) / samples
+			return s.Evaluate(r3.Vec{X: r * math.Cos(angle), Y: r * math.Sin(angle)}) < 0
+		}
+		count := 0
+		for i := 0; i < samples; i++ {
+			if inside(root+toothDepth/2, i) && !inside(root+toothDepth/2, i+1) {
+				count++
+			}
+			if !inside(root-0.05, i) {
+				t.Fatalf("%d teeth: root circle not solid", teeth)
+			}
+			if inside(radius+0.05, i) {
+				t.Fatalf("%d teeth: solid past outer radius", teeth)
+			}
+		}
+		if count != teeth {
+			t.Errorf("got %d teeth, want %d", count, teeth)
+		}
+		// Teeth reach the outer radius, the first one along +X.
+		if p := (r3.Vec{X: radius - 0.05}); s.Evaluate(p) >= 0 {
+			t.Errorf("%d teeth: tooth tip at %v not solid", teeth, p)
+		}
+	}
+
+	for _, test := range []struct {
+		name                       string
+		radius, toothDepth, length float64
+		teeth                      int
+	}{
+		{name: "one tooth", radius: radius, teeth: 1, toothDepth: toothDepth, length: 4},
+		{name: "tooth too deep", radius: radius, teeth: 6, toothDepth: radius, length: 4},
+		{name: "zero length", radius: radius, teeth: 6, toothDepth: toothDepth},
+	} {
+		if _, err := obj3.SplineShaft(test.radius, test.teeth, test.toothDepth, test.length); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
@@ -103,6 +103,17 @@ func (s *screw) Bounds() r3.Box {
 	return s.bb
 }
 
+// Describe returns kind "screw" with its "length", thread "pitch",
+// "lead" per turn and "taper" angle in radians.
+func (s *screw) Describe() (string, map[string]float64) {
+	return "screw", map[string]float64{
+		"length": 2 * s.length,
+		"pitch":  s.pitch,
+		"lead":   s.lead,
+		"taper":  s.taper,
+	}
+}
+
 func sawTooth(x, period float64) float64 {
 	x += period / 2
 	t := x / period
@@ -0,0 +1,33 @@
+package form3
+
+import (
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2"
+)
+
+// CylindricalText lays out text with size tall letters in the TrueType font file
+// font, or Go Regular if font is empty, and wraps it around a cylinder of radius
+// cylinderRadius with sdf.CylindricalText3D. The text is centered on the +X axis
+// with its baseline on the XY plane.
+func CylindricalText(font, text string, size, cylinderRadius, depth float64, engrave bool) (sdf.SDF3, error) {
+	f, err := form2.LoadFont(font)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := form2.Text(f, text, size)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.CylindricalText3D(profile, cylinderRadius, depth, engrave)
+}
+
+// NumberedParts returns count copies of base engraved with serial numbers with
+// sdf.NumberedParts3D, laying out labels in the TrueType font file font,
+// or Go Regular if font is empty.
+func NumberedParts(base sdf.SDF3, font, prefix string, start, count int, face sdf.Plane, size, depth float64) ([]sdf.SDF3, error) {
+	f, err := form2.LoadFont(font)
+	if err != nil {
+		return nil, err
+	}
+	return sdf.NumberedParts3D(base, form2.TextLabel(f, size), prefix, start, count, face, size, depth)
+}
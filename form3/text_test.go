@@ -0,0 +1,54 @@
+package form3_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestCylindricalText(t *testing.T) {
+	const radius, size, depth = 20, 5, 1
+	s, err := form3.CylindricalText("", "o", size, radius, depth, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bb := s.Bounds()
+	if math.Abs(bb.Max.X-(radius+depth)) > 1e-9 || bb.Min.Z > 0 || bb.Max.Z > size {
+		t.Errorf("got bounds %v", bb)
+	}
+	// The hole of the letter is centered on the +X axis.
+	mid := (bb.Min.Z + bb.Max.Z) / 2
+	if d := s.Evaluate(r3.Vec{X: radius + depth/2, Z: mid}); d < 0 {
+		t.Errorf("got %g in the hole of the letter", d)
+	}
+	if _, err := form3.CylindricalText("nonexistent.ttf", "o", size, radius, depth, false); err == nil {
+		t.Error("expected error for missing font file")
+	}
+}
+
+func TestNumberedParts(t *testing.T) {
+	base, err := form3.Box(r3.Vec{X: 10, Y: 10, Z: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	face := sdf.Plane{Point: r3.Vec{Z: 1}, Normal: r3.Vec{Z: 1}}
+	parts, err := form3.NumberedParts(base, "", "", 1, 2, face, 4, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("got %d parts, want 2", len(parts))
+	}
+	// The stem of the 1 crosses its center, the 2 leaves its center uncut.
+	for i, engraved := range []bool{true, false} {
+		if got := parts[i].Evaluate(r3.Vec{Z: 0.9}) > 0; got != engraved {
+			t.Errorf("part %d: got engraved %t at label center, want %t", i, got, engraved)
+		}
+	}
+	if _, err := form3.NumberedParts(base, "nonexistent.ttf", "", 1, 2, face, 4, 0.5); err == nil {
+		t.Error("expected error for missing font file")
+	}
+}
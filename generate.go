@@ -0,0 +1,70 @@
+package sdf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// GenerateFromJSON reads a JSON part specification from r and returns the SDF3 built by
+// the generator it names, which lets parts be tweaked through configuration files.
+// The specification names one of the generators and its numeric parameters:
+//
+//	{"generator": "bolt", "params": {"diameter": 8, "length": 30}}
+//
+// The parameters are passed to the generator, which should return an error
+// naming any missing or invalid parameter. Fields other than generator and
+// params are rejected to catch misspellings.
+func GenerateFromJSON(r io.Reader, generators map[string]func(map[string]float64) (SDF3, error)) (SDF3, error) {
+	var spec struct {
+		Generator *string            `json:"generator"`
+		Params    map[string]float64 `json:"params"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("decoding part specification: %w", err)
+	}
+	if spec.Generator == nil {
+		return nil, errors.New("part specification missing generator")
+	}
+	name := *spec.Generator
+	generate, ok := generators[name]
+	if !ok || generate == nil {
+		names := make([]string, 0, len(generators))
+		for n := range generators {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown generator %q, available generators: %s", name, strings.Join(names, ", "))
+	}
+	if spec.Params == nil {
+		spec.Params = make(map[string]float64)
+	}
+	s, err := generate(spec.Params)
+	if err != nil {
+		return nil, fmt.Errorf("generator %q: %w", name, err)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("generator %q returned nil SDF3", name)
+	}
+	return s, nil
+}
+
+// RequireParams returns an error naming the parameters of names missing from
+// params, for use by generators passed to GenerateFromJSON.
+func RequireParams(params map[string]float64, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing parameters: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
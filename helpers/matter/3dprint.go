@@ -1,6 +1,11 @@
 package matter
 
-import "github.com/soypat/sdf"
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"gonum.org/v1/gonum/spatial/r3"
+)
 
 type Material interface {
 	Scale(s sdf.SDF3) sdf.SDF3
@@ -37,3 +42,116 @@ func (m Viscoelastic) InternalDimScale(real float64) float64 {
 	}
 	return real*(m.shrink+1) + m.pullShrink
 }
+
+// Compensate3D offsets the surface of a part by outwardOffset to compensate
+// for dimensional errors of the printing process. A positive outwardOffset
+// grows the part, a negative one shrinks it.
+//
+// Printed holes usually come out undersized and pegs oversized, both of which
+// are fixed by shrinking the part since shrinking it grows its holes. A hole
+// is enlarged in radius, and a peg reduced, by the same amount:
+//
+//	// Holes 0.1mm larger in radius, pegs 0.1mm smaller.
+//	part = matter.Compensate3D(part, -0.1)
+//
+// To apply different compensations to holes and pegs, compensate the hole
+// and peg primitives separately before combining them, see sdf.MapLeaves3D.
+//
+// Compensate3D panics if the offset is not finite or would shrink the part
+// past the smallest dimension of its bounding box.
+func Compensate3D(s sdf.SDF3, outwardOffset float64) sdf.SDF3 {
+	if math.IsNaN(outwardOffset) || math.IsInf(outwardOffset, 0) {
+		panic("offset must be finite")
+	}
+	bb := s.Bounds()
+	size := bb.Max.Sub(bb.Min)
+	if -2*outwardOffset >= math.Min(size.X, math.Min(size.Y, size.Z)) {
+		panic("offset shrinks part past its smallest dimension")
+	}
+	return sdf.Offset3D(s, outwardOffset)
+}
+
+// FlattenBottom3D cuts away the part of s below z so that it rests on the
+// printer bed on a planar face at z. The bounding box is clipped to the cut.
+func FlattenBottom3D(s sdf.SDF3, z float64) sdf.SDF3 {
+	bb := s.Bounds()
+	if z >= bb.Max.Z {
+		panic("z above part")
+	}
+	return sdf.Trim3D(s, []sdf.Plane{{Point: r3.Vec{Z: z}, Normal: r3.Vec{Z: -1}}})
+}
+
+// AutoFlattenBottom3D flattens s at the lowest point of its surface and clips
+// its bounding box there, so the part sits exactly on the bed when placed at the
+// bottom of its bounding box. The bounding boxes of rotated parts are often loose,
+// so the lowest point is found by marching rays up from the bottom of the bounding
+// box on a grid, taking half steps if s is not exact. Use FlattenBottom3D slightly
+// above it for a larger contact area.
+func AutoFlattenBottom3D(s sdf.SDF3) sdf.SDF3 {
+	const grid = 64
+	step := 1.0
+	if !sdf.IsExactSDF3(s) {
+		step = 0.5
+	}
+	bb := s.Bounds()
+	size := bb.Max.Sub(bb.Min)
+	tol := 1e-6 * r3.Norm(size)
+	lowest := bb.Max.Z
+	for i := 0; i <= grid; i++ {
+		for j := 0; j <= grid; j++ {
+			p := r3.Vec{X: bb.Min.X + size.X*float64(i)/grid, Y: bb.Min.Y + size.Y*float64(j)/grid, Z: bb.Min.Z}
+			for p.Z < lowest {
+				d := s.Evaluate(p)
+				if d <= tol {
+					lowest = p.Z
+					break
+				}
+				p.Z += step * d
+			}
+		}
+	}
+	if lowest >= bb.Max.Z {
+		panic("no surface found")
+	}
+	return FlattenBottom3D(s, lowest)
+}
+
+// Overhangs3D returns points on the surface of s which overhang by more
+// than maxAngle, in radians, from the vertical and need support when
+// printed along Z. Walls have an overhang of 0 and downward facing
+// horizontal faces an overhang of π/2. A common limit is π/4.
+//
+// The surface is sampled in cells of a grid with samples cells along the longest
+// side of the bounding box. Points at the bottom of the bounding box rest on
+// the bed and are not reported.
+func Overhangs3D(s sdf.SDF3, maxAngle float64, samples int) []r3.Vec {
+	if samples <= 0 {
+		panic("samples <= 0")
+	}
+	bb := s.Bounds()
+	size := bb.Max.Sub(bb.Min)
+	cell := math.Max(size.X, math.Max(size.Y, size.Z)) / float64(samples)
+	halfDiag := 0.5 * math.Sqrt(3) * cell
+	eps := 1e-3 * cell
+	minNormalZ := -math.Sin(maxAngle)
+	var overhangs []r3.Vec
+	for i := 0; float64(i)*cell < size.X; i++ {
+		for j := 0; float64(j)*cell < size.Y; j++ {
+			for k := 0; float64(k)*cell < size.Z; k++ {
+				p := bb.Min.Add(r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+				if math.Abs(s.Evaluate(p)) > halfDiag {
+					continue
+				}
+				p, _ = sdf.ProjectToSurface3D(s, p, eps, eps)
+				if p.Z-bb.Min.Z < cell/2 {
+					continue // on the bed.
+				}
+				n := r3.Unit(sdf.EvaluateGradient(s, p, eps))
+				if n.Z < minNormalZ {
+					overhangs = append(overhangs, p)
+				}
+			}
+		}
+	}
+	return overhangs
+}
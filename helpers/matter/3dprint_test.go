@@ -0,0 +1,114 @@
+package matter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"github.com/soypat/sdf/helpers/matter"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// box returns a box of the given size with its minimum corner at min.
+func box(min, size r3.Vec) sdf.SDF3 {
+	return sdf.Transform3D(must3.Box(size, 0), sdf.Translate3D(r3.Add(min, r3.Scale(0.5, size))))
+}
+
+// tee returns a T shape standing on z=0, a 2x2 stem reaching into
+// an 8x2x1 bar at z=4 which overhangs the stem along X.
+func tee() sdf.SDF3 {
+	return sdf.Union3D(
+		box(r3.Vec{X: -1, Y: -1}, r3.Vec{X: 2, Y: 2, Z: 4.5}),
+		box(r3.Vec{X: -4, Y: -1, Z: 4}, r3.Vec{X: 8, Y: 2, Z: 1}),
+	)
+}
+
+func TestOverhangs3D(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		s    sdf.SDF3
+		// check reports whether an overhanging point is expected there.
+		check   func(p r3.Vec) bool
+		wantAny bool
+	}{
+		{
+			name:  "box on bed",
+			s:     box(r3.Vec{}, r3.Vec{X: 2, Y: 3, Z: 4}),
+			check: func(r3.Vec) bool { return false },
+		},
+		{
+			name:    "tee",
+			s:       tee(),
+			check:   func(p r3.Vec) bool { return math.Abs(p.Z-4) < 1e-3 && math.Abs(p.X) >= 1-1e-3 },
+			wantAny: true,
+		},
+		{
+			// Below 45° from the bottom of the sphere the surface overhangs.
+			name:    "sphere",
+			s:       must3.Sphere(2),
+			check:   func(p r3.Vec) bool { return p.Z < -2*math.Sin(math.Pi/4)+0.1 },
+			wantAny: true,
+		},
+	} {
+		got := matter.Overhangs3D(test.s, math.Pi/4, 40)
+		if test.wantAny && len(got) == 0 {
+			t.Errorf("%s: got no overhangs", test.name)
+		}
+		for _, p := range got {
+			if !test.check(p) {
+				t.Errorf("%s: unexpected overhang at %v", test.name, p)
+				break
+			}
+		}
+	}
+}
+
+func TestFlattenBottom3D(t *testing.T) {
+	flat := matter.FlattenBottom3D(must3.Sphere(1), -0.5)
+	if got := flat.Bounds().Min.Z; got != -0.5 {
+		t.Errorf("got bottom of bounds %g, want -0.5", got)
+	}
+	for _, test := range []struct {
+		p      r3.Vec
+		inside bool
+	}{
+		{p: r3.Vec{Z: -0.45}, inside: true},
+		{p: r3.Vec{Z: -0.55}},
+		{p: r3.Vec{X: 0.8, Z: -0.45}, inside: true},
+		{p: r3.Vec{Z: 0.9}, inside: true},
+	} {
+		if got := flat.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("at %v got inside %t, want %t", test.p, got, test.inside)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for z above part")
+			}
+		}()
+		matter.FlattenBottom3D(must3.Sphere(1), 1)
+	}()
+}
+
+func TestAutoFlattenBottom3D(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		s    sdf.SDF3
+		want float64
+	}{
+		{name: "box dropped by z", s: box(r3.Vec{X: -1, Y: -1, Z: -3}, r3.Vec{X: 2, Y: 2, Z: 1}), want: -3},
+		// Rotated spheres have bounding boxes reaching below the sphere.
+		{name: "rotated sphere", s: sdf.Transform3D(must3.Sphere(1), sdf.RotateX(math.Pi/4)), want: -1},
+		{name: "tee", s: tee(), want: 0},
+	} {
+		if bb := test.s.Bounds(); test.name == "rotated sphere" && bb.Min.Z > test.want-0.1 {
+			t.Fatalf("%s: bounds %v not loose", test.name, bb)
+		}
+		flat := matter.AutoFlattenBottom3D(test.s)
+		if got := flat.Bounds().Min.Z; math.Abs(got-test.want) > 1e-3 {
+			t.Errorf("%s: got bottom %g, want %g", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package matter
+
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// maxOverhang is the overhang angle from the vertical beyond
+// which ValidatePrintable reports the surface needs support.
+const maxOverhang = math.Pi / 4
+
+// PrintReport lists the manufacturability issues of a part found by ValidatePrintable.
+type PrintReport struct {
+	// ThinWalls are surface points where the part is thinner than
+	// can be printed.
+	ThinWalls []ThinWall
+	// Overhangs are surface points which need support, see Overhangs3D.
+	Overhangs []r3.Vec
+	// Islands are the parts of the solid disconnected from its largest
+	// body, which would be printed as separate loose pieces.
+	Islands []Island
+}
+
+// ThinWall is a point on the surface of a part where the part is too thin.
+type ThinWall struct {
+	Point     r3.Vec
+	Thickness float64 // thickness measured along the inward normal.
+}
+
+// Island is a piece of a part disconnected from its largest body.
+type Island struct {
+	Center r3.Vec // centroid of the island.
+	Volume float64
+}
+
+// Issues returns the total number of issues in the report.
+func (r PrintReport) Issues() int {
+	return len(r.ThinWalls) + len(r.Overhangs) + len(r.Islands)
+}
+
+// ValidatePrintable samples s on a grid with res cells along each axis of its bounding box
+// and reports the issues which prevent printing it along Z in a single pass:
+//   - Walls thinner than nozzleWidth, or than layerHeight for faces closer to horizontal
+//     than vertical. Thickness is measured by marching from the surface along the
+//     inward normal, given by the gradient, until the opposite surface.
+//   - Overhangs of more than 45° from the vertical, excluding points on the bed.
+//   - Islands: groups of inside cells not connected through cell faces to the largest group.
+//
+// Surface issues are reported once for each cell the surface crosses, so large
+// problem areas give many points. Features smaller than a cell may be missed.
+func ValidatePrintable(s sdf.SDF3, nozzleWidth, layerHeight float64, res sdf.V3i) PrintReport {
+	if nozzleWidth <= 0 || layerHeight <= 0 {
+		panic("nozzle width and layer height must be positive")
+	}
+	if res[0] < 1 || res[1] < 1 || res[2] < 1 {
+		panic("bad grid dimensions")
+	}
+	bb := s.Bounds()
+	size := bb.Max.Sub(bb.Min)
+	cell := r3.Vec{X: size.X / float64(res[0]), Y: size.Y / float64(res[1]), Z: size.Z / float64(res[2])}
+	halfDiag := r3.Norm(cell) / 2
+	eps := 1e-3 * math.Min(cell.X, math.Min(cell.Y, cell.Z))
+	minNormalZ := -math.Sin(maxOverhang)
+	index := func(i, j, k int) int { return i + res[0]*(j+res[1]*k) }
+	center := func(i, j, k int) r3.Vec {
+		return bb.Min.Add(r3.Vec{X: cell.X * (float64(i) + 0.5), Y: cell.Y * (float64(j) + 0.5), Z: cell.Z * (float64(k) + 0.5)})
+	}
+	values := make([]float64, res[0]*res[1]*res[2])
+	var report PrintReport
+	for k := 0; k < res[2]; k++ {
+		for j := 0; j < res[1]; j++ {
+			for i := 0; i < res[0]; i++ {
+				p := center(i, j, k)
+				d := s.Evaluate(p)
+				values[index(i, j, k)] = d
+				if math.Abs(d) > halfDiag {
+					continue
+				}
+				p, _ = sdf.ProjectToSurface3D(s, p, eps, eps)
+				n := r3.Unit(sdf.EvaluateGradient(s, p, eps))
+				if math.IsNaN(n.X) {
+					continue
+				}
+				if n.Z < minNormalZ && p.Z-bb.Min.Z >= cell.Z/2 {
+					report.Overhangs = append(report.Overhangs, p)
+				}
+				minThickness := nozzleWidth
+				if math.Abs(n.Z) > math.Sqrt2/2 {
+					minThickness = layerHeight
+				}
+				if t, thin := wallThickness(s, p, n, minThickness); thin {
+					report.ThinWalls = append(report.ThinWalls, ThinWall{Point: p, Thickness: t})
+				}
+			}
+		}
+	}
+	report.Islands = islands(values, res, func(idx sdf.V3i) r3.Vec { return center(idx[0], idx[1], idx[2]) }, cell.X*cell.Y*cell.Z)
+	return report
+}
+
+// wallThickness marches from p on the surface of s along -n until it leaves
+// s and returns the distance travelled if it is shorter than limit.
+func wallThickness(s sdf.SDF3, p, n r3.Vec, limit float64) (float64, bool) {
+	minStep := limit / 32
+	for t := minStep; t < limit; {
+		d := s.Evaluate(p.Sub(r3.Scale(t, n)))
+		if d >= 0 {
+			return t, true
+		}
+		t += math.Max(-d, minStep)
+	}
+	return 0, false
+}
+
+// islands groups the negative values of the grid of cell values with res cells
+// along each axis into groups connected through cell faces and returns all
+// groups but the largest. center returns the center of a cell.
+func islands(values []float64, res sdf.V3i, center func(sdf.V3i) r3.Vec, cellVolume float64) []Island {
+	index := func(c sdf.V3i) int { return c[0] + res[0]*(c[1]+res[1]*c[2]) }
+	visited := make([]bool, len(values))
+	neighbors := [6]sdf.V3i{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+	var groups []Island
+	largest := -1
+	for k := 0; k < res[2]; k++ {
+		for j := 0; j < res[1]; j++ {
+			for i := 0; i < res[0]; i++ {
+				start := sdf.V3i{i, j, k}
+				if visited[index(start)] || values[index(start)] >= 0 {
+					continue
+				}
+				// Flood fill the group.
+				visited[index(start)] = true
+				stack := []sdf.V3i{start}
+				var sum r3.Vec
+				count := 0
+				for len(stack) > 0 {
+					c := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					sum = sum.Add(center(c))
+					count++
+					for _, o := range neighbors {
+						nb := c.Add(o)
+						if nb[0] < 0 || nb[1] < 0 || nb[2] < 0 || nb[0] >= res[0] || nb[1] >= res[1] || nb[2] >= res[2] {
+							continue
+						}
+						if idx := index(nb); !visited[idx] && values[idx] < 0 {
+							visited[idx] = true
+							stack = append(stack, nb)
+						}
+					}
+				}
+				groups = append(groups, Island{Center: r3.Scale(1/float64(count), sum), Volume: float64(count) * cellVolume})
+				if largest < 0 || groups[len(groups)-1].Volume > groups[largest].Volume {
+					largest = len(groups) - 1
+				}
+			}
+		}
+	}
+	if largest < 0 {
+		return nil
+	}
+	return append(groups[:largest], groups[largest+1:]...)
+}
@@ -0,0 +1,52 @@
+package matter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/helpers/matter"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestValidatePrintable(t *testing.T) {
+	const nozzle, layer = 0.4, 0.2
+	res := sdf.V3i{20, 20, 20}
+	for _, test := range []struct {
+		name                          string
+		s                             sdf.SDF3
+		thinWalls, overhangs, islands bool
+	}{
+		{name: "box", s: box(r3.Vec{}, r3.Vec{X: 4, Y: 4, Z: 4})},
+		{name: "thin wall", s: box(r3.Vec{}, r3.Vec{X: 0.2, Y: 4, Z: 4}), thinWalls: true},
+		{name: "tee", s: tee(), overhangs: true},
+		{
+			name: "island",
+			s: sdf.Union3D(
+				box(r3.Vec{}, r3.Vec{X: 4, Y: 4, Z: 4}),
+				box(r3.Vec{X: 6}, r3.Vec{X: 1, Y: 1, Z: 1}),
+			),
+			islands: true,
+		},
+	} {
+		report := matter.ValidatePrintable(test.s, nozzle, layer, res)
+		if got := len(report.ThinWalls) > 0; got != test.thinWalls {
+			t.Errorf("%s: got %d thin walls, want any %t", test.name, len(report.ThinWalls), test.thinWalls)
+		}
+		if got := len(report.Overhangs) > 0; got != test.overhangs {
+			t.Errorf("%s: got %d overhangs, want any %t", test.name, len(report.Overhangs), test.overhangs)
+		}
+		if got := len(report.Islands) > 0; got != test.islands {
+			t.Errorf("%s: got %d islands, want any %t", test.name, len(report.Islands), test.islands)
+		}
+		for _, w := range report.ThinWalls {
+			if math.Abs(w.Thickness-0.2) > 0.05 {
+				t.Errorf("%s: got thin wall of thickness %g at %v, want 0.2", test.name, w.Thickness, w.Point)
+				break
+			}
+		}
+		if got := report.Issues(); got != len(report.ThinWalls)+len(report.Overhangs)+len(report.Islands) {
+			t.Errorf("%s: got %d issues", test.name, got)
+		}
+	}
+}
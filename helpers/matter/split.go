@@ -0,0 +1,247 @@
+package matter
+
+import (
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// JointType is the kind of joint added between the pieces of a split part.
+type JointType int
+
+// Joints added by SplitForPrinting3D at each cut face. The lower piece along the
+// axis normal to the cut carries the joint, which protrudes into the upper piece,
+// and the upper piece gets a matching socket enlarged by JointClearance.
+const (
+	// JointNone leaves the cut faces flat, to be glued or aligned by hand.
+	JointNone JointType = iota
+	// JointPin adds a round pin normal to the cut face which fits a hole in
+	// the neighbouring piece. Pieces are assembled by pushing them together
+	// and are usually glued.
+	JointPin
+	// JointDovetail adds a dovetail key running across the cut face which is
+	// slid into a matching slot of the neighbouring piece along the cut face.
+	// The key locks the pieces against being pulled apart.
+	JointDovetail
+)
+
+// JointClearance is the gap between joints and their sockets, in millimeters,
+// so that printed pieces fit together.
+const JointClearance = 0.2
+
+// dovetailAngle is the flare angle of the sides of dovetail keys.
+const dovetailAngle = 15 * math.Pi / 180
+
+// SplitForPrinting3D cuts s into pieces which fit a printer of bed size bedSize by
+// planes normal to the axes, dividing the bounding box of s into equal cells, and
+// adds joints of type joint at the cut faces so the pieces can be reassembled.
+// Cells containing no solid are left out. Pieces keep their position in the part,
+// so they must be rotated to rest on a flat face and moved onto the bed before printing.
+// With joints the cells are made 10% smaller than the bed to leave room for the
+// joints protruding out of the pieces.
+//
+// One joint is added at each cut face between two cells, centered on the deepest
+// point of the solid on the middle of the face, found by sampling, and sized
+// from its depth. Faces where the solid is too thin for a joint are left flat.
+func SplitForPrinting3D(s sdf.SDF3, bedSize r3.Vec, joint JointType) []sdf.SDF3 {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	if !(bedSize.X > 0 && bedSize.Y > 0 && bedSize.Z > 0) {
+		panic("bed size must be positive")
+	}
+	if joint < JointNone || joint > JointDovetail {
+		panic("unknown joint type")
+	}
+	bb := s.Bounds()
+	bed := vecArray(bedSize)
+	if joint != JointNone {
+		bed = vecArray(r3.Scale(0.9, bedSize))
+	}
+	origin, size := vecArray(bb.Min), vecArray(bb.Max.Sub(bb.Min))
+	var n sdf.V3i
+	var cell [3]float64
+	for k := range n {
+		n[k] = int(math.Max(1, math.Ceil(size[k]/bed[k])))
+		cell[k] = size[k] / float64(n[k])
+	}
+	cellBox := func(c sdf.V3i) r3.Box {
+		var lo, hi [3]float64
+		for k := range c {
+			lo[k] = origin[k] + cell[k]*float64(c[k])
+			hi[k] = lo[k] + cell[k]
+		}
+		return r3.Box{Min: arrayVec(lo), Max: arrayVec(hi)}
+	}
+	// Joints carried by each cell and sockets cut into it.
+	joints := make(map[sdf.V3i][]sdf.SDF3)
+	sockets := make(map[sdf.V3i][]sdf.SDF3)
+	if joint != JointNone {
+		for k := 0; k < 3; k++ {
+			for c := (sdf.V3i{}); c[2] < n[2]; c = nextCell(c, n) {
+				if c[k] == n[k]-1 {
+					continue
+				}
+				upper := c
+				upper[k]++
+				key, socket := cutJoint(s, cellBox(c), k, 0.1*vecArray(bedSize)[k], joint)
+				if key != nil {
+					joints[c] = append(joints[c], key)
+					sockets[upper] = append(sockets[upper], socket)
+				}
+			}
+		}
+	}
+	var pieces []sdf.SDF3
+	for c := (sdf.V3i{}); c[2] < n[2]; c = nextCell(c, n) {
+		box := cellBox(c)
+		if !hasSolid(s, box) {
+			continue
+		}
+		// Cut only at the faces between cells, the outer
+		// faces of the bounding box need no trimming.
+		var planes []sdf.Plane
+		for k := range c {
+			normal := arrayVec(unitArray(k))
+			if c[k] > 0 {
+				planes = append(planes, sdf.Plane{Point: box.Min, Normal: r3.Scale(-1, normal)})
+			}
+			if c[k] < n[k]-1 {
+				planes = append(planes, sdf.Plane{Point: box.Max, Normal: normal})
+			}
+		}
+		piece := s
+		if len(planes) > 0 {
+			piece = sdf.Trim3D(s, planes)
+		}
+		if len(joints[c]) > 0 {
+			piece = sdf.Union3D(append([]sdf.SDF3{piece}, joints[c]...)...)
+		}
+		for _, socket := range sockets[c] {
+			piece = sdf.Difference3D(piece, socket)
+		}
+		pieces = append(pieces, piece)
+	}
+	return pieces
+}
+
+// cutJoint returns the joint carried by the cell box at its upper face normal to
+// axis k and the socket to cut from the neighbouring cell, or nil if the solid is
+// too thin at the face. Joints protrude at most maxProtrusion from the face.
+func cutJoint(s sdf.SDF3, box r3.Box, k int, maxProtrusion float64, joint JointType) (key, socket sdf.SDF3) {
+	const samples = 16
+	u, v := (k+1)%3, (k+2)%3
+	lo, hi := vecArray(box.Min), vecArray(box.Max)
+	// Find the deepest point of the solid on the middle of the face,
+	// so that joints, which are smaller than a quarter of the face,
+	// do not reach into the neighbouring cells. Of points about as
+	// deep, as on slabs, the one nearest the center of the face is used.
+	var deepest [3]float64
+	depth, offCenter := 0.0, math.Inf(1)
+	tol := 1e-6 * r3.Norm(box.Max.Sub(box.Min))
+	for i := 0; i < samples; i++ {
+		for j := 0; j < samples; j++ {
+			var p [3]float64
+			p[k] = hi[k]
+			p[u] = lo[u] + (hi[u]-lo[u])*(0.25+0.5*(float64(i)+0.5)/samples)
+			p[v] = lo[v] + (hi[v]-lo[v])*(0.25+0.5*(float64(j)+0.5)/samples)
+			d := -s.Evaluate(arrayVec(p))
+			off := math.Hypot(p[u]-(lo[u]+hi[u])/2, p[v]-(lo[v]+hi[v])/2)
+			if d > depth+tol || (d > depth-tol && off < offCenter) {
+				deepest, depth, offCenter = p, math.Max(d, depth), off
+			}
+		}
+	}
+	// The joint, with its socket, must lie within the solid and the cells.
+	r := math.Min(depth/3, maxProtrusion/2)
+	r = math.Min(r, math.Min(hi[u]-lo[u], hi[v]-lo[v])/8)
+	if r <= 2*JointClearance || math.Hypot(r+JointClearance, 2*r+JointClearance) > depth {
+		return nil, nil
+	}
+	center := arrayVec(deepest)
+	axis := arrayVec(unitArray(k))
+	switch joint {
+	case JointPin:
+		// Pins are cylinders along Z rotated onto the axis.
+		rot := sdf.Translate3D(r3.Vec{})
+		switch k {
+		case 0:
+			rot = sdf.RotateY(math.Pi / 2)
+		case 1:
+			rot = sdf.RotateX(math.Pi / 2)
+		}
+		pin := sdf.Transform3D(must3.Cylinder(2*r, r, 0), sdf.Translate3D(center.Add(r3.Scale(r, axis))).Mul(rot))
+		hole := sdf.Transform3D(must3.Cylinder(2*r+2*JointClearance, r+JointClearance, 0),
+			sdf.Translate3D(center.Add(r3.Scale(r, axis))).Mul(rot))
+		return pin, hole
+	}
+	// Dovetail keys run across the face along v, flaring along u away from the face.
+	var size [3]float64
+	size[k] = 2 * r
+	size[u] = 2 * (r + 2*r*math.Tan(dovetailAngle))
+	size[v] = hi[v] - lo[v]
+	boxCenter := deepest
+	boxCenter[k] += r
+	boxCenter[v] = (lo[v] + hi[v]) / 2
+	prism := sdf.Transform3D(must3.Box(arrayVec(size), 0), sdf.Translate3D(arrayVec(boxCenter)))
+	var out [3]float64
+	out[u] = 1
+	out[k] = -math.Tan(dovetailAngle)
+	var in [3]float64
+	in[u] = -1
+	in[k] = -math.Tan(dovetailAngle)
+	side := arrayVec(deepest)
+	planes := []sdf.Plane{
+		{Point: side.Add(r3.Scale(r, arrayVec(unitArray(u)))), Normal: arrayVec(out)},
+		{Point: side.Sub(r3.Scale(r, arrayVec(unitArray(u)))), Normal: arrayVec(in)},
+	}
+	dovetail := sdf.Trim3D(prism, planes)
+	return sdf.Intersect3D(dovetail, s), sdf.Offset3D(dovetail, JointClearance)
+}
+
+// hasSolid reports whether s may have solid within box, sampling it on a grid.
+func hasSolid(s sdf.SDF3, box r3.Box) bool {
+	const samples = 8
+	size := box.Max.Sub(box.Min)
+	step := r3.Scale(1.0/samples, size)
+	halfDiag := r3.Norm(step) / 2
+	for i := 0; i < samples; i++ {
+		for j := 0; j < samples; j++ {
+			for k := 0; k < samples; k++ {
+				p := box.Min.Add(r3.Vec{X: step.X * (float64(i) + 0.5), Y: step.Y * (float64(j) + 0.5), Z: step.Z * (float64(k) + 0.5)})
+				if s.Evaluate(p) < halfDiag {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// nextCell returns the cell after c in a grid of n cells, X first.
+// The Z index reaches n[2] past the last cell.
+func nextCell(c, n sdf.V3i) sdf.V3i {
+	c[0]++
+	if c[0] == n[0] {
+		c[0] = 0
+		c[1]++
+		if c[1] == n[1] {
+			c[1] = 0
+			c[2]++
+		}
+	}
+	return c
+}
+
+func vecArray(v r3.Vec) [3]float64 { return [3]float64{v.X, v.Y, v.Z} }
+
+func arrayVec(a [3]float64) r3.Vec { return r3.Vec{X: a[0], Y: a[1], Z: a[2]} }
+
+// unitArray returns the unit vector along axis k.
+func unitArray(k int) [3]float64 {
+	var a [3]float64
+	a[k] = 1
+	return a
+}
@@ -0,0 +1,95 @@
+package matter_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/helpers/matter"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestSplitForPrinting3D(t *testing.T) {
+	const step = 0.2
+	part := box(r3.Vec{}, r3.Vec{X: 18, Y: 12, Z: 6})
+	bed := r3.Vec{X: 10, Y: 10, Z: 10}
+	// With joints cells are 90% of the bed: 2 cells of 9 along X and 2 of 6 along Y.
+	cell := r3.Vec{X: 9, Y: 6, Z: 6}
+	for _, test := range []struct {
+		name  string
+		joint matter.JointType
+	}{
+		{name: "none", joint: matter.JointNone},
+		{name: "pin", joint: matter.JointPin},
+		{name: "dovetail", joint: matter.JointDovetail},
+	} {
+		pieces := matter.SplitForPrinting3D(part, bed, test.joint)
+		if len(pieces) != 4 {
+			t.Fatalf("%s: got %d pieces, want 4", test.name, len(pieces))
+		}
+		// owner is the piece holding the center of each cell.
+		owner := map[[2]int]int{}
+		for i := 0; i < 2; i++ {
+			for j := 0; j < 2; j++ {
+				center := r3.Vec{X: cell.X * (float64(i) + 0.5), Y: cell.Y * (float64(j) + 0.5), Z: 3}
+				for k, piece := range pieces {
+					if piece.Evaluate(center) < 0 {
+						owner[[2]int{i, j}] = k
+					}
+				}
+			}
+		}
+		if len(owner) != 4 {
+			t.Fatalf("%s: cells owned by %v, want a piece each", test.name, owner)
+		}
+		extents := make([]r3.Box, len(pieces))
+		for k := range extents {
+			extents[k] = r3.Box{Min: r3.Vec{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)}, Max: r3.Vec{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)}}
+		}
+		keyPoints := 0
+		for x := -1 + step/2; x < 19; x += step {
+			for y := -1 + step/2; y < 13; y += step {
+				for z := -1 + step/2; z < 7; z += step {
+					p := r3.Vec{X: x, Y: y, Z: z}
+					c := [2]int{int(math.Floor(x / cell.X)), int(math.Floor(y / cell.Y))}
+					inside := -1
+					for k, piece := range pieces {
+						if piece.Evaluate(p) >= 0 {
+							continue
+						}
+						if inside >= 0 {
+							t.Fatalf("%s: pieces %d and %d overlap at %v", test.name, inside, k, p)
+						}
+						inside = k
+						extents[k].Min = r3.Vec{X: math.Min(extents[k].Min.X, x), Y: math.Min(extents[k].Min.Y, y), Z: math.Min(extents[k].Min.Z, z)}
+						extents[k].Max = r3.Vec{X: math.Max(extents[k].Max.X, x), Y: math.Max(extents[k].Max.Y, y), Z: math.Max(extents[k].Max.Z, z)}
+					}
+					if test.joint == matter.JointNone {
+						// The pieces union back to the part.
+						if want := part.Evaluate(p) < 0; want != (inside >= 0) {
+							t.Fatalf("%s: got inside a piece %t at %v, want %t", test.name, inside >= 0, p, want)
+						}
+						continue
+					}
+					own, ok := owner[c]
+					if inside < 0 || !ok || inside == own {
+						continue
+					}
+					// p lies in a joint protruding into the neighbouring cell,
+					// which must be clear of the socket cut for it.
+					keyPoints++
+					if d := pieces[own].Evaluate(p); d < 0.9*matter.JointClearance {
+						t.Fatalf("%s: joint at %v within %g of the socket, want clearance %g", test.name, p, d, matter.JointClearance)
+					}
+				}
+			}
+		}
+		if test.joint != matter.JointNone && keyPoints == 0 {
+			t.Errorf("%s: no joints found", test.name)
+		}
+		for k, e := range extents {
+			if size := e.Max.Sub(e.Min); size.X > bed.X || size.Y > bed.Y || size.Z > bed.Z {
+				t.Errorf("%s: piece %d of size %v does not fit the bed", test.name, k, size)
+			}
+		}
+	}
+}
@@ -16,24 +16,97 @@ import (
 // using vertexTol.
 // vertexTol should be of the order of 1/1000th of the size of the smallest
 // triangle in the model. If set to 0 then it is inferred automatically.
+// The sign of the distance is found with pseudo normals, see ImportModelWithOptions.
+//
+// Models rendered from an SDF3 by the render package can be imported to continue
+// modelling, for example after editing the mesh elsewhere. The surface of the imported
+// SDF3 then lies within one mesh cell of the surface of the original SDF3.
 func ImportModel(model []r3.Triangle, vertexTolOrZero float64) (ImportedSDF3, error) {
-	m, err := newMesh(model, vertexTolOrZero)
+	return ImportModelWithOptions(model, ImportOptions{
+		VertexTol:  vertexTolOrZero,
+		SignMethod: SignPseudoNormal,
+	})
+}
+
+// SignMethod selects how an imported model decides whether a point is inside.
+type SignMethod int
+
+const (
+	// SignWindingNumber sums the solid angles subtended by all triangles at the
+	// point, which is the generalized winding number of Jacobson et al. Points with
+	// a winding number above 1/2 are inside. It gives the expected result for meshes
+	// with holes, self intersections or overlapping parts but evaluation
+	// time grows linearly with the number of triangles.
+	SignWindingNumber SignMethod = iota
+	// SignPseudoNormal uses the angle weighted pseudo normal of the closest
+	// triangle feature. It is fast but only reliable for closed, consistently
+	// oriented and non self intersecting meshes. Other meshes may come out inside
+	// out or speckled with wrongly signed regions.
+	SignPseudoNormal
+)
+
+// ImportOptions configures ImportModelWithOptions.
+type ImportOptions struct {
+	// VertexTol is the distance under which vertices are merged. See ImportModel.
+	VertexTol float64
+	// SignMethod is SignWindingNumber by default.
+	SignMethod SignMethod
+}
+
+// ImportModelWithOptions instantiates an SDF3 from a set of triangles such as those read
+// from STL and 3MF files. Unlike ImportModel it defaults to the robust but slower
+// winding number sign test which handles imperfect meshes.
+func ImportModelWithOptions(model []r3.Triangle, opts ImportOptions) (ImportedSDF3, error) {
+	m, err := newMesh(model, opts.VertexTol)
 	if err != nil {
 		return ImportedSDF3{}, err
 	}
 	tree := kdtree.New(m, true)
-	return ImportedSDF3{tree: *tree, mesh: m}, nil
+	s := ImportedSDF3{tree: *tree, mesh: m}
+	switch opts.SignMethod {
+	case SignWindingNumber:
+		s.winding = append([]r3.Triangle{}, model...)
+	case SignPseudoNormal:
+	default:
+		return ImportedSDF3{}, errors.New("unknown sign method")
+	}
+	return s, nil
 }
 
 type ImportedSDF3 struct {
 	tree kdtree.Tree
 	mesh *mesh
+	// winding holds the triangles for the winding number sign test.
+	// nil if pseudo normals are used.
+	winding []r3.Triangle
 }
 
 func (s ImportedSDF3) Evaluate(q r3.Vec) float64 {
 	tri, dist2 := s.tree.Nearest(&meshTriangle{C: q})
+	dist := math.Sqrt(dist2)
+	if s.winding != nil {
+		if windingNumber(s.winding, q) > 0.5 {
+			return -dist
+		}
+		return dist
+	}
 	kd := tri.(*meshTriangle)
-	return kd.CopySign(q, math.Sqrt(dist2))
+	return kd.CopySign(q, dist)
+}
+
+// windingNumber returns the generalized winding number of the triangles at p,
+// which is 1 inside and 0 outside a closed mesh with outward facing normals.
+// The solid angle of each triangle is computed with the formula of Van Oosterom and Strackee.
+func windingNumber(tris []r3.Triangle, p r3.Vec) float64 {
+	var sum float64
+	for _, t := range tris {
+		a, b, c := r3.Sub(t[0], p), r3.Sub(t[1], p), r3.Sub(t[2], p)
+		la, lb, lc := r3.Norm(a), r3.Norm(b), r3.Norm(c)
+		num := r3.Dot(a, r3.Cross(b, c))
+		den := la*lb*lc + r3.Dot(a, b)*lc + r3.Dot(b, c)*la + r3.Dot(c, a)*lb
+		sum += 2 * math.Atan2(num, den)
+	}
+	return sum / (4 * math.Pi)
 }
 
 func (s ImportedSDF3) Bounds() r3.Box {
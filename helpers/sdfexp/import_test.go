@@ -1,13 +1,18 @@
 package sdfexp_test
 
 import (
+	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/soypat/sdf"
 	"github.com/soypat/sdf/form3"
 	"github.com/soypat/sdf/form3/obj3/thread"
 	"github.com/soypat/sdf/helpers/sdfexp"
 	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
 )
 
 func TestImportModel(t *testing.T) {
@@ -22,7 +27,11 @@ func TestImportModel(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	fp, _ := os.Create("src.stl")
+	dir := t.TempDir()
+	fp, err := os.Create(filepath.Join(dir, "src.stl"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	defer fp.Close()
 	err = render.WriteSTL(fp, model)
 	if err != nil {
@@ -32,8 +41,87 @@ func TestImportModel(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	err = render.CreateSTL("imported.stl", render.NewOctreeRenderer(sdf, quality))
+	err = render.CreateSTL(filepath.Join(dir, "imported.stl"), render.NewOctreeRenderer(sdf, quality))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportModelWindingNumber(t *testing.T) {
+	// Two overlapping spheres meshed separately and concatenated without
+	// a boolean union, so the surface of each lies within the other.
+	sphere, _ := form3.Sphere(1)
+	a, err := render.RenderAll(render.NewOctreeRenderer(sphere, 20))
 	if err != nil {
 		t.Fatal(err)
 	}
+	b, err := render.RenderAll(render.NewOctreeRenderer(sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: 1})), 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := append(a, b...)
+	robust, err := sdfexp.ImportModelWithOptions(model, sdfexp.ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Points inside one sphere and just outside the other, where pseudo
+	// normals of the closest surface give the wrong sign.
+	for _, p := range []r3.Vec{{X: -0.05}, {X: 0.5}, {X: 1.05}} {
+		if d := robust.Evaluate(p); d >= 0 {
+			t.Errorf("point %v inside overlapping spheres has distance %g", p, d)
+		}
+	}
+	for _, p := range []r3.Vec{{X: -1.1}, {X: 2.1}, {Y: 1.2}} {
+		if d := robust.Evaluate(p); d <= 0 {
+			t.Errorf("point %v outside overlapping spheres has distance %g", p, d)
+		}
+	}
+}
+
+func TestImportModelRoundTrip(t *testing.T) {
+	const res = 24
+	box, _ := form3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0.2)
+	hole, _ := form3.Cylinder(3, 0.5, 0)
+	part := sdf.Union3D(sdf.Difference3D(box, hole), sdf.Transform3D(box, sdf.Translate3D(r3.Vec{X: 1.5, Y: 1, Z: 0.5})))
+	model, err := render.RenderAll(render.NewOctreeRenderer(part, res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := sdfexp.ImportModel(model, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := part.Bounds().Size()
+	cell := math.Max(size.X, math.Max(size.Y, size.Z)) / res
+	// The Hausdorff distance between both surfaces is the largest distance
+	// from a point on either surface to the other surface.
+	var hausdorff float64
+	for _, tri := range model {
+		for _, v := range tri {
+			hausdorff = math.Max(hausdorff, math.Abs(part.Evaluate(v)))
+		}
+	}
+	for _, p := range sdf.SurfacePoints3D(part, 2000, 1) {
+		hausdorff = math.Max(hausdorff, math.Abs(imported.Evaluate(p)))
+	}
+	if hausdorff > cell {
+		t.Errorf("round trip Hausdorff distance %g larger than cell size %g", hausdorff, cell)
+	}
+	// Both fields agree in sign away from the surface.
+	rng := rand.New(rand.NewSource(1))
+	bb := part.Bounds()
+	wrong := 0
+	for i := 0; i < 2000; i++ {
+		p := r3.Vec{
+			X: bb.Min.X + rng.Float64()*size.X,
+			Y: bb.Min.Y + rng.Float64()*size.Y,
+			Z: bb.Min.Z + rng.Float64()*size.Z,
+		}
+		if d := part.Evaluate(p); math.Abs(d) > cell && (d < 0) != (imported.Evaluate(p) < 0) {
+			wrong++
+		}
+	}
+	if wrong > 0 {
+		t.Errorf("%d of 2000 points have the wrong sign after the round trip", wrong)
+	}
 }
@@ -0,0 +1,70 @@
+package sdf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// NumberedParts3D returns count copies of base, each engraved with a serial number
+// label made of prefix followed by the numbers start, start+1 and so on, as is done
+// to trace parts of a production batch. label returns the SDF2 of the given text.
+// Fonts are laid out by form2 which depends on this package, so the font is not taken
+// here. form2.TextLabel builds label from a font and form3.NumberedParts takes a font
+// file name instead.
+//
+// Labels are placed on face, whose normal points out of base. Each label is scaled
+// so its height is size and its bounding box is centered on face.Point. The text is
+// read looking at the face from outside, with its X axis along the face as chosen
+// for ImageRelief3D: for faces normal to Z the text is read from above along +X,
+// otherwise its Y axis is the projection of the Y axis, or of Z for faces normal to Y,
+// onto the face. Labels are engraved depth into base.
+func NumberedParts3D(base SDF3, label func(text string) (SDF2, error), prefix string, start, count int, face Plane, size, depth float64) ([]SDF3, error) {
+	if base == nil {
+		return nil, errors.New("nil SDF3 argument")
+	}
+	if label == nil {
+		return nil, errors.New("nil label function")
+	}
+	if count < 1 {
+		return nil, errors.New("count must be 1 or larger")
+	}
+	if size <= 0 || depth <= 0 {
+		return nil, errors.New("size and depth must be positive")
+	}
+	if r3.Norm(face.Normal) == 0 {
+		return nil, errors.New("zero face normal")
+	}
+	u, v, n := face.axes()
+	p := face.Point
+	frame := m44{
+		u.X, v.X, n.X, p.X,
+		u.Y, v.Y, n.Y, p.Y,
+		u.Z, v.Z, n.Z, p.Z,
+		0, 0, 0, 1,
+	}
+	parts := make([]SDF3, count)
+	for i := range parts {
+		text := prefix + strconv.Itoa(start+i)
+		profile, err := label(text)
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %w", text, err)
+		}
+		bb := profile.Bounds()
+		height := bb.Max.Y - bb.Min.Y
+		if height <= 0 {
+			return nil, fmt.Errorf("label %q: empty bounding box", text)
+		}
+		center := r2.Scale(0.5, r2.Add(bb.Min, bb.Max))
+		profile = Transform2D(profile, Translate2D(r2.Scale(-1, center)))
+		profile = ScaleUniform2D(profile, size/height)
+		// Extrude through the face so the cut does not
+		// leave a skin where the surfaces coincide.
+		engraving := Transform3D(Extrude3D(profile, 2*depth), frame)
+		parts[i] = Difference3D(base, engraving)
+	}
+	return parts, nil
+}
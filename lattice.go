@@ -0,0 +1,69 @@
+package sdf
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// gyroid3 is a gyroid sheet with per axis periods and variable thickness.
+type gyroid3 struct {
+	period    r3.Vec
+	k         r3.Vec // angular frequencies along each axis.
+	kmin      float64
+	thickness func(r3.Vec) float64
+}
+
+// GradedGyroid3D returns an infinite gyroid sheet lattice, a triply periodic minimal
+// surface used as 3D printing infill. The cell repeats every period.X, period.Y
+// and period.Z along each axis, so cells may be stretched to stiffen a direction.
+// The wall thickness at p is thickness(p), which allows functionally graded
+// lattices which are denser near load bearing regions. Thickness should vary
+// slowly compared to the period and be smaller than it.
+//
+// The distance is estimated as the gyroid function divided by its gradient
+// which is not exact: it is accurate close to the sheet and may overestimate
+// the distance far from it, so the result should be meshed and not sphere
+// traced. The lattice is unbounded, so it should be clipped to a part
+// by passing the part as the first argument of Intersect3D, whose bounding box
+// is that of its first argument.
+func GradedGyroid3D(period r3.Vec, thickness func(r3.Vec) float64) SDF3 {
+	if period.X <= 0 || period.Y <= 0 || period.Z <= 0 {
+		panic("period must be positive")
+	}
+	if thickness == nil {
+		panic("nil thickness function")
+	}
+	k := r3.Vec{X: 2 * math.Pi / period.X, Y: 2 * math.Pi / period.Y, Z: 2 * math.Pi / period.Z}
+	return &gyroid3{
+		period:    period,
+		k:         k,
+		kmin:      math.Min(k.X, math.Min(k.Y, k.Z)),
+		thickness: thickness,
+	}
+}
+
+// Evaluate returns the approximate minimum distance to the gyroid sheet.
+func (s *gyroid3) Evaluate(p r3.Vec) float64 {
+	sx, cx := math.Sincos(s.k.X * p.X)
+	sy, cy := math.Sincos(s.k.Y * p.Y)
+	sz, cz := math.Sincos(s.k.Z * p.Z)
+	g := sx*cy + sy*cz + sz*cx
+	grad := r3.Vec{
+		X: s.k.X * (cx*cy - sz*sx),
+		Y: s.k.Y * (cy*cz - sx*sy),
+		Z: s.k.Z * (cz*cx - sy*sz),
+	}
+	// The gradient vanishes at a few points away from the sheet
+	// where the estimate would diverge.
+	gn := math.Max(r3.Norm(grad), s.kmin)
+	return math.Abs(g)/gn - s.thickness(p)/2
+}
+
+// Bounds returns an infinite bounding box.
+func (s *gyroid3) Bounds() r3.Box {
+	return r3.Box{
+		Min: r3.Vec{X: math.Inf(-1), Y: math.Inf(-1), Z: math.Inf(-1)},
+		Max: r3.Vec{X: math.Inf(1), Y: math.Inf(1), Z: math.Inf(1)},
+	}
+}
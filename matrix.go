@@ -1,6 +1,8 @@
 package sdf
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 
@@ -447,6 +449,29 @@ func (a m22) Determinant() float64 {
 	return a.x00*a.x11 - a.x01*a.x10
 }
 
+// checkInvertible returns an error if the matrix is singular, or so nearly
+// singular relative to the size of its elements that its inverse is unreliable.
+// Translations do not change the determinant so they are left out of the size.
+func (a m44) checkInvertible() error {
+	scale := 0.0
+	for i, x := range [...]float64{
+		a.x00, a.x01, a.x02, a.x10, a.x11, a.x12, a.x20, a.x21, a.x22, a.x33,
+		a.x03, a.x13, a.x23, a.x30, a.x31, a.x32,
+	} {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return errors.New("transform matrix has non-finite elements")
+		}
+		if i < 9 {
+			scale = math.Max(scale, math.Abs(x))
+		}
+	}
+	det := a.Determinant()
+	if math.Abs(det) <= 1e-12*scale*scale*scale*math.Abs(a.x33) || det == 0 {
+		return fmt.Errorf("singular transform matrix (determinant %g)", det)
+	}
+	return nil
+}
+
 // Inverse returns the inverse of a 4x4 matrix.
 func (a m44) Inverse() m44 {
 	m := m44{}
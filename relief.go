@@ -0,0 +1,140 @@
+package sdf
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Plane is an infinite plane through Point. Normal need not be of unit length.
+type Plane struct {
+	Point  r3.Vec
+	Normal r3.Vec
+}
+
+// axes returns unit vectors u and v along the plane and the unit normal n which
+// form a right handed frame. For a plane normal to Z the plane is viewed from
+// above with u along X. Otherwise v is the projection of Y onto the plane, or
+// of Z if the normal is close to Y.
+func (p Plane) axes() (u, v, n r3.Vec) {
+	n = r3.Unit(p.Normal)
+	up := r3.Vec{Y: 1}
+	if math.Abs(n.Y) > 0.9 {
+		up = r3.Vec{Z: 1}
+	}
+	u = r3.Unit(r3.Cross(up, n))
+	v = r3.Cross(n, u)
+	return u, v, n
+}
+
+// relief3 carves an image into the face of an SDF3.
+type relief3 struct {
+	sdf   SDF3
+	img   image.Image
+	plane Plane
+	depth float64
+	// Unit normal and image axes in the plane.
+	n, u, v r3.Vec
+	// Extents of the base along u and v which the image spans.
+	umin, usize float64
+	vmin, vsize float64
+}
+
+// ImageRelief3D carves img into the face of base lying on plane, whose normal
+// points out of the solid. The surface is displaced inward by depth times the
+// brightness of the image, so white pixels are carved deepest, as is needed for
+// lithophanes. The image is stretched over the extents of the base's bounding
+// box projected onto the plane and sampled with bilinear interpolation.
+//
+// The result is the intersection of base with the half-space below the displaced
+// face, which is an approximate distance field. It is intended to be meshed with
+// a resolution similar to the pixel size and not sphere traced.
+func ImageRelief3D(base SDF3, img image.Image, plane Plane, depth float64) SDF3 {
+	if base == nil {
+		panic("nil SDF3 argument")
+	}
+	if img == nil || img.Bounds().Empty() {
+		panic("empty image")
+	}
+	if depth <= 0 {
+		panic("depth <= 0")
+	}
+	if r3.Norm(plane.Normal) == 0 {
+		panic("zero plane normal")
+	}
+	u, v, n := plane.axes()
+	s := relief3{
+		sdf:   base,
+		img:   img,
+		plane: plane,
+		depth: depth,
+		n:     n,
+		u:     u,
+		v:     v,
+		umin:  math.Inf(1),
+		vmin:  math.Inf(1),
+	}
+	umax, vmax := math.Inf(-1), math.Inf(-1)
+	for _, c := range d3.Box(base.Bounds()).Vertices() {
+		cu, cv := r3.Dot(c, u), r3.Dot(c, v)
+		s.umin, umax = math.Min(s.umin, cu), math.Max(umax, cu)
+		s.vmin, vmax = math.Min(s.vmin, cv), math.Max(vmax, cv)
+	}
+	s.usize, s.vsize = umax-s.umin, vmax-s.vmin
+	if s.usize == 0 || s.vsize == 0 {
+		panic("base has no extent on plane")
+	}
+	return &s
+}
+
+// Evaluate returns the approximate minimum distance to the carved SDF3.
+func (s *relief3) Evaluate(p r3.Vec) float64 {
+	d := s.sdf.Evaluate(p)
+	h := r3.Dot(r3.Sub(p, s.plane.Point), s.n)
+	if h < -s.depth {
+		// Below the deepest carving.
+		return d
+	}
+	// Image Y axis points down.
+	x := (r3.Dot(p, s.u) - s.umin) / s.usize
+	y := 1 - (r3.Dot(p, s.v)-s.vmin)/s.vsize
+	return math.Max(d, h+s.depth*s.brightness(x, y))
+}
+
+// brightness returns the interpolated brightness of the image in [0,1]
+// at normalized image coordinates x and y, which are clamped to [0,1].
+func (s *relief3) brightness(x, y float64) float64 {
+	b := s.img.Bounds()
+	// Pixel centers lie at half integer coordinates.
+	fx := clamp(x, 0, 1)*float64(b.Dx()) - 0.5
+	fy := clamp(y, 0, 1)*float64(b.Dy()) - 0.5
+	x0, y0 := math.Floor(fx), math.Floor(fy)
+	tx, ty := fx-x0, fy-y0
+	pixel := func(i, j int) float64 {
+		i = b.Min.X + clampInt(i, 0, b.Dx()-1)
+		j = b.Min.Y + clampInt(j, 0, b.Dy()-1)
+		return float64(color.Gray16Model.Convert(s.img.At(i, j)).(color.Gray16).Y) / 0xffff
+	}
+	i, j := int(x0), int(y0)
+	top := (1-tx)*pixel(i, j) + tx*pixel(i+1, j)
+	bottom := (1-tx)*pixel(i, j+1) + tx*pixel(i+1, j+1)
+	return (1-ty)*top + ty*bottom
+}
+
+// Bounds returns the bounding box of the base SDF3.
+func (s *relief3) Bounds() r3.Box {
+	return s.sdf.Bounds()
+}
+
+func clampInt(x, a, b int) int {
+	if x < a {
+		return a
+	}
+	if x > b {
+		return b
+	}
+	return x
+}
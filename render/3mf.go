@@ -32,10 +32,16 @@ func Create3MF(filename string, r Renderer) error {
 	if err != nil {
 		return err
 	}
+	return create3MF(filename, t, unitMillimeter)
+}
+
+// create3MF saves triangles to a 3MF file with the given units.
+func create3MF(filename string, t []r3.Triangle, unit string) error {
 	mf := new3MF([]modelMetadata{
 		{Name: "author", Description: "anonymous"},
 	})
-	err = mf.AddObject("SDFMesh", t)
+	mf.Unit = unit
+	err := mf.AddObject("SDFMesh", t)
 	if err != nil {
 		fmt.Println("file generated may be corrupt or not be manifold:", err)
 	}
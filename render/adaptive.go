@@ -0,0 +1,128 @@
+package render
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// adaptiveMaxLevels is the maximum number of times an edge of the base mesh is
+// halved by MarchingCubesAdaptive. It bounds refinement of sharp features
+// whose estimated curvature grows as edges get shorter.
+const adaptiveMaxLevels = 4
+
+// MarchingCubesAdaptive renders s with marching cubes at baseRes cells along the
+// longest side of its bounding box and refines the mesh where the surface is curved.
+// The curvature along each edge of the mesh is estimated as the angle between
+// the field gradients at its ends divided by its length. Edges whose curvature
+// exceeds curvatureThreshold (in radians per unit length) are split at their
+// midpoint, which is then projected onto the surface. This concentrates triangles
+// on fillets and small features while flat regions keep the base resolution.
+//
+// Whether an edge is split is decided once for both triangles sharing it and
+// triangles are split into 2, 3 or 4 according to the number of split edges,
+// so no cracks appear between refined and unrefined regions. Edges are halved
+// at most adaptiveMaxLevels times.
+func MarchingCubesAdaptive(s sdf.SDF3, baseRes int, curvatureThreshold float64) ([]r3.Triangle, error) {
+	if curvatureThreshold <= 0 {
+		return nil, errors.New("curvature threshold must be positive")
+	}
+	tris, err := RenderAll(NewOctreeRenderer(s, baseRes))
+	if err != nil {
+		return nil, err
+	}
+	// Work on an indexed mesh so shared edges are split once.
+	faces := weldVertices(tris)
+	var verts []r3.Vec
+	for i, f := range faces {
+		for j, v := range f {
+			if v == len(verts) {
+				verts = append(verts, tris[i][j])
+			}
+		}
+	}
+	eps := 1e-6 * r3.Norm(d3.Box(s.Bounds()).Size())
+	var normals []r3.Vec
+	normal := func(v int) r3.Vec {
+		for len(normals) <= v {
+			normals = append(normals, sdf.Normal3D(s, verts[len(normals)], eps))
+		}
+		return normals[v]
+	}
+	type edge [2]int
+	for level := 0; level < adaptiveMaxLevels; level++ {
+		// midpoints holds the vertex index of the midpoint of split edges and -1 for other edges.
+		midpoints := make(map[edge]int)
+		midpoint := func(a, b int) int {
+			if a > b {
+				a, b = b, a
+			}
+			if m, ok := midpoints[edge{a, b}]; ok {
+				return m
+			}
+			m := -1
+			length := r3.Norm(r3.Sub(verts[b], verts[a]))
+			angle := math.Acos(math.Max(-1, math.Min(1, r3.Dot(normal(a), normal(b)))))
+			if length > 0 && angle/length > curvatureThreshold {
+				m = len(verts)
+				mid, _ := sdf.ProjectToSurface3D(s, r3.Scale(0.5, r3.Add(verts[a], verts[b])), eps, eps)
+				verts = append(verts, mid)
+			}
+			midpoints[edge{a, b}] = m
+			return m
+		}
+		split := false
+		refined := make([][3]int, 0, len(faces))
+		for _, f := range faces {
+			if f[0] == f[1] || f[1] == f[2] || f[2] == f[0] {
+				continue // degenerate after welding.
+			}
+			m := [3]int{midpoint(f[0], f[1]), midpoint(f[1], f[2]), midpoint(f[2], f[0])}
+			refined = appendSplitFace(refined, f, m)
+			split = split || m[0] >= 0 || m[1] >= 0 || m[2] >= 0
+		}
+		faces = refined
+		if !split {
+			break
+		}
+	}
+	result := make([]r3.Triangle, len(faces))
+	for i, f := range faces {
+		result[i] = r3.Triangle{verts[f[0]], verts[f[1]], verts[f[2]]}
+	}
+	return result, nil
+}
+
+// appendSplitFace appends to dst the triangles face f is split into, given
+// the midpoint vertices m of its edges f[0]f[1], f[1]f[2] and f[2]f[0], which
+// are negative for edges which are not split. The winding of f is preserved.
+func appendSplitFace(dst [][3]int, f, m [3]int) [][3]int {
+	switch {
+	case m[0] >= 0 && m[1] >= 0 && m[2] >= 0:
+		return append(dst,
+			[3]int{f[0], m[0], m[2]},
+			[3]int{m[0], f[1], m[1]},
+			[3]int{m[2], m[1], f[2]},
+			[3]int{m[0], m[1], m[2]},
+		)
+	case m[0] < 0 && m[1] < 0 && m[2] < 0:
+		return append(dst, f)
+	}
+	// Rotate the face so that edge f[0]f[1] is split and, if
+	// two edges are split, f[2]f[0] is the edge left whole.
+	for m[0] < 0 || (m[2] >= 0 && m[1] < 0) {
+		f = [3]int{f[1], f[2], f[0]}
+		m = [3]int{m[1], m[2], m[0]}
+	}
+	if m[1] < 0 {
+		return append(dst, [3]int{f[0], m[0], f[2]}, [3]int{m[0], f[1], f[2]})
+	}
+	return append(dst,
+		[3]int{m[0], f[1], m[1]},
+		[3]int{f[0], m[0], m[1]},
+		[3]int{f[0], m[1], f[2]},
+	)
+}
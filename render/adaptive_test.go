@@ -0,0 +1,61 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestMarchingCubesAdaptive(t *testing.T) {
+	sphere, _ := form3.Sphere(1)
+	base, err := render.RenderAll(render.NewOctreeRenderer(sphere, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No edge is curved enough to be split.
+	flat, err := render.MarchingCubesAdaptive(sphere, 8, 1e6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flat) != len(base) {
+		t.Errorf("got %d triangles without refinement, want %d", len(flat), len(base))
+	}
+	refined, err := render.MarchingCubesAdaptive(sphere, 8, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refined) <= len(base) {
+		t.Fatalf("refinement did not add triangles: %d <= %d", len(refined), len(base))
+	}
+	if issues, ok := render.CheckManifold(refined); !ok {
+		t.Errorf("refined mesh has %d non manifold edges", issues)
+	}
+	if got, want := maxCentroidError(sphere, refined), maxCentroidError(sphere, base); got >= want/2 {
+		t.Errorf("refined mesh error %g not much smaller than base error %g", got, want)
+	}
+
+	// Refined and unrefined regions of a box with a sphere on top join without cracks.
+	box, _ := form3.Box(r3.Vec{X: 4, Y: 4, Z: 1}, 0)
+	bump := sdf.Union3D(box, sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{Z: 0.5})))
+	mesh, err := render.MarchingCubesAdaptive(bump, 16, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues, ok := render.CheckManifold(mesh); !ok {
+		t.Errorf("mixed refinement has %d non manifold edges", issues)
+	}
+}
+
+// maxCentroidError returns the largest distance from s to the centroid of a triangle.
+func maxCentroidError(s sdf.SDF3, tris []r3.Triangle) float64 {
+	var maxErr float64
+	for _, tri := range tris {
+		c := r3.Scale(1./3, r3.Add(tri[0], r3.Add(tri[1], tri[2])))
+		maxErr = math.Max(maxErr, math.Abs(s.Evaluate(c)))
+	}
+	return maxErr
+}
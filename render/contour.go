@@ -0,0 +1,107 @@
+package render
+
+import (
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/internal/d2"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+// gridEdge identifies an edge of the marching squares grid. Edge (i,j)
+// starts at grid node (i,j) and runs along +X, or +Y if vertical.
+type gridEdge struct {
+	i, j     int
+	vertical bool
+}
+
+// ContourLoops2D traces the zero level of s with marching squares and returns
+// its closed loops as polygons. The bounding box of s is sampled with resolution
+// cells along its longest side. Loops are oriented with the inside of s on their
+// left so outer boundaries run counter-clockwise and holes clockwise. The last
+// point of a loop is not repeated.
+//
+// Cells whose corners alternate between inside and outside are ambiguous.
+// These are resolved by evaluating s at the cell center: if the center is
+// inside the inside corners are joined, otherwise they are kept apart.
+func ContourLoops2D(s sdf.SDF2, resolution int) [][]r2.Vec {
+	if resolution < 1 {
+		panic("resolution must be 1 or larger")
+	}
+	// Enlarge the bounding box so the grid boundary lies outside s
+	// and every loop is closed.
+	bb := d2.Box(s.Bounds()).ScaleAboutCenter(1.01)
+	size := bb.Size()
+	cell := d2.Max(size) / float64(resolution)
+	nx, ny := int(size.X/cell)+2, int(size.Y/cell)+2
+	node := func(i, j int) r2.Vec {
+		return r2.Add(bb.Min, r2.Vec{X: float64(i) * cell, Y: float64(j) * cell})
+	}
+	values := make([]float64, (nx+1)*(ny+1))
+	for j := 0; j <= ny; j++ {
+		for i := 0; i <= nx; i++ {
+			values[i+j*(nx+1)] = s.Evaluate(node(i, j))
+		}
+	}
+	value := func(i, j int) float64 { return values[i+j*(nx+1)] }
+
+	points := make(map[gridEdge]r2.Vec)
+	next := make(map[gridEdge]gridEdge)
+	var starts []gridEdge // segment starts in grid order for deterministic output.
+	for j := 0; j < ny; j++ {
+		for i := 0; i < nx; i++ {
+			// Corners and edges of the cell in counter-clockwise order,
+			// edge k joins corner k to corner k+1.
+			corners := [4][2]int{{i, j}, {i + 1, j}, {i + 1, j + 1}, {i, j + 1}}
+			edges := [4]gridEdge{{i, j, false}, {i + 1, j, true}, {i, j + 1, false}, {i, j, true}}
+			var crossings [4]gridEdge
+			var exits [4]bool // crossing goes from inside to outside.
+			n := 0
+			for k := range corners {
+				a, b := corners[k], corners[(k+1)%4]
+				va, vb := value(a[0], a[1]), value(b[0], b[1])
+				if (va < 0) == (vb < 0) {
+					continue
+				}
+				e := edges[k]
+				if _, ok := points[e]; !ok {
+					t := va / (va - vb)
+					pa, pb := node(a[0], a[1]), node(b[0], b[1])
+					points[e] = r2.Add(pa, r2.Scale(t, r2.Sub(pb, pa)))
+				}
+				crossings[n] = e
+				exits[n] = va < 0
+				n++
+			}
+			if n == 0 {
+				continue
+			}
+			// Each exit is joined to an entry so that the inside lies on
+			// the left. With 4 crossings the entry is the next crossing if
+			// the center is inside, joining inside corners, else the previous.
+			step := 1
+			if n == 4 && s.Evaluate(r2.Add(node(i, j), r2.Vec{X: cell / 2, Y: cell / 2})) >= 0 {
+				step = n - 1
+			}
+			for k := 0; k < n; k++ {
+				if exits[k] {
+					next[crossings[k]] = crossings[(k+step)%n]
+					starts = append(starts, crossings[k])
+				}
+			}
+		}
+	}
+
+	var loops [][]r2.Vec
+	visited := make(map[gridEdge]bool)
+	for _, start := range starts {
+		if visited[start] {
+			continue
+		}
+		var loop []r2.Vec
+		for e := start; !visited[e]; e = next[e] {
+			visited[e] = true
+			loop = append(loop, points[e])
+		}
+		loops = append(loops, loop)
+	}
+	return loops
+}
@@ -0,0 +1,67 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r2"
+)
+
+func TestContourLoops2D(t *testing.T) {
+	circle := must2.Circle(1)
+	loops := render.ContourLoops2D(circle, 50)
+	if len(loops) != 1 {
+		t.Fatalf("circle: got %d loops, want 1", len(loops))
+	}
+	for _, p := range loops[0] {
+		if d := math.Abs(r2.Norm(p) - 1); d > 1e-3 {
+			t.Fatalf("point %v is %g from circle", p, d)
+		}
+	}
+	if a := signedArea(loops[0]); math.Abs(a-math.Pi) > 0.01 {
+		t.Errorf("circle loop area %g, want %g", a, math.Pi)
+	}
+
+	ring := sdf.Difference2D(circle, must2.Circle(0.5))
+	loops = render.ContourLoops2D(ring, 50)
+	if len(loops) != 2 {
+		t.Fatalf("ring: got %d loops, want 2", len(loops))
+	}
+	a0, a1 := signedArea(loops[0]), signedArea(loops[1])
+	if a0 < a1 {
+		a0, a1 = a1, a0
+	}
+	// Outer loop counter-clockwise, hole clockwise.
+	if math.Abs(a0-math.Pi) > 0.01 || math.Abs(a1+math.Pi/4) > 0.01 {
+		t.Errorf("ring loop areas %g and %g, want %g and %g", a0, a1, math.Pi, -math.Pi/4)
+	}
+
+	// Two squares touching at a corner produce saddle cells at every resolution.
+	square := must2.Box(r2.Vec{X: 1, Y: 1}, 0)
+	touching := sdf.Union2D(
+		sdf.Transform2D(square, sdf.Translate2D(r2.Vec{X: -0.5, Y: -0.5})),
+		sdf.Transform2D(square, sdf.Translate2D(r2.Vec{X: 0.5, Y: 0.5})),
+	)
+	for _, res := range []int{20, 21, 40, 41} {
+		var area float64
+		for _, loop := range render.ContourLoops2D(touching, res) {
+			area += signedArea(loop)
+		}
+		if math.Abs(area-2) > 0.05 {
+			t.Errorf("resolution %d: touching squares area %g, want 2", res, area)
+		}
+	}
+}
+
+// signedArea returns the area of a polygon, positive if counter-clockwise.
+func signedArea(poly []r2.Vec) float64 {
+	var a float64
+	for i, p := range poly {
+		q := poly[(i+1)%len(poly)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a / 2
+}
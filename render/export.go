@@ -0,0 +1,167 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Axis is a coordinate axis.
+type Axis int
+
+const (
+	// AxisZ is the up axis of models built with this library and of most CAD and slicer software.
+	AxisZ Axis = iota
+	// AxisY is the up axis of many game engines and 3D animation software.
+	AxisY
+)
+
+// Unit is a unit of length.
+type Unit int
+
+// Units supported by ExportOptions.
+const (
+	Millimeter Unit = iota
+	Micron
+	Centimeter
+	Meter
+	Inch
+	Foot
+)
+
+// millimeters returns the length of the unit in millimeters and
+// its name as used by the 3MF format.
+func (u Unit) millimeters() (float64, string, error) {
+	switch u {
+	case Millimeter:
+		return 1, unitMillimeter, nil
+	case Micron:
+		return 1e-3, "micron", nil
+	case Centimeter:
+		return 10, "centimeter", nil
+	case Meter:
+		return 1000, "meter", nil
+	case Inch:
+		return 25.4, "inch", nil
+	case Foot:
+		return 304.8, "foot", nil
+	}
+	return 0, "", fmt.Errorf("unknown unit %d", u)
+}
+
+// ExportOptions converts the coordinates of models, which are Z-up and in
+// millimeters, to those expected by other software before writing them.
+// The zero value writes models unchanged.
+type ExportOptions struct {
+	// UpAxis is the axis pointing up in the written file. Y-up files are
+	// rotated about X so that Z maps to Y and Y to -Z, which keeps the model
+	// right handed and its triangles facing out.
+	UpAxis Axis
+	// Scale multiplies vertex coordinates. Zero is taken as one.
+	Scale float64
+	// Units are the units of the written file. Vertex coordinates are converted
+	// from millimeters. STL files have no units so the importing software must
+	// be set to the same units.
+	Units Unit
+	// ReverseWinding swaps the vertex order of triangles, turning their normals
+	// inward, for software which expects clockwise winding. Renderers wind
+	// triangles counterclockwise seen from outside, so that normals point
+	// out of the model along the SDF gradient.
+	ReverseWinding bool
+}
+
+// transform returns the function which converts vertices as
+// set by opts and the name of the units for 3MF files.
+func (opts ExportOptions) transform() (func(r3.Vec) r3.Vec, string, error) {
+	mm, unit, err := opts.Units.millimeters()
+	if err != nil {
+		return nil, "", err
+	}
+	scale := opts.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 0 {
+		// Negative scales mirror the model, turning its triangles inside out.
+		return nil, "", errors.New("negative scale")
+	}
+	scale /= mm
+	switch opts.UpAxis {
+	case AxisZ:
+		return func(v r3.Vec) r3.Vec { return r3.Scale(scale, v) }, unit, nil
+	case AxisY:
+		return func(v r3.Vec) r3.Vec { return r3.Vec{X: scale * v.X, Y: scale * v.Z, Z: -scale * v.Y} }, unit, nil
+	}
+	return nil, "", fmt.Errorf("unknown up axis %d", opts.UpAxis)
+}
+
+// exportTriangles returns a copy of model converted as set by opts.
+func exportTriangles(model []r3.Triangle, opts ExportOptions) ([]r3.Triangle, string, error) {
+	tform, unit, err := opts.transform()
+	if err != nil {
+		return nil, "", err
+	}
+	dst := make([]r3.Triangle, len(model))
+	for i, t := range model {
+		dst[i] = r3.Triangle{tform(t[0]), tform(t[1]), tform(t[2])}
+		if opts.ReverseWinding {
+			dst[i][1], dst[i][2] = dst[i][2], dst[i][1]
+		}
+	}
+	return dst, unit, nil
+}
+
+// exportRenderer converts the triangles read from a Renderer.
+type exportRenderer struct {
+	r       Renderer
+	tform   func(r3.Vec) r3.Vec
+	reverse bool
+}
+
+func (e *exportRenderer) ReadTriangles(dst []r3.Triangle) (int, error) {
+	n, err := e.r.ReadTriangles(dst)
+	for i := range dst[:n] {
+		t := &dst[i]
+		t[0], t[1], t[2] = e.tform(t[0]), e.tform(t[1]), e.tform(t[2])
+		if e.reverse {
+			t[1], t[2] = t[2], t[1]
+		}
+	}
+	return n, err
+}
+
+// CreateSTLWithOptions renders an SDF3 as an STL file using a Renderer
+// converting its coordinates as set by opts.
+func CreateSTLWithOptions(path string, r Renderer, opts ExportOptions) error {
+	tform, _, err := opts.transform()
+	if err != nil {
+		return err
+	}
+	return createSTL(path, &exportRenderer{r: r, tform: tform, reverse: opts.ReverseWinding})
+}
+
+// WriteSTLWithOptions writes model triangles to a writer in STL file format
+// converting their coordinates as set by opts. model is not modified.
+func WriteSTLWithOptions(w io.Writer, model []r3.Triangle, opts ExportOptions) error {
+	model, _, err := exportTriangles(model, opts)
+	if err != nil {
+		return err
+	}
+	return WriteSTL(w, model)
+}
+
+// Create3MFWithOptions saves Renderer stream to a 3MF file type converting
+// its coordinates and setting the units of the file as set by opts.
+func Create3MFWithOptions(filename string, r Renderer, opts ExportOptions) error {
+	t, err := RenderAll(r)
+	if err != nil {
+		return err
+	}
+	t, unit, err := exportTriangles(t, opts)
+	if err != nil {
+		return err
+	}
+	return create3MF(filename, t, unit)
+}
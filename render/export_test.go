@@ -0,0 +1,164 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestExportOptions(t *testing.T) {
+	const res = 10
+	box := sdf.Transform3D(must3.Box(r3.Vec{X: 3, Y: 2, Z: 1}, 0), sdf.Translate3D(r3.Vec{X: 1, Y: 2, Z: 3}))
+	renderer := func() Renderer { return NewOctreeRenderer(box, res) }
+	model, err := RenderAll(renderer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	opts := ExportOptions{UpAxis: AxisY, Scale: 2, Units: Centimeter}
+	// Vertices of each writer's output.
+	outputs := map[string][]r3.Vec{}
+
+	var b bytes.Buffer
+	if err := WriteSTLWithOptions(&b, model, opts); err != nil {
+		t.Fatal(err)
+	}
+	tris, err := readBinarySTL(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs["WriteSTL"] = triangleVertices(tris)
+
+	stlPath := filepath.Join(dir, "box.stl")
+	if err := CreateSTLWithOptions(stlPath, renderer(), opts); err != nil {
+		t.Fatal(err)
+	}
+	fp, err := os.Open(stlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	tris, err = readBinarySTL(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs["CreateSTL"] = triangleVertices(tris)
+
+	mfPath := filepath.Join(dir, "box.3mf")
+	if err := Create3MFWithOptions(mfPath, renderer(), opts); err != nil {
+		t.Fatal(err)
+	}
+	mf := read3MF(t, mfPath)
+	if mf.Unit != "centimeter" {
+		t.Errorf("got 3MF unit %q, want centimeter", mf.Unit)
+	}
+	var vertices []r3.Vec
+	for _, v := range mf.Resources.Objects[0].Mesh.Vertices {
+		vertices = append(vertices, r3.Vec(v))
+	}
+	outputs["3MF"] = vertices
+
+	// The box spans Y from 1 to 3 and Z from 2.5 to 3.5 in millimeters,
+	// doubled by Scale and converted to centimeters.
+	want := r3.Box{Min: r3.Vec{X: -0.1, Y: 0.5, Z: -0.6}, Max: r3.Vec{X: 0.5, Y: 0.7, Z: -0.2}}
+	for name, vertices := range outputs {
+		got := r3.Box{Min: vertices[0], Max: vertices[0]}
+		for _, v := range vertices {
+			got.Min = r3.Vec{X: math.Min(got.Min.X, v.X), Y: math.Min(got.Min.Y, v.Y), Z: math.Min(got.Min.Z, v.Z)}
+			got.Max = r3.Vec{X: math.Max(got.Max.X, v.X), Y: math.Max(got.Max.Y, v.Y), Z: math.Max(got.Max.Z, v.Z)}
+		}
+		if r3.Norm(r3.Sub(got.Min, want.Min)) > 1e-6 || r3.Norm(r3.Sub(got.Max, want.Max)) > 1e-6 {
+			t.Errorf("%s: got vertices within %v, want %v", name, got, want)
+		}
+	}
+	// Triangles keep facing out of the box after the rotation.
+	for _, tri := range tris {
+		center := r3.Scale(1./3, r3.Add(tri[0], r3.Add(tri[1], tri[2])))
+		if r3.Dot(tri.Normal(), r3.Sub(center, r3.Vec{X: 0.2, Y: 0.6, Z: -0.4})) <= 0 {
+			t.Fatalf("triangle %v faces into the box", tri)
+		}
+	}
+	if err := WriteSTLWithOptions(&b, model, ExportOptions{Units: Unit(-1)}); err == nil {
+		t.Error("expected error for unknown unit")
+	}
+}
+
+func triangleVertices(tris []r3.Triangle) []r3.Vec {
+	var vertices []r3.Vec
+	for _, tri := range tris {
+		vertices = append(vertices, tri[:]...)
+	}
+	return vertices
+}
+
+func read3MF(t *testing.T, path string) model {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	f, err := zr.Open(modelFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var m model
+	if err := xml.NewDecoder(f).Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestSTLWinding(t *testing.T) {
+	sphere := must3.Sphere(5)
+	model, err := RenderAll(NewOctreeRenderer(sphere, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, reverse := range []bool{false, true} {
+		opts := ExportOptions{ReverseWinding: reverse}
+		var b bytes.Buffer
+		if err := WriteSTLWithOptions(&b, model, opts); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "sphere.stl")
+		if err := CreateSTLWithOptions(path, NewOctreeRenderer(sphere, 20), opts); err != nil {
+			t.Fatal(err)
+		}
+		created, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, data := range map[string][]byte{"WriteSTL": b.Bytes(), "CreateSTL": created} {
+			// Facets are 50 bytes after the 84 byte header, starting
+			// with the normal followed by the three vertices.
+			var facet [12]float32
+			n := (len(data) - 84) / 50
+			for i := 0; i < n; i++ {
+				err := binary.Read(bytes.NewReader(data[84+50*i:]), binary.LittleEndian, &facet)
+				if err != nil {
+					t.Fatal(err)
+				}
+				normal := r3.Vec{X: float64(facet[0]), Y: float64(facet[1]), Z: float64(facet[2])}
+				var center r3.Vec
+				for j := 3; j < 12; j += 3 {
+					center = r3.Add(center, r3.Vec{X: float64(facet[j]), Y: float64(facet[j+1]), Z: float64(facet[j+2])})
+				}
+				if outward := r3.Dot(normal, center) > 0; outward == reverse {
+					t.Fatalf("%s with ReverseWinding %v: facet %d normal %v at %v", name, reverse, i, normal, center)
+				}
+			}
+		}
+	}
+}
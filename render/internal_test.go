@@ -2,11 +2,15 @@ package render
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
+	"math"
 	"runtime"
+	"sync/atomic"
 	"testing"
 
+	"github.com/soypat/sdf"
 	"github.com/soypat/sdf/form3/must3"
 	"github.com/soypat/sdf/form3/obj3/thread"
 	"github.com/soypat/sdf/internal/d3"
@@ -117,3 +121,125 @@ func BenchmarkBoltThreaded(b *testing.B) {
 		CreateSTL(output, oct)
 	}
 }
+
+func TestAccurateBooleans(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	hole := sdf.Transform3D(must3.Cylinder(4, 0.63, 0), sdf.Translate3D(r3.Vec{X: 0.37, Y: 0.21}))
+	part := sdf.Difference3D(box, hole)
+	const res = 20
+	cell := 2 / float64(res)
+	// seamError returns the mean absolute field at vertices near
+	// the seam where the hole meets the top and bottom faces.
+	seamError := func(accurate bool) float64 {
+		oct := NewOctreeRenderer(part, res)
+		oct.AccurateBooleans = accurate
+		tris, err := RenderAll(oct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sum float64
+		n := 0
+		for _, tri := range tris {
+			for _, v := range tri {
+				if math.Abs(box.Evaluate(v)) < cell && math.Abs(hole.Evaluate(v)) < cell {
+					sum += math.Abs(part.Evaluate(v))
+					n++
+				}
+			}
+		}
+		if n == 0 {
+			t.Fatal("no vertices near seam")
+		}
+		return sum / float64(n)
+	}
+	linear, accurate := seamError(false), seamError(true)
+	if accurate > linear/10 {
+		t.Errorf("got mean seam error %g with accurate booleans, want well below %g", accurate, linear)
+	}
+}
+
+func TestAccurateBooleansCost(t *testing.T) {
+	const res = 50
+	render := func(s sdf.SDF3, accurate bool) {
+		oct := NewOctreeRenderer(s, res)
+		oct.AccurateBooleans = accurate
+		if _, err := RenderAll(oct); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Curved surfaces without seams are not refined.
+	var plain, accurate countingSDF3
+	plain.SDF3, accurate.SDF3 = must3.Sphere(1), must3.Sphere(1)
+	render(&plain, false)
+	render(&accurate, true)
+	if accurate.n != plain.n {
+		t.Errorf("sphere took %d evaluations with accurate booleans, want %d", accurate.n, plain.n)
+	}
+	// Curved surfaces of booleans away from seams are not refined either.
+	// Evaluations are counted on the operands, which are evaluated once
+	// at every cell on the surface to find seams.
+	evaluations := func(accurate bool) int64 {
+		left := &countingSDF3{SDF3: sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: -1.5}))}
+		right := &countingSDF3{SDF3: sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 1.5}))}
+		render(sdf.Union3D(left, right), accurate)
+		return left.n + right.n
+	}
+	if plain, accurate := evaluations(false), evaluations(true); float64(accurate) > 1.25*float64(plain) {
+		t.Errorf("union took %d evaluations with accurate booleans, want near %d", accurate, plain)
+	}
+}
+
+// countingSDF3 counts the evaluations of an SDF3.
+type countingSDF3 struct {
+	sdf.SDF3
+	n int64
+}
+
+func (c *countingSDF3) Evaluate(p r3.Vec) float64 {
+	atomic.AddInt64(&c.n, 1)
+	return c.SDF3.Evaluate(p)
+}
+
+func BenchmarkMeshProgressive(b *testing.B) {
+	box := must3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0.2)
+	resolutions := []int{16, 32, 64}
+	b.Run("reuse", func(b *testing.B) {
+		s := &countingSDF3{SDF3: box}
+		for i := 0; i < b.N; i++ {
+			MeshProgressive(context.Background(), s, resolutions, func(int, []r3.Triangle) {})
+		}
+		b.ReportMetric(float64(s.n)/float64(b.N), "evals/op")
+	})
+	b.Run("scratch", func(b *testing.B) {
+		s := &countingSDF3{SDF3: box}
+		for i := 0; i < b.N; i++ {
+			for _, res := range resolutions {
+				RenderAll(NewOctreeRenderer(s, res))
+			}
+		}
+		b.ReportMetric(float64(s.n)/float64(b.N), "evals/op")
+	})
+}
+
+func TestOctreeDeterministic(t *testing.T) {
+	model := sdf.Difference3D(must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0.2), must3.Sphere(1.2))
+	render := func(concurrent int) []byte {
+		oct := NewOctreeRenderer(model, 40)
+		oct.concurrent = concurrent
+		tris, err := RenderAll(oct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err := WriteSTL(&b, tris); err != nil {
+			t.Fatal(err)
+		}
+		return b.Bytes()
+	}
+	want := render(0)
+	for _, concurrent := range []int{1, 2, 3, runtime.NumCPU(), 2, 3} {
+		if got := render(concurrent); !bytes.Equal(got, want) {
+			t.Errorf("output with %d goroutines differs from single threaded output", concurrent)
+		}
+	}
+}
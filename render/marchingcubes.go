@@ -12,7 +12,11 @@ const (
 	marchingCubesMaxTriangles = 5
 )
 
-func mcToTriangles(dst []r3.Triangle, p [8]r3.Vec, v [8]float64, x float64) (n int) {
+// mcToTriangles writes the triangles of a marching cube with corners p and
+// values v at the isosurface x to dst. Triangles which are degenerate or have
+// an area smaller than minArea are discarded. If refine is not nil it places
+// the vertices on the edges instead of linear interpolation, which requires x=0.
+func mcToTriangles(dst []r3.Triangle, p [8]r3.Vec, v [8]float64, x, minArea float64, refine func(p1, p2 r3.Vec, v1, v2 float64) r3.Vec) (n int) {
 	if len(dst) < marchingCubesMaxTriangles {
 		panic("destination triangle buffer must be greater than 5")
 	}
@@ -34,7 +38,11 @@ func mcToTriangles(dst []r3.Triangle, p [8]r3.Vec, v [8]float64, x float64) (n i
 		if mcEdgeTable[index]&bit != 0 {
 			a := mcPairTable[i][0]
 			b := mcPairTable[i][1]
-			points[i] = mcInterpolate(p[a], p[b], v[a], v[b], x)
+			if refine != nil {
+				points[i] = refine(p[a], p[b], v[a], v[b])
+			} else {
+				points[i] = mcInterpolate(p[a], p[b], v[a], v[b], x)
+			}
 		}
 	}
 	// create the triangles
@@ -46,7 +54,7 @@ func mcToTriangles(dst []r3.Triangle, p [8]r3.Vec, v [8]float64, x float64) (n i
 			points[table[i*3+1]],
 			points[table[i*3+0]],
 		}
-		if !t.IsDegenerate(1e-12) {
+		if !t.IsDegenerate(1e-12) && !degenerateTriangle(t, minArea) {
 			dst[n] = t
 			n++
 		}
@@ -0,0 +1,337 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// ClipMeshToBox clips the triangles of a mesh to box. Triangles entirely
+// inside the box are kept as is, triangles outside are discarded and
+// triangles crossing the box faces are clipped against the six planes of the
+// box using Sutherland-Hodgman polygon clipping and re-triangulated as a fan.
+// This allows meshing a large model in tiles which are assembled later.
+func ClipMeshToBox(tris []r3.Triangle, box r3.Box) []r3.Triangle {
+	var result []r3.Triangle
+	// Two buffers are swapped back and forth between clipping planes.
+	// A triangle clipped by 6 planes has at most 9 vertices.
+	poly := make([]r3.Vec, 0, 9)
+	next := make([]r3.Vec, 0, 9)
+	for _, t := range tris {
+		if triangleInBox(t, box) {
+			result = append(result, t)
+			continue
+		}
+		poly = append(poly[:0], t[0], t[1], t[2])
+		for axis := 0; axis < 3 && len(poly) > 0; axis++ {
+			poly, next = clipPolygon(next[:0], poly, axis, component(box.Min, axis), 1), poly
+			poly, next = clipPolygon(next[:0], poly, axis, component(box.Max, axis), -1), poly
+		}
+		for i := 2; i < len(poly); i++ {
+			result = append(result, r3.Triangle{poly[0], poly[i-1], poly[i]})
+		}
+	}
+	return result
+}
+
+// clipPolygon appends to dst the vertices of poly which lie on the side of the
+// axis aligned plane at position pos given by sign (1 keeps coordinates >= pos, -1 keeps <= pos).
+// Edges crossing the plane are split at the intersection. Vertices on the
+// plane are kept and do not split their edges.
+func clipPolygon(dst, poly []r3.Vec, axis int, pos, sign float64) []r3.Vec {
+	for i, cur := range poly {
+		prev := poly[(i+len(poly)-1)%len(poly)]
+		dc := sign * (component(cur, axis) - pos)
+		dp := sign * (component(prev, axis) - pos)
+		if (dc > 0 && dp < 0) || (dc < 0 && dp > 0) {
+			// Edge crosses the plane.
+			t := dp / (dp - dc)
+			dst = append(dst, r3.Add(prev, r3.Scale(t, r3.Sub(cur, prev))))
+		}
+		if dc >= 0 {
+			dst = append(dst, cur)
+		}
+	}
+	return dst
+}
+
+func triangleInBox(t r3.Triangle, box r3.Box) bool {
+	for _, v := range t {
+		if v.X < box.Min.X || v.Y < box.Min.Y || v.Z < box.Min.Z ||
+			v.X > box.Max.X || v.Y > box.Max.Y || v.Z > box.Max.Z {
+			return false
+		}
+	}
+	return true
+}
+
+// component returns the X, Y or Z component of v for axis 0, 1 or 2 respectively.
+func component(v r3.Vec, axis int) float64 {
+	switch axis {
+	case 0:
+		return v.X
+	case 1:
+		return v.Y
+	}
+	return v.Z
+}
+
+// CheckManifold welds the vertices of a triangle mesh and checks that every
+// edge is shared by exactly two triangles which traverse it in opposite
+// directions, as required by slicers for closed, consistently oriented meshes.
+// It returns the number of problematic edges, which are boundary edges (cracks),
+// edges shared by more than two triangles or inconsistently oriented and edges
+// of degenerate triangles with two welded vertices.
+func CheckManifold(tris []r3.Triangle) (edgeIssues int, ok bool) {
+	if len(tris) == 0 {
+		return 0, true
+	}
+	// edges counts the directed uses of each undirected edge. The edge
+	// from a to b with a<b counts as forward, b to a as backward.
+	type uses struct{ forward, backward int }
+	edges := make(map[[2]int]*uses)
+	for _, v := range weldVertices(tris) {
+		if v[0] == v[1] || v[1] == v[2] || v[2] == v[0] {
+			edgeIssues += 3
+			continue
+		}
+		for i := range v {
+			a, b := v[i], v[(i+1)%3]
+			forward := a < b
+			if !forward {
+				a, b = b, a
+			}
+			e := edges[[2]int{a, b}]
+			if e == nil {
+				e = &uses{}
+				edges[[2]int{a, b}] = e
+			}
+			if forward {
+				e.forward++
+			} else {
+				e.backward++
+			}
+		}
+	}
+	for _, e := range edges {
+		if e.forward != 1 || e.backward != 1 {
+			edgeIssues++
+		}
+	}
+	return edgeIssues, edgeIssues == 0
+}
+
+// weldVertices returns the vertex indices of each triangle where
+// vertices closer than a small fraction of the mesh size share an index.
+func weldVertices(tris []r3.Triangle) [][3]int {
+	if len(tris) == 0 {
+		return nil
+	}
+	bb := r3.Box{Min: tris[0][0], Max: tris[0][0]}
+	for _, t := range tris {
+		for _, v := range t {
+			bb.Min = r3.Vec{X: math.Min(bb.Min.X, v.X), Y: math.Min(bb.Min.Y, v.Y), Z: math.Min(bb.Min.Z, v.Z)}
+			bb.Max = r3.Vec{X: math.Max(bb.Max.X, v.X), Y: math.Max(bb.Max.Y, v.Y), Z: math.Max(bb.Max.Z, v.Z)}
+		}
+	}
+	tol := 1e-9 * r3.Norm(r3.Sub(bb.Max, bb.Min))
+	if tol == 0 {
+		tol = 1e-9
+	}
+	ids := make(map[[3]int64]int)
+	weld := func(v r3.Vec) int {
+		key := [3]int64{int64(math.Round(v.X / tol)), int64(math.Round(v.Y / tol)), int64(math.Round(v.Z / tol))}
+		id, ok := ids[key]
+		if !ok {
+			id = len(ids)
+			ids[key] = id
+		}
+		return id
+	}
+	indices := make([][3]int, len(tris))
+	for i, t := range tris {
+		indices[i] = [3]int{weld(t[0]), weld(t[1]), weld(t[2])}
+	}
+	return indices
+}
+
+// RepairWinding makes the winding of the triangles of a closed mesh
+// consistent so that all normals point outward, as is expected of meshes
+// used for inside/outside tests. Imported meshes often have some
+// triangles with reversed vertex order which turns parts of them inside out.
+//
+// The winding of each connected part of the mesh is made consistent by
+// flood filling across shared edges. Each part is then oriented to enclose
+// a positive volume, unless a ray cast from it crosses other parts an odd
+// number of times, in which case it bounds a cavity and faces inward.
+// An error is returned if a part is not orientable, i.e. an edge is shared
+// by more than two triangles or winding conflicts around a loop.
+// The triangles are modified in place.
+func RepairWinding(tris []r3.Triangle) ([]r3.Triangle, error) {
+	indices := weldVertices(tris)
+	type edge [2]int
+	key := func(a, b int) edge {
+		if a > b {
+			a, b = b, a
+		}
+		return edge{a, b}
+	}
+	adjacent := make(map[edge][]int)
+	for i, v := range indices {
+		for j := range v {
+			e := key(v[j], v[(j+1)%3])
+			adjacent[e] = append(adjacent[e], i)
+		}
+	}
+	// hasEdge reports whether triangle v traverses the edge from a to b.
+	hasEdge := func(v [3]int, a, b int) bool {
+		return v[0] == a && v[1] == b || v[1] == a && v[2] == b || v[2] == a && v[0] == b
+	}
+	flip := func(i int) {
+		tris[i][1], tris[i][2] = tris[i][2], tris[i][1]
+		indices[i][1], indices[i][2] = indices[i][2], indices[i][1]
+	}
+	partOf := make([]int, len(tris))
+	for i := range partOf {
+		partOf[i] = -1
+	}
+	var parts [][]int
+	for seed := range tris {
+		if partOf[seed] >= 0 {
+			continue
+		}
+		id := len(parts)
+		part := []int{seed}
+		partOf[seed] = id
+		for k := 0; k < len(part); k++ {
+			v := indices[part[k]]
+			for j := range v {
+				a, b := v[j], v[(j+1)%3]
+				shared := adjacent[key(a, b)]
+				if len(shared) > 2 {
+					return nil, fmt.Errorf("edge shared by %d triangles", len(shared))
+				}
+				for _, n := range shared {
+					if n == part[k] {
+						continue
+					}
+					// A consistently wound neighbor traverses the edge from b to a.
+					consistent := !hasEdge(indices[n], a, b)
+					if partOf[n] >= 0 {
+						if !consistent {
+							return nil, errors.New("mesh is not orientable")
+						}
+						continue
+					}
+					if !consistent {
+						flip(n)
+					}
+					partOf[n] = id
+					part = append(part, n)
+				}
+			}
+		}
+		parts = append(parts, part)
+	}
+	for id, part := range parts {
+		var volume float64
+		for _, i := range part {
+			t := tris[i]
+			volume += r3.Dot(t[0], r3.Cross(t[1], t[2]))
+		}
+		// Count the other parts enclosing a point of this part.
+		origin := r3.Scale(1./3, r3.Add(tris[part[0]][0], r3.Add(tris[part[0]][1], tris[part[0]][2])))
+		crossings := 0
+		for i := range tris {
+			if partOf[i] != id && rayIntersectsTriangle(origin, tris[i]) {
+				crossings++
+			}
+		}
+		if (volume < 0) != (crossings%2 == 1) {
+			for _, i := range part {
+				flip(i)
+			}
+		}
+	}
+	return tris, nil
+}
+
+// rayDirection is a direction unlikely to be aligned with mesh edges.
+var rayDirection = r3.Unit(r3.Vec{X: 1, Y: 0.3183098861837907, Z: 0.1591549430919538})
+
+// rayIntersectsTriangle reports whether the ray from origin in rayDirection
+// intersects t using the Möller-Trumbore algorithm.
+func rayIntersectsTriangle(origin r3.Vec, t r3.Triangle) bool {
+	e1 := r3.Sub(t[1], t[0])
+	e2 := r3.Sub(t[2], t[0])
+	h := r3.Cross(rayDirection, e2)
+	det := r3.Dot(e1, h)
+	if det == 0 {
+		return false
+	}
+	s := r3.Sub(origin, t[0])
+	u := r3.Dot(s, h) / det
+	if u < 0 || u > 1 {
+		return false
+	}
+	q := r3.Cross(s, e1)
+	v := r3.Dot(rayDirection, q) / det
+	if v < 0 || u+v > 1 {
+		return false
+	}
+	return r3.Dot(e2, q)/det > 0
+}
+
+// SortTriangles sorts triangles in place by their vertex coordinates so that
+// meshes whose triangle order differs, such as those of different renderers,
+// can be compared and written deterministically. Vertex order within each
+// triangle is preserved so orientation is not modified.
+func SortTriangles(tris []r3.Triangle) {
+	cmp := func(a, b r3.Vec) int {
+		switch {
+		case a.X != b.X:
+			return cmpFloat(a.X, b.X)
+		case a.Y != b.Y:
+			return cmpFloat(a.Y, b.Y)
+		}
+		return cmpFloat(a.Z, b.Z)
+	}
+	sort.Slice(tris, func(i, j int) bool {
+		for k := 0; k < 3; k++ {
+			if c := cmp(tris[i][k], tris[j][k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+func cmpFloat(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// RemoveDegenerateTriangles removes triangles with coincident vertices or with
+// an area smaller than minArea from tris. Slivers and zero area triangles
+// break many mesh tools. The backing array of tris is reused for the result.
+func RemoveDegenerateTriangles(tris []r3.Triangle, minArea float64) []r3.Triangle {
+	result := tris[:0]
+	for _, t := range tris {
+		if !degenerateTriangle(t, minArea) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// degenerateTriangle reports whether t has coincident vertices or an area below minArea.
+func degenerateTriangle(t r3.Triangle, minArea float64) bool {
+	return t[0] == t[1] || t[1] == t[2] || t[2] == t[0] || t.Area() < minArea
+}
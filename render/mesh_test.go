@@ -0,0 +1,172 @@
+package render_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf/form3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestClipMeshToBox(t *testing.T) {
+	box := r3.Box{Min: r3.Vec{}, Max: r3.Vec{X: 1, Y: 1, Z: 1}}
+	inside := r3.Triangle{{X: 0.1, Y: 0.1, Z: 0.5}, {X: 0.9, Y: 0.1, Z: 0.5}, {X: 0.1, Y: 0.9, Z: 0.5}}
+	outside := r3.Triangle{{X: 2, Y: 2, Z: 2}, {X: 3, Y: 2, Z: 2}, {X: 2, Y: 3, Z: 2}}
+	// Straddles the X=1 face of the box with its right half outside.
+	straddle := r3.Triangle{{X: 0.5, Y: 0.2, Z: 0.5}, {X: 1.5, Y: 0.2, Z: 0.5}, {X: 0.5, Y: 0.8, Z: 0.5}}
+
+	got := render.ClipMeshToBox([]r3.Triangle{inside, outside}, box)
+	if len(got) != 1 || got[0] != inside {
+		t.Fatalf("expected only inside triangle to be kept, got %v", got)
+	}
+
+	got = render.ClipMeshToBox([]r3.Triangle{straddle}, box)
+	if len(got) == 0 {
+		t.Fatal("straddling triangle was discarded")
+	}
+	var area float64
+	normal := r3.Unit(straddle.Normal())
+	for _, tri := range got {
+		for _, v := range tri {
+			if v.X > 1+1e-12 {
+				t.Errorf("vertex %v outside of box", v)
+			}
+		}
+		if r3.Dot(r3.Unit(tri.Normal()), normal) < 1-1e-9 {
+			t.Errorf("clipped triangle %v flipped orientation", tri)
+		}
+		area += tri.Area()
+	}
+	// The part of the straddling triangle with X>1 is a similar triangle
+	// with half the side length, which leaves 3/4 of the area inside.
+	want := 0.75 * straddle.Area()
+	if math.Abs(area-want) > 1e-12 {
+		t.Errorf("clipped area %g, want %g", area, want)
+	}
+
+	// A vertex exactly on the X=1 face must not give degenerate triangles.
+	touching := r3.Triangle{{X: 0.5, Y: 0.2, Z: 0.5}, {X: 1, Y: 0.2, Z: 0.5}, {X: 1.5, Y: 0.8, Z: 0.5}}
+	got = render.ClipMeshToBox([]r3.Triangle{touching}, box)
+	if len(got) == 0 {
+		t.Fatal("touching triangle was discarded")
+	}
+	for _, tri := range got {
+		if tri.Area() < 1e-12 {
+			t.Errorf("degenerate triangle %v", tri)
+		}
+	}
+}
+
+func TestCheckManifold(t *testing.T) {
+	a, b, c, d := r3.Vec{}, r3.Vec{X: 1}, r3.Vec{Y: 1}, r3.Vec{Z: 1}
+	tetra := []r3.Triangle{{a, c, b}, {a, b, d}, {a, d, c}, {b, c, d}}
+	if issues, ok := render.CheckManifold(tetra); !ok || issues != 0 {
+		t.Errorf("tetrahedron: got %d issues", issues)
+	}
+	// Removing a face leaves a hole bounded by 3 edges.
+	if issues, ok := render.CheckManifold(tetra[:3]); ok || issues != 3 {
+		t.Errorf("open tetrahedron: got %d issues, want 3", issues)
+	}
+	// Flipping a face breaks orientation on its 3 edges.
+	flipped := append([]r3.Triangle{}, tetra...)
+	flipped[3] = r3.Triangle{b, d, c}
+	if issues, ok := render.CheckManifold(flipped); ok || issues != 3 {
+		t.Errorf("flipped face: got %d issues, want 3", issues)
+	}
+	// Vertices within rounding error are welded.
+	welded := append([]r3.Triangle{}, tetra...)
+	welded[3][0].X += 1e-14
+	if issues, ok := render.CheckManifold(welded); !ok {
+		t.Errorf("nearly equal vertices not welded: got %d issues", issues)
+	}
+
+	sphere, _ := form3.Sphere(1)
+	mesh, err := render.RenderAll(render.NewOctreeRenderer(sphere, 40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues, ok := render.CheckManifold(mesh); !ok {
+		t.Errorf("octree sphere mesh has %d non manifold edges", issues)
+	}
+}
+
+func TestSortTriangles(t *testing.T) {
+	sphere, _ := form3.Sphere(1)
+	mesh, err := render.RenderAll(render.NewOctreeRenderer(sphere, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed := make([]r3.Triangle, len(mesh))
+	for i := range mesh {
+		reversed[len(mesh)-1-i] = mesh[i]
+	}
+	render.SortTriangles(mesh)
+	render.SortTriangles(reversed)
+	for i := range mesh {
+		if mesh[i] != reversed[i] {
+			t.Fatalf("triangle %d differs after sorting", i)
+		}
+	}
+}
+
+func TestRemoveDegenerateTriangles(t *testing.T) {
+	good := r3.Triangle{{}, {X: 1}, {Y: 1}}
+	coincident := r3.Triangle{{X: 1}, {X: 1}, {Y: 1}}
+	collinear := r3.Triangle{{}, {X: 1}, {X: 2}}
+	sliver := r3.Triangle{{}, {X: 1}, {X: 0.5, Y: 1e-9}}
+	got := render.RemoveDegenerateTriangles([]r3.Triangle{good, coincident, collinear, sliver, good}, 1e-6)
+	if len(got) != 2 || got[0] != good || got[1] != good {
+		t.Errorf("expected only the two good triangles to remain, got %v", got)
+	}
+	// Rendered meshes contain no degenerate triangles.
+	box, _ := form3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0.1)
+	mesh, err := render.RenderAll(render.NewOctreeRenderer(box, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(render.RemoveDegenerateTriangles(append([]r3.Triangle{}, mesh...), 0)); n != len(mesh) {
+		t.Errorf("rendered mesh contains %d degenerate triangles", len(mesh)-n)
+	}
+}
+
+func TestRepairWinding(t *testing.T) {
+	box, _ := form3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	outer, err := render.RenderAll(render.NewOctreeRenderer(box, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A cavity inside the box must face inward.
+	cavity := make([]r3.Triangle, len(outer))
+	for i, tri := range outer {
+		for j, v := range tri {
+			cavity[i][2-j] = r3.Scale(0.25, v)
+		}
+	}
+	want := append(append([]r3.Triangle{}, outer...), cavity...)
+	// Flip every third triangle and turn the whole cavity outward.
+	broken := append([]r3.Triangle{}, want...)
+	for i := range broken {
+		if i%3 == 0 || i >= len(outer) {
+			broken[i][1], broken[i][2] = broken[i][2], broken[i][1]
+		}
+	}
+	got, err := render.RepairWinding(broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		if r3.Dot(got[i].Normal(), want[i].Normal()) <= 0 {
+			t.Fatalf("triangle %d has wrong winding", i)
+		}
+	}
+	if issues, ok := render.CheckManifold(got); !ok {
+		t.Errorf("repaired mesh has %d non manifold edges", issues)
+	}
+	// A tetrahedron with a duplicated face is not a manifold.
+	a, b, c, d := r3.Vec{}, r3.Vec{X: 1}, r3.Vec{Y: 1}, r3.Vec{Z: 1}
+	extra := []r3.Triangle{{a, c, b}, {a, b, d}, {a, d, c}, {b, c, d}, {a, b, c}}
+	if _, err := render.RepairWinding(extra); err == nil {
+		t.Error("expected error for edge shared by more than two triangles")
+	}
+}
@@ -10,6 +10,10 @@ import (
 	"gonum.org/v1/gonum/spatial/r3"
 )
 
+// minTriangleAreaFactor times the squared mesh resolution is the minimum area
+// of triangles generated by the renderers. Smaller triangles are discarded.
+const minTriangleAreaFactor = 1e-10
+
 // MarchingCubesOctree renders using marching cubes with octree space sampling.
 type octree struct {
 	dc        dc3
@@ -24,6 +28,20 @@ type octree struct {
 	cubes int
 	// number of cubes processed.
 	cubesP int
+	// Triangles with a smaller area are discarded.
+	minArea float64
+	// AccurateBooleans places vertices by bisection along cell edges near
+	// seams of a union, difference or intersection at the root of the
+	// rendered SDF3. These combine fields with min and max so their field
+	// is kinked along seams where the surfaces of their operands meet and
+	// interpolated vertices there are displaced, giving jagged edges. A cell
+	// holds a seam if the fields of all operands at its center are smaller
+	// than its diagonal. The bisection converges on the zero level of the operands
+	// independently of the kink. It costs an evaluation of each operand for
+	// every cell on the surface and a few evaluations for each vertex near a seam.
+	AccurateBooleans bool
+	// operands of the boolean at the root of the rendered SDF3, nil if none.
+	operands []sdf.SDF3
 }
 
 type cube struct {
@@ -64,9 +82,28 @@ func NewOctreeRenderer(s sdf.SDF3, meshCells int) *octree {
 		unwritten: TriangleBuffer{buf: make([]r3.Triangle, 0, 1024)},
 		todo:      cubes,
 		cubes:     1,
+		// Discard slivers with an area negligible compared to the mesh cell.
+		minArea:  minTriangleAreaFactor * resolution * resolution,
+		operands: booleanOperands(s),
 	}
 }
 
+// booleanOperands returns the operands of s if it is a union,
+// difference or intersection, nil otherwise.
+func booleanOperands(s sdf.SDF3) []sdf.SDF3 {
+	d, ok := s.(sdf.SDF3Describer)
+	if !ok {
+		return nil
+	}
+	switch kind, _ := d.Describe(); kind {
+	case "union", "difference", "intersection":
+		if p, ok := s.(sdf.SDF3Parent); ok {
+			return p.Children()
+		}
+	}
+	return nil
+}
+
 // ReadTriangles writes triangles rendered from the model into the argument buffer.
 // returns number of triangles written and an error if present.
 func (oc *octree) ReadTriangles(dst []r3.Triangle) (n int, err error) {
@@ -130,53 +167,42 @@ func (oc *octree) readTriangles(dst []r3.Triangle, todo []cube) (n, cubesProcess
 }
 
 // readTrianglesThreaded is a multithreaded triangle reader implementation for octree.
-// It writes nt triangles into dst.
+// It writes nt triangles into dst. Each goroutine processes a contiguous run of
+// cubes and results are merged in cube order, so triangles are output in the same
+// order as the single threaded implementation regardless of the number of goroutines.
 func (oc *octree) readTrianglesThreaded(dst []r3.Triangle) (nt int) {
 	var wg sync.WaitGroup
-	div := len(dst) / oc.concurrent
+	batch := oc.todo[:min(len(oc.todo), len(dst))]
 	work := make([][]r3.Triangle, oc.concurrent)
-	cubeWork := make([][]cube, oc.concurrent)
 	newCubesC := make([][]cube, oc.concurrent)
-	divC := len(oc.todo) / oc.concurrent
+	divC := len(batch) / oc.concurrent
 	for i := 0; i < oc.concurrent; i++ {
 		i := i // Escape loop variable.
+		cubeWork := batch[i*divC : (i+1)*divC]
+		if i == oc.concurrent-1 {
+			cubeWork = batch[i*divC:]
+		}
 		wg.Add(1)
 		go func() {
-			start := div * i
-			if i == oc.concurrent-1 {
-				work[i] = dst[start:]
-				cubeWork[i] = oc.todo[i*divC:]
-			} else {
-				work[i] = dst[start : start+div]
-				cubeWork[i] = oc.todo[i*divC : (i+1)*divC]
+			tmp := make([]r3.Triangle, marchingCubesMaxTriangles)
+			for _, c := range cubeWork {
+				tri, cubes := oc.processCube(tmp, c)
+				work[i] = append(work[i], tmp[:tri]...)
+				newCubesC[i] = append(newCubesC[i], cubes...)
 			}
-			ntc, nc, newC := oc.readTriangles(work[i], cubeWork[i])
-			newCubesC[i] = newC
-			work[i] = work[i][:ntc]
-			cubeWork[i] = cubeWork[i][nc:]
 			wg.Done()
 		}()
 	}
 	wg.Wait()
-	// Consolidate work done.
-	offset := 0
-	oc.todo = oc.todo[len(oc.todo):]
+	// Consolidate work done in cube order.
+	oc.todo = oc.todo[len(batch):]
 	for i := 0; i < oc.concurrent; i++ {
-		// Triangles written.
-		start := div*i - offset
-		if i != oc.concurrent-1 && len(work[i]) != div {
-			copy(dst[start+len(work[i]):], dst[start+div:])
-			offset += div - len(work[i])
-		}
-		nt += len(work[i])
-		// Cubes unprocessed.
-		if len(cubeWork[i]) != 0 {
-			oc.todo = append(oc.todo, cubeWork[i]...)
-		}
-		// New Cubes
-		if len(newCubesC[i]) != 0 {
-			oc.todo = append(oc.todo, newCubesC[i]...)
+		n := copy(dst[nt:], work[i])
+		nt += n
+		if n < len(work[i]) {
+			oc.unwritten.Write(work[i][n:])
 		}
+		oc.todo = append(oc.todo, newCubesC[i]...)
 	}
 	return nt
 }
@@ -197,7 +223,11 @@ func (oc *octree) processCube(dst []r3.Triangle, c cube) (writtenTriangles int,
 		corners := [8]r3.Vec{c0, c1, c2, c3, c4, c5, c6, c7}
 		values := [8]float64{d0, d1, d2, d3, d4, d5, d6, d7}
 		// output the triangle(s) for this cube
-		writtenTriangles = mcToTriangles(dst, corners, values, 0)
+		var refine func(p1, p2 r3.Vec, v1, v2 float64) r3.Vec
+		if oc.AccurateBooleans && oc.isSeam(corners, values) {
+			refine = oc.refineVertex
+		}
+		writtenTriangles = mcToTriangles(dst, corners, values, 0, oc.minArea, refine)
 	} else {
 		// process the sub cubes
 		n := c.n - 1
@@ -227,16 +257,62 @@ func (oc *octree) processCube(dst []r3.Triangle, c cube) (writtenTriangles int,
 	return writtenTriangles, newCubes
 }
 
+// isSeam reports whether the cube with corners and values at them holds a
+// seam of the operands, where the surfaces of all operands pass near
+// the cube, judged by their fields at its center.
+func (oc *octree) isSeam(corners [8]r3.Vec, values [8]float64) bool {
+	if len(oc.operands) < 2 {
+		return false
+	}
+	crossed := false
+	for _, v := range values[1:] {
+		crossed = crossed || (v < 0) != (values[0] < 0)
+	}
+	if !crossed {
+		return false
+	}
+	center := r3.Scale(0.5, r3.Add(corners[0], corners[6]))
+	diag := r3.Norm(r3.Sub(corners[6], corners[0]))
+	for _, op := range oc.operands {
+		if math.Abs(op.Evaluate(center)) > diag {
+			return false
+		}
+	}
+	return true
+}
+
+// refineVertex returns the point where the surface crosses the edge from p1
+// to p2, whose ends have values v1 and v2 of opposite sign. The point found
+// by linear interpolation is kept if the field nearly vanishes there,
+// otherwise the crossing is found by bisection.
+func (oc *octree) refineVertex(p1, p2 r3.Vec, v1, v2 float64) r3.Vec {
+	const iterations = 20
+	p := mcInterpolate(p1, p2, v1, v2, 0)
+	tol := 1e-3 * math.Abs(v1-v2)
+	if math.Abs(oc.dc.s.Evaluate(p)) <= tol {
+		return p
+	}
+	for i := 0; i < iterations; i++ {
+		mid := r3.Scale(0.5, r3.Add(p1, p2))
+		if vm := oc.dc.s.Evaluate(mid); (vm < 0) == (v1 < 0) {
+			p1, v1 = mid, vm
+		} else {
+			p2 = mid
+		}
+	}
+	return r3.Scale(0.5, r3.Add(p1, p2))
+}
+
 // dc3 implements a 3 dimensional distance cache. evaluates the SDF3 via a distance cache to avoid repeated evaluations.
 // Experimentally about 2/3 of lookups get a hit, and the overall speedup
 // is about 2x a non-cached evaluation.
 type dc3 struct {
-	mu         sync.Mutex          // lock the the cache during reads/writes
-	cache      map[sdf.V3i]float64 // cache of distances
-	origin     r3.Vec              // origin of the overall bounding cube
-	resolution float64             // size of smallest octree cube
-	hdiag      []float64           // lookup table of cube half diagonals
-	s          sdf.SDF3            // the SDF3 to be rendered
+	cache      *sampleCache // cache of distances
+	scale      int          // grid coordinates are multiplied by scale to key cache.
+	origin     r3.Vec       // origin of the overall bounding cube
+	resolution float64      // size of smallest octree cube
+	hdiag      []float64    // lookup table of cube half diagonals
+	s          sdf.SDF3     // the SDF3 to be rendered
 }
 
 // Evaluate evaluates if
@@ -244,14 +320,15 @@ func (dc *dc3) Evaluate(vi sdf.V3i) (r3.Vec, float64) {
 	v := r3.Add(dc.origin, r3.Scale(dc.resolution, vi.ToV3()))
 
 	// do we have it in the cache?
-	dist, found := dc.read(vi)
+	key := sdf.V3i{vi[0] * dc.scale, vi[1] * dc.scale, vi[2] * dc.scale}
+	dist, found := dc.cache.read(key)
 	if found {
 		return v, dist
 	}
 	// evaluate the SDF3
 	dist = dc.s.Evaluate(v)
 	// write it to the cache
-	dc.write(vi, dist)
+	dc.cache.write(key, dist)
 	return v, dist
 }
 
@@ -275,7 +352,8 @@ func newDc3(s sdf.SDF3, origin r3.Vec, resolution float64, n uint) *dc3 {
 		resolution: resolution,
 		hdiag:      make([]float64, n),
 		s:          s,
-		cache:      make(map[sdf.V3i]float64),
+		cache:      newSampleCache(),
+		scale:      1,
 	}
 	// build a lut for cube half diagonal lengths
 	for i := range dc.hdiag {
@@ -286,19 +364,30 @@ func newDc3(s sdf.SDF3, origin r3.Vec, resolution float64, n uint) *dc3 {
 	return &dc
 }
 
-// read from the cache
-func (dc *dc3) read(vi sdf.V3i) (float64, bool) {
-	dc.mu.Lock()
-	dist, found := dc.cache[vi]
-	dc.mu.Unlock()
+// sampleCache holds field samples keyed by integer grid coordinates.
+// It is safe for concurrent use.
+type sampleCache struct {
+	mu sync.Mutex
+	m  map[sdf.V3i]float64
+}
+
+func newSampleCache() *sampleCache {
+	return &sampleCache{m: make(map[sdf.V3i]float64)}
+}
+
+// read returns the sample at grid coordinates vi if present.
+func (c *sampleCache) read(vi sdf.V3i) (float64, bool) {
+	c.mu.Lock()
+	dist, found := c.m[vi]
+	c.mu.Unlock()
 	return dist, found
 }
 
-// write to the cache
-func (dc *dc3) write(vi sdf.V3i, dist float64) {
-	dc.mu.Lock()
-	dc.cache[vi] = dist
-	dc.mu.Unlock()
+// write stores the sample at grid coordinates vi.
+func (c *sampleCache) write(vi sdf.V3i, dist float64) {
+	c.mu.Lock()
+	c.m[vi] = dist
+	c.mu.Unlock()
 }
 
 func max(a, b int) int {
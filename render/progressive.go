@@ -0,0 +1,78 @@
+package render
+
+import (
+	"context"
+	"io"
+	"math"
+
+	"github.com/soypat/sdf"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// MeshProgressive renders s with the octree marching cubes renderer at each of
+// the increasing resolutions in turn and calls cb with the mesh of each, so that
+// an interactive application can display a coarse mesh right away and refine it.
+// Rendering stops with the context's error if ctx is cancelled. Cancellation is
+// checked between batches of triangles.
+//
+// All levels share a single cache of field samples, so samples are reused
+// wherever grid points of two levels coincide. When a resolution is a multiple
+// of a previous one the grid of the previous level is contained in the finer
+// grid, so resolutions which double at each level cost little more than the finest.
+func MeshProgressive(ctx context.Context, s sdf.SDF3, resolutions []int, cb func(res int, tris []r3.Triangle)) error {
+	// The cache is keyed by coordinates on the grid of the least common multiple
+	// of the resolutions, which contains the grids of all levels. If it grows
+	// too fine for the keys the following levels start a new cache.
+	const maxGrid = math.MaxInt32 / 4
+	grids := make([]int, len(resolutions))
+	for start := 0; start < len(resolutions); {
+		grid, end := resolutions[start], start+1
+		for ; end < len(resolutions); end++ {
+			res := resolutions[end]
+			lcm := grid / gcd(grid, res) * res
+			if lcm/res > maxGrid/(2*res) {
+				break
+			}
+			grid = lcm
+		}
+		for i := start; i < end; i++ {
+			grids[i] = grid
+		}
+		start = end
+	}
+	var cache *sampleCache
+	for i, res := range resolutions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		oc := NewOctreeRenderer(s, res)
+		if i == 0 || grids[i] != grids[i-1] {
+			cache = newSampleCache()
+		}
+		oc.dc.cache, oc.dc.scale = cache, grids[i]/res
+		var tris []r3.Triangle
+		buf := make([]r3.Triangle, 1024)
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n, err := oc.ReadTriangles(buf)
+			tris = append(tris, buf[:n]...)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+		}
+		cb(res, tris)
+	}
+	return nil
+}
+
+// gcd returns the greatest common divisor of positive a and b.
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
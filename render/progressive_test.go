@@ -0,0 +1,51 @@
+package render_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/soypat/sdf/form3"
+	"github.com/soypat/sdf/render"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestMeshProgressive(t *testing.T) {
+	s, _ := form3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0.2)
+	resolutions := []int{8, 16, 32, 40}
+	var got []int
+	err := render.MeshProgressive(context.Background(), s, resolutions, func(res int, tris []r3.Triangle) {
+		got = append(got, res)
+		want, err := render.RenderAll(render.NewOctreeRenderer(s, res))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reused samples give the same mesh as rendering from scratch.
+		render.SortTriangles(tris)
+		render.SortTriangles(want)
+		if len(tris) != len(want) {
+			t.Fatalf("resolution %d: got %d triangles, want %d", res, len(tris), len(want))
+		}
+		for i := range want {
+			if tris[i] != want[i] {
+				t.Fatalf("resolution %d: triangle %d differs", res, i)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(resolutions) {
+		t.Errorf("callback called for resolutions %v, want %v", got, resolutions)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err = render.MeshProgressive(ctx, s, resolutions, func(int, []r3.Triangle) {
+		calls++
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("cancelled rendering: got error %v after %d calls", err, calls)
+	}
+}
@@ -370,6 +370,79 @@ func (s *slice2) Bounds() r2.Box {
 	return s.bb
 }
 
+// silhouette2 is the projection of an SDF3 along a coordinate axis.
+type silhouette2 struct {
+	sdf   SDF3
+	axis  int
+	depth []float64 // sampled coordinates along the axis.
+	bb    r2.Box
+}
+
+// Silhouette2D returns the silhouette of sdf viewed along a coordinate axis (0=X, 1=Y, 2=Z).
+// The 2D X and Y axes are the remaining 3D axes in order, i.e. Y and Z when viewing along X.
+// The silhouette is evaluated as the minimum of sdf over zSamples points evenly spaced along
+// the axis within the bounding box. A point lies inside the silhouette if a sample of the
+// ray through it lies inside sdf, so features thinner than the sample spacing may be missed.
+// Outside the silhouette the value is an upper bound of the 2D distance.
+func Silhouette2D(sdf SDF3, axis int, zSamples int) SDF2 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if axis < 0 || axis > 2 {
+		panic("axis must be 0, 1 or 2")
+	}
+	if zSamples < 2 {
+		panic("zSamples must be 2 or larger")
+	}
+	bb := sdf.Bounds()
+	s := silhouette2{
+		sdf:   sdf,
+		axis:  axis,
+		depth: make([]float64, zSamples),
+	}
+	min, max := silhouetteSplit(bb.Min, axis), silhouetteSplit(bb.Max, axis)
+	for i := range s.depth {
+		s.depth[i] = min.Z + (max.Z-min.Z)*float64(i)/float64(zSamples-1)
+	}
+	s.bb = r2.Box{Min: r2.Vec{X: min.X, Y: min.Y}, Max: r2.Vec{X: max.X, Y: max.Y}}
+	return &s
+}
+
+// silhouetteSplit returns v with its components reordered so that
+// the component along axis is Z and the others keep their order.
+func silhouetteSplit(v r3.Vec, axis int) r3.Vec {
+	switch axis {
+	case 0:
+		return r3.Vec{X: v.Y, Y: v.Z, Z: v.X}
+	case 1:
+		return r3.Vec{X: v.X, Y: v.Z, Z: v.Y}
+	}
+	return v
+}
+
+// Evaluate returns the approximate minimum distance to the silhouette.
+func (s *silhouette2) Evaluate(p r2.Vec) float64 {
+	d := math.Inf(1)
+	for _, z := range s.depth {
+		var q r3.Vec
+		switch s.axis {
+		case 0:
+			q = r3.Vec{X: z, Y: p.X, Z: p.Y}
+		case 1:
+			q = r3.Vec{X: p.X, Y: z, Z: p.Y}
+		default:
+			q = r3.Vec{X: p.X, Y: p.Y, Z: z}
+		}
+		d = math.Min(d, s.sdf.Evaluate(q))
+	}
+	return d
+}
+
+// Bounds returns the bounding box of the silhouette.
+func (s *silhouette2) Bounds() r2.Box {
+	return s.bb
+}
+
 // union2 is a union of multiple SDF2 objects.
 type union2 struct {
 	sdf []SDF2
@@ -1,7 +1,10 @@
 package sdf
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"math/rand"
 	"strconv"
 
 	"github.com/soypat/sdf/internal/d2"
@@ -28,6 +31,12 @@ type SDF3Union interface {
 	SetMin(MinFunc)
 }
 
+// Culler is implemented by unions which can skip evaluating objects whose
+// bounding sphere is farther than the distance found so far, see Union3D.
+type Culler interface {
+	SetCull(cull bool)
+}
+
 type SDF3Diff interface {
 	SDF3
 	SetMax(MaxFunc)
@@ -109,6 +118,57 @@ func (s *revolution3) Bounds() r3.Box {
 	return s.bb
 }
 
+// revolutionCapped3 is a partial solid of revolution with exact distances to its end faces.
+type revolutionCapped3 struct {
+	revolution3
+	dir1 r2.Vec // direction of the end face at theta
+}
+
+// RevolveCapped3D returns an SDF3 for a partial solid of revolution of theta radians.
+// Unlike Revolve3D, which bounds the distance outside the revolution with the two
+// planes of the end faces, the distance to the flat end faces is exact which
+// gives better behaved fields and normals near the cuts. The profile must lie
+// on the positive X side of the axis for the end face distances to be exact.
+// Full revolutions are equivalent to Revolve3D.
+func RevolveCapped3D(sdf SDF2, theta float64) SDF3 {
+	rev := Revolve3D(sdf, theta)
+	r, ok := rev.(*revolution3)
+	if !ok || r.theta == 0 {
+		return rev
+	}
+	return &revolutionCapped3{
+		revolution3: *r,
+		dir1:        r2.Vec{X: r.norm.Y, Y: -r.norm.X},
+	}
+}
+
+// Evaluate returns the minimum distance to a capped partial solid of revolution.
+func (s *revolutionCapped3) Evaluate(p r3.Vec) float64 {
+	// distance to the end face in the plane spanned by the Z axis and dir.
+	// The face is the profile placed on the half plane of dir.
+	face := func(dir, norm r2.Vec) float64 {
+		pxy := r2.Vec{X: p.X, Y: p.Y}
+		h := pxy.Dot(norm)
+		d := math.Max(0, s.sdf.Evaluate(r2.Vec{X: pxy.Dot(dir), Y: p.Z}))
+		return math.Hypot(d, h)
+	}
+	d0 := face(r2.Vec{X: 1}, r2.Vec{Y: 1})
+	d1 := face(s.dir1, s.norm)
+	phi := math.Atan2(p.Y, p.X)
+	if phi < 0 {
+		phi += tau
+	}
+	if phi > s.theta {
+		// Outside the wedge the closest point is on one of the end faces.
+		return math.Min(d0, d1)
+	}
+	a := s.sdf.Evaluate(r2.Vec{X: math.Hypot(p.X, p.Y), Y: p.Z})
+	if a >= 0 {
+		return a
+	}
+	return -math.Min(-a, math.Min(d0, d1))
+}
+
 // extrude3 extrudes an SDF2 to an SDF3.
 type extrude3 struct {
 	sdf     SDF2
@@ -350,10 +410,14 @@ type transform3 struct {
 }
 
 // Transform3D applies a transformation matrix to an SDF3.
+// It panics if the matrix is singular, see Transform3DChecked.
 func Transform3D(sdf SDF3, matrix m44) SDF3 {
 	if sdf == nil {
 		panic("nil SDF3 argument")
 	}
+	if err := matrix.checkInvertible(); err != nil {
+		panic(err.Error())
+	}
 	s := transform3{}
 	s.sdf = sdf
 	s.matrix = matrix
@@ -362,10 +426,31 @@ func Transform3D(sdf SDF3, matrix m44) SDF3 {
 	return &s
 }
 
+// Transform3DChecked applies a transformation matrix to an SDF3 like Transform3D but
+// returns an error instead of panicking if sdf is nil or the matrix is singular or
+// nearly so, such as a scaling by zero along an axis, whose inverse would turn the
+// field into NaNs or infinities.
+func Transform3DChecked(sdf SDF3, matrix m44) (SDF3, error) {
+	if sdf == nil {
+		return nil, errors.New("nil SDF3 argument")
+	}
+	if err := matrix.checkInvertible(); err != nil {
+		return nil, err
+	}
+	return Transform3D(sdf, matrix), nil
+}
+
 // Evaluate returns the minimum distance to a transformed SDF3.
 // Distance is *not* preserved with scaling.
 func (s *transform3) Evaluate(p r3.Vec) float64 {
-	return s.sdf.Evaluate(s.inverse.MulPosition(p))
+	q := s.inverse.MulPosition(p)
+	if DebugChecks {
+		checkPoint(s, p, q)
+		d := s.sdf.Evaluate(q)
+		checkDistance(s.sdf, q, d)
+		return d
+	}
+	return s.sdf.Evaluate(q)
 }
 
 // BoundingBox returns the bounding box of a transformed SDF3.
@@ -405,16 +490,94 @@ func (s *scaleUniform3) Bounds() r3.Box {
 	return s.bb
 }
 
+// BoundingSphere returns the sphere circumscribing the bounding box of an SDF3.
+// The center is the center of the box and the radius is half its diagonal.
+func BoundingSphere(sdf SDF3) (center r3.Vec, radius float64) {
+	bb := d3.Box(sdf.Bounds())
+	return bb.Center(), 0.5 * r3.Norm(bb.Size())
+}
+
+// SceneBounds returns the union of the bounding boxes of sdfs, the extent of a scene
+// of separate objects which are not joined by a union. The zero box is returned
+// for no arguments.
+func SceneBounds(sdfs ...SDF3) r3.Box {
+	if len(sdfs) == 0 {
+		return r3.Box{}
+	}
+	var bb d3.Box
+	for i, s := range sdfs {
+		if s == nil {
+			panic("nil SDF3 argument")
+		}
+		if i == 0 {
+			bb = d3.Box(s.Bounds())
+		} else {
+			bb = bb.Extend(d3.Box(s.Bounds()))
+		}
+	}
+	return r3.Box(bb)
+}
+
+// SceneCenter returns the center of the SceneBounds of sdfs.
+func SceneCenter(sdfs ...SDF3) r3.Vec {
+	return d3.Box(SceneBounds(sdfs...)).Center()
+}
+
+// SceneSize returns the size of the SceneBounds of sdfs.
+func SceneSize(sdfs ...SDF3) r3.Vec {
+	return d3.Box(SceneBounds(sdfs...)).Size()
+}
+
+// FitToBox3D scales and translates an SDF3 so that its bounding box fits the
+// target box. If uniform is true the SDF3 is scaled by the same factor on every
+// axis so its aspect ratio is kept and it is centered within target. Otherwise
+// each axis is scaled independently to fill target exactly, in which case the
+// distance is not preserved, same as with Transform3D.
+func FitToBox3D(sdf SDF3, target r3.Box, uniform bool) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	bb := d3.Box(sdf.Bounds())
+	tb := d3.Box(target)
+	size := bb.Size()
+	if d3.LTEZero(size) {
+		panic("SDF3 bounding box has zero size")
+	}
+	if d3.LTEZero(tb.Size()) {
+		panic("target box has zero size")
+	}
+	k := d3.DivElem(tb.Size(), size)
+	if uniform {
+		ku := math.Min(k.X, math.Min(k.Y, k.Z))
+		scaled := ScaleUniform3D(sdf, ku)
+		center := r3.Scale(ku, bb.Center())
+		return Transform3D(scaled, Translate3D(r3.Sub(tb.Center(), center)))
+	}
+	return Transform3D(sdf, Translate3D(tb.Center()).Mul(Scale3D(k)).Mul(Translate3D(r3.Scale(-1, bb.Center()))))
+}
+
 // union3 is a union of SDF3s.
 type union3 struct {
 	sdf []SDF3
 	min MinFunc
 	bb  r3.Box
+	// Bounding spheres of sdf used to skip evaluating far away
+	// objects when cull is set. Only valid when min is math.Min.
+	centers []r3.Vec
+	radii   []float64
+	cull    bool
+	blend   bool
+	// index maps each SDF3 to its position in the arguments of
+	// Union3DKeepNil. nil if positions match.
+	index []int
+	// pad enlarges bb on each side to hold the bulge of SmoothUnion3D.
+	pad float64
 }
 
 // Union3D returns the union of multiple SDF3 objects.
 // Union3D will panic if arguments list is empty or if
-// an argument SDF3 is nil.
+// an argument SDF3 is nil. If all objects report exact distances
+// far away objects are skipped using their bounding spheres, see SetCull.
 func Union3D(sdf ...SDF3) SDF3Union {
 	if len(sdf) < 2 {
 		panic("union require at least 2 sdfs")
@@ -434,25 +597,134 @@ func Union3D(sdf ...SDF3) SDF3Union {
 	}
 	s.bb = r3.Box(bb)
 	s.min = math.Min
+	s.centers = make([]r3.Vec, len(s.sdf))
+	s.radii = make([]float64, len(s.sdf))
+	for i, x := range s.sdf {
+		s.centers[i], s.radii[i] = BoundingSphere(x)
+	}
+	s.cull = exactAll(s.sdf...)
 	return &s
 }
 
+// SmoothUnion3D returns the union of sdfs blended with fillets of radius k where they
+// meet, using the quadratic polynomial smooth minimum of MinPoly(2, k). Unlike setting
+// the minimum function of a Union3D afterwards the bounding box is enlarged to hold
+// the bulge of the blend, which lowers the field by up to k/4 for each SDF3 folded in,
+// so by (len(sdfs)-1)*k/4 on each side. SDF3s are folded in order, so where more
+// than two meet the result depends slightly on their order.
+func SmoothUnion3D(k float64, sdfs ...SDF3) SDF3 {
+	if !(k > 0) || math.IsInf(k, 1) {
+		panic("k must be finite and > 0")
+	}
+	s := Union3D(sdfs...).(*union3)
+	s.SetMin(MinPoly(2, k))
+	s.pad = float64(len(sdfs)-1) * k / 4
+	s.bb = r3.Box(d3.Box(s.bb).Enlarge(d3.Elem(2 * s.pad)))
+	return s
+}
+
+// Union3DKeepNil returns the union of the non nil SDF3s in sdf. Unlike Union3D
+// nil arguments are allowed and the index returned by EvaluateIndex is the
+// position of the closest SDF3 in sdf, nils included. This keeps the index aligned
+// with slices of metadata such as materials or names which parallel sdf.
+// At least one SDF3 must be non nil.
+func Union3DKeepNil(sdf ...SDF3) SDF3Union {
+	var objects []SDF3
+	var index []int
+	for i, x := range sdf {
+		if x != nil {
+			objects = append(objects, x)
+			index = append(index, i)
+		}
+	}
+	switch len(objects) {
+	case 0:
+		panic("union requires at least 1 non nil sdf")
+	case 1:
+		// Union3D requires two SDF3s, the single object is repeated.
+		objects = append(objects, objects[0])
+		index = append(index, index[0])
+	}
+	u := Union3D(objects...).(*union3)
+	u.index = index
+	return u
+}
+
+// IndexEvaluator is implemented by SDF3s composed of several SDF3s which can
+// report which of them is closest to a point, for example to assign materials.
+type IndexEvaluator interface {
+	// EvaluateIndex returns the distance to the SDF3 and the index
+	// of the closest of the SDF3s it is composed of.
+	EvaluateIndex(p r3.Vec) (d float64, index int)
+}
+
+// EvaluateIndex returns the distance to the union and the index of the
+// closest SDF3, which is its position in the arguments to Union3D or Union3DKeepNil.
+// With a blending minimum set the index is that of the SDF3 with the smallest distance.
+func (s *union3) EvaluateIndex(p r3.Vec) (d float64, index int) {
+	nearest := math.Inf(1)
+	for i, x := range s.sdf {
+		if s.cull && i > 0 && r3.Norm(r3.Sub(p, s.centers[i]))-s.radii[i] >= nearest {
+			continue
+		}
+		di := x.Evaluate(p)
+		if i == 0 {
+			d = di
+		} else {
+			d = s.min(d, di)
+		}
+		if di < nearest {
+			nearest, index = di, i
+		}
+	}
+	if s.index != nil {
+		index = s.index[index]
+	}
+	return d, index
+}
+
 // Evaluate returns the minimum distance to an SDF3 union.
 func (s *union3) Evaluate(p r3.Vec) float64 {
 	var d float64
 	for i, x := range s.sdf {
 		if i == 0 {
 			d = x.Evaluate(p)
-		} else {
-			d = s.min(d, x.Evaluate(p))
+			if DebugChecks {
+				checkDistance(x, p, d)
+			}
+			continue
+		}
+		// An object can not be closer than its bounding sphere so
+		// it can't lower the minimum if its sphere is farther than d.
+		if s.cull && r3.Norm(r3.Sub(p, s.centers[i]))-s.radii[i] >= d {
+			continue
 		}
+		di := x.Evaluate(p)
+		if DebugChecks {
+			checkDistance(x, p, di)
+		}
+		d = s.min(d, di)
 	}
 	return d
 }
 
 // SetMin sets the minimum function to control blending.
+// Bounding sphere culling is disabled since blending
+// functions may be affected by far away objects.
 func (s *union3) SetMin(min MinFunc) {
 	s.min = min
+	s.blend = true
+	s.cull = false
+}
+
+// SetCull sets whether objects whose bounding sphere is farther than the
+// distance found so far are skipped during evaluation. Union3D enables it
+// if all objects are exact. Enabling it for objects which may underestimate
+// their distance, such as differences or scaled objects, can change the
+// result where their field is lower than the distance to their bounding sphere.
+// It must not be enabled after setting a blending minimum function.
+func (s *union3) SetCull(cull bool) {
+	s.cull = cull
 }
 
 // BoundingBox returns the bounding box of an SDF3 union.
@@ -462,10 +734,11 @@ func (s *union3) Bounds() r3.Box {
 
 // diff3 is the difference of two SDF3s, s0 - s1.
 type diff3 struct {
-	s0  SDF3
-	s1  SDF3
-	max MaxFunc
-	bb  r3.Box
+	s0    SDF3
+	s1    SDF3
+	max   MaxFunc
+	bb    r3.Box
+	blend bool // max was set by SetMax
 }
 
 // Difference3D returns the difference of two SDF3s, s0 - s1.
@@ -484,12 +757,19 @@ func Difference3D(s0, s1 SDF3) SDF3Diff {
 
 // Evaluate returns the minimum distance to the SDF3 difference.
 func (s *diff3) Evaluate(p r3.Vec) float64 {
+	if DebugChecks {
+		d0, d1 := s.s0.Evaluate(p), s.s1.Evaluate(p)
+		checkDistance(s.s0, p, d0)
+		checkDistance(s.s1, p, d1)
+		return s.max(d0, -d1)
+	}
 	return s.max(s.s0.Evaluate(p), -s.s1.Evaluate(p))
 }
 
 // SetMax sets the maximum function to control blending.
 func (s *diff3) SetMax(max MaxFunc) {
 	s.max = max
+	s.blend = true
 }
 
 // BoundingBox returns the bounding box of the SDF3 difference.
@@ -497,6 +777,33 @@ func (s *diff3) Bounds() r3.Box {
 	return s.bb
 }
 
+// Difference3DSmooth returns the difference of two SDF3s, s0 - s1, where the
+// transition between the surface of s0 and the surface cut by s1 is blended
+// over a band of width k. This removes the gradient kink along the seam of the
+// cut which otherwise shows up as shading artifacts in meshed normals.
+// k<=0 is equivalent to Difference3D.
+//
+// Deprecated: Use SmoothDifference3D, which this calls for k>0.
+func Difference3DSmooth(s0, s1 SDF3, k float64) SDF3 {
+	if k <= 0 {
+		return Difference3D(s0, s1)
+	}
+	return SmoothDifference3D(k, s0, s1)
+}
+
+// SmoothDifference3D returns the difference of two SDF3s, s0 - s1, with the edges
+// left by the cut blended with fillets of radius k, using the quadratic polynomial
+// smooth maximum of MaxPoly(2, k). The blend only removes material so the bounding
+// box of s0 is kept.
+func SmoothDifference3D(k float64, s0, s1 SDF3) SDF3 {
+	if !(k > 0) || math.IsInf(k, 1) {
+		panic("k must be finite and > 0")
+	}
+	s := Difference3D(s0, s1)
+	s.SetMax(MaxPoly(2, k))
+	return s
+}
+
 // elongate3 is the elongation of an SDF3.
 type elongate3 struct {
 	sdf    SDF3   // the sdf being elongated
@@ -556,6 +863,12 @@ func Intersect3D(s0, s1 SDF3) SDF3Diff {
 
 // Evaluate returns the minimum distance to the SDF3 intersection.
 func (s *intersection3) Evaluate(p r3.Vec) float64 {
+	if DebugChecks {
+		d0, d1 := s.s0.Evaluate(p), s.s1.Evaluate(p)
+		checkDistance(s.s0, p, d0)
+		checkDistance(s.s1, p, d1)
+		return s.max(d0, d1)
+	}
 	return s.max(s.s0.Evaluate(p), s.s1.Evaluate(p))
 }
 
@@ -569,6 +882,23 @@ func (s *intersection3) Bounds() r3.Box {
 	return s.bb
 }
 
+// SmoothIntersect3D returns the intersection of two SDF3s with the edges where
+// their surfaces meet rounded over with radius k, using the quadratic polynomial
+// smooth maximum of MaxPoly(2, k). The blend only removes material so the bounding
+// box is the intersection of the bounding boxes of s0 and s1.
+func SmoothIntersect3D(k float64, s0, s1 SDF3) SDF3 {
+	if !(k > 0) || math.IsInf(k, 1) {
+		panic("k must be finite and > 0")
+	}
+	s := Intersect3D(s0, s1).(*intersection3)
+	s.SetMax(MaxPoly(2, k))
+	bb0, bb1 := s0.Bounds(), s1.Bounds()
+	s.bb.Min = d3.MaxElem(bb0.Min, bb1.Min)
+	// Disjoint boxes leave an empty box at the corner of the overlap.
+	s.bb.Max = d3.MaxElem(s.bb.Min, d3.MinElem(bb0.Max, bb1.Max))
+	return s
+}
+
 // cut3 makes a planar cut through an SDF3.
 type cut3 struct {
 	sdf SDF3
@@ -599,6 +929,97 @@ func (s *cut3) Bounds() r3.Box {
 	return s.bb
 }
 
+// trim3 intersects an SDF3 with half-spaces.
+type trim3 struct {
+	sdf    SDF3
+	planes []Plane // planes with unit normals.
+	bb     r3.Box
+}
+
+// Trim3D cuts sdf with planes whose normals point away from the part of the
+// solid which remains, as when machining a part from stock. Unlike chained
+// Cut3D calls the bounding box is trimmed to the convex polytope left of the
+// original bounding box by the planes.
+func Trim3D(sdf SDF3, planes []Plane) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	s := trim3{
+		sdf:    sdf,
+		planes: make([]Plane, len(planes)),
+	}
+	for i, plane := range planes {
+		if r3.Norm(plane.Normal) == 0 {
+			panic("zero plane normal")
+		}
+		plane.Normal = r3.Unit(plane.Normal)
+		s.planes[i] = plane
+	}
+	// The remaining region of the bounding box is a convex polytope whose
+	// vertices lie where three of the planes or box faces intersect.
+	bb := sdf.Bounds()
+	faces := append([]Plane{
+		{Point: bb.Min, Normal: r3.Vec{X: -1}}, {Point: bb.Max, Normal: r3.Vec{X: 1}},
+		{Point: bb.Min, Normal: r3.Vec{Y: -1}}, {Point: bb.Max, Normal: r3.Vec{Y: 1}},
+		{Point: bb.Min, Normal: r3.Vec{Z: -1}}, {Point: bb.Max, Normal: r3.Vec{Z: 1}},
+	}, s.planes...)
+	tol := 1e-9 * r3.Norm(r3.Sub(bb.Max, bb.Min))
+	var vertices d3.Set
+	for i := range faces {
+		for j := i + 1; j < len(faces); j++ {
+			for k := j + 1; k < len(faces); k++ {
+				v, ok := intersectPlanes(faces[i], faces[j], faces[k])
+				if ok && inHalfSpaces(faces, v, tol) {
+					vertices = append(vertices, v)
+				}
+			}
+		}
+	}
+	if len(vertices) == 0 {
+		panic("planes trim away the whole SDF3")
+	}
+	s.bb = r3.Box{Min: vertices.Min(), Max: vertices.Max()}
+	return &s
+}
+
+// intersectPlanes returns the point where three planes intersect.
+// Returns false if the planes do not intersect at a single point.
+func intersectPlanes(a, b, c Plane) (r3.Vec, bool) {
+	bc := r3.Cross(b.Normal, c.Normal)
+	det := r3.Dot(a.Normal, bc)
+	if math.Abs(det) < epsilon {
+		return r3.Vec{}, false
+	}
+	v := r3.Scale(r3.Dot(a.Point, a.Normal), bc)
+	v = r3.Add(v, r3.Scale(r3.Dot(b.Point, b.Normal), r3.Cross(c.Normal, a.Normal)))
+	v = r3.Add(v, r3.Scale(r3.Dot(c.Point, c.Normal), r3.Cross(a.Normal, b.Normal)))
+	return r3.Scale(1/det, v), true
+}
+
+// inHalfSpaces reports whether p lies behind all planes within tol.
+func inHalfSpaces(planes []Plane, p r3.Vec, tol float64) bool {
+	for _, plane := range planes {
+		if r3.Dot(r3.Sub(p, plane.Point), plane.Normal) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate returns the minimum distance to the trimmed SDF3.
+func (s *trim3) Evaluate(p r3.Vec) float64 {
+	d := s.sdf.Evaluate(p)
+	for _, plane := range s.planes {
+		d = math.Max(d, r3.Dot(r3.Sub(p, plane.Point), plane.Normal))
+	}
+	return d
+}
+
+// Bounds returns the bounding box of the trimmed SDF3.
+func (s *trim3) Bounds() r3.Box {
+	return s.bb
+}
+
 // array3 stores an XYZ array of a given SDF3
 type array3 struct {
 	sdf  SDF3
@@ -606,6 +1027,10 @@ type array3 struct {
 	step r3.Vec
 	min  MinFunc
 	bb   r3.Box
+	// near enables evaluating only the 2x2x2 instances
+	// closest to the point. See Perforate3D.
+	near  bool
+	blend bool // min was set by SetMin
 }
 
 // Array3D returns an XYZ array of a given SDF3
@@ -629,10 +1054,14 @@ func Array3D(sdf SDF3, num V3i, step r3.Vec) SDF3Union {
 // SetMin sets the minimum function to control blending.
 func (s *array3) SetMin(min MinFunc) {
 	s.min = min
+	s.blend = true
 }
 
 // Evaluate returns the minimum distance to an XYZ SDF3 array.
 func (s *array3) Evaluate(p r3.Vec) float64 {
+	if s.near {
+		return s.evaluateNear(p)
+	}
 	d := math.MaxFloat64
 	for j := 0; j < s.num[0]; j++ {
 		for k := 0; k < s.num[1]; k++ {
@@ -645,18 +1074,85 @@ func (s *array3) Evaluate(p r3.Vec) float64 {
 	return d
 }
 
+// evaluateNear returns the minimum distance to the up to 2x2x2 array instances
+// closest to p. The result is exact near instances which fit within their
+// array cell and overestimates the distance far from the array.
+func (s *array3) evaluateNear(p r3.Vec) float64 {
+	var idx, dir [3]int
+	pc := [3]float64{p.X, p.Y, p.Z}
+	step := [3]float64{s.step.X, s.step.Y, s.step.Z}
+	for i := range idx {
+		if s.num[i] == 1 || step[i] == 0 {
+			continue
+		}
+		x := pc[i] / step[i]
+		idx[i] = int(clamp(math.Round(x), 0, float64(s.num[i]-1)))
+		dir[i] = 1
+		if x < float64(idx[i]) {
+			dir[i] = -1
+		}
+	}
+	// Each axis has the closest instance and, if there is one,
+	// the next instance towards p.
+	var n [3]int
+	for i := range n {
+		n[i] = 1
+		if next := idx[i] + dir[i]; dir[i] != 0 && next >= 0 && next < s.num[i] {
+			n[i] = 2
+		}
+	}
+	d := math.MaxFloat64
+	for a := 0; a < n[0]; a++ {
+		for b := 0; b < n[1]; b++ {
+			for c := 0; c < n[2]; c++ {
+				x := p.Sub(r3.Vec{
+					X: float64(idx[0]+a*dir[0]) * s.step.X,
+					Y: float64(idx[1]+b*dir[1]) * s.step.Y,
+					Z: float64(idx[2]+c*dir[2]) * s.step.Z,
+				})
+				d = s.min(d, s.sdf.Evaluate(x))
+			}
+		}
+	}
+	return d
+}
+
 // BoundingBox returns the bounding box of an XYZ SDF3 array.
 func (s *array3) Bounds() r3.Box {
 	return s.bb
 }
 
+// Perforate3D subtracts an XYZ array of hole from base. The bounding box of base is kept.
+// If hole fits within its array cell, i.e. within half a step of the origin on
+// every arrayed axis, only the holes closest to the point are evaluated so the
+// cost does not grow with the number of holes.
+func Perforate3D(base, hole SDF3, num V3i, step r3.Vec) SDF3 {
+	if base == nil || hole == nil {
+		panic("nil SDF3 argument")
+	}
+	holes := Array3D(hole, num, step)
+	if a, ok := holes.(*array3); ok {
+		half := d3.AbsElem(r3.Scale(0.5, step))
+		bb := hole.Bounds()
+		fits := true
+		for i, v := range [][3]float64{
+			{bb.Min.X, bb.Max.X, half.X}, {bb.Min.Y, bb.Max.Y, half.Y}, {bb.Min.Z, bb.Max.Z, half.Z},
+		} {
+			fits = fits && (num[i] == 1 || (v[0] >= -v[2] && v[1] <= v[2]))
+		}
+		a.near = fits
+	}
+	return Difference3D(base, holes)
+}
+
 // rotateUnion creates a union of SDF3s rotated about the z-axis.
 type rotateUnion struct {
-	sdf  SDF3
-	num  int
-	step m44
-	min  MinFunc
-	bb   r3.Box
+	sdf   SDF3
+	num   int
+	step  m44
+	min   MinFunc
+	blend bool // min was set by SetMin
+	bb    r3.Box
 }
 
 // RotateUnion3D creates a union of SDF3s rotated about the z-axis.
@@ -700,6 +1196,7 @@ func (s *rotateUnion) Evaluate(p r3.Vec) float64 {
 // SetMin sets the minimum function to control blending.
 func (s *rotateUnion) SetMin(min MinFunc) {
 	s.min = min
+	s.blend = true
 }
 
 // BoundingBox returns the bounding box of a rotate/union object.
@@ -754,6 +1251,120 @@ func (s *rotateCopy3) Bounds() r3.Box {
 	return s.bb
 }
 
+// symmetrize3 combines rotated copies of an SDF3 about the z-axis.
+type symmetrize3 struct {
+	sdf SDF3
+	rot []m44   // inverse rotation of each copy.
+	max MaxFunc // nil to average the copies.
+	bb  r3.Box
+}
+
+// Symmetrize3D enforces n-fold rotational symmetry about the z-axis on an SDF3
+// which is nearly symmetric, such as a design with small asymmetric errors. The
+// n rotated copies of sdf are evaluated and their distances averaged, which keeps
+// the size of the shape unlike RotateUnion3D and does not fold space unlike
+// RotateCopy3D. Call SetMax(math.Max) to keep only the part of the shape common
+// to all copies instead, which is conservative but removes asymmetric features.
+func Symmetrize3D(sdf SDF3, n int) SDF3Diff {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if n <= 0 {
+		panic("n <= 0")
+	}
+	s := symmetrize3{
+		sdf: sdf,
+		rot: make([]m44, n),
+	}
+	// The zero level of the combined distance lies within one of the copies.
+	v := d3.Box(sdf.Bounds()).Vertices()
+	s.bb = r3.Box{Min: v.Min(), Max: v.Max()}
+	for i := range s.rot {
+		rot := RotateZ(tau * float64(i) / float64(n))
+		s.rot[i] = rot.Inverse()
+		for _, vertex := range v {
+			vertex = rot.MulPosition(vertex)
+			s.bb.Min = d3.MinElem(s.bb.Min, vertex)
+			s.bb.Max = d3.MaxElem(s.bb.Max, vertex)
+		}
+	}
+	return &s
+}
+
+// Evaluate returns the combined distance of the rotated copies.
+func (s *symmetrize3) Evaluate(p r3.Vec) float64 {
+	if s.max != nil {
+		d := -math.MaxFloat64
+		for _, rot := range s.rot {
+			d = s.max(d, s.sdf.Evaluate(rot.MulPosition(p)))
+		}
+		return d
+	}
+	var d float64
+	for _, rot := range s.rot {
+		d += s.sdf.Evaluate(rot.MulPosition(p))
+	}
+	return d / float64(len(s.rot))
+}
+
+// SetMax combines the copies with max instead of averaging them.
+func (s *symmetrize3) SetMax(max MaxFunc) {
+	s.max = max
+}
+
+// Bounds returns the bounding box of the symmetrized SDF3.
+func (s *symmetrize3) Bounds() r3.Box {
+	return s.bb
+}
+
+// mirror3 is the union of an SDF3 and its mirror image across a plane.
+type mirror3 struct {
+	sdf    SDF3
+	point  r3.Vec // point on plane
+	normal r3.Vec // unit normal to plane
+	bb     r3.Box
+}
+
+// Mirror3D returns the union of sdf and its mirror image across the plane
+// passing through planePoint with normal planeNormal, for building symmetric
+// parts from one half. Parts of sdf crossing the plane are kept on both sides.
+func Mirror3D(sdf SDF3, planePoint, planeNormal r3.Vec) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if r3.Norm(planeNormal) == 0 {
+		panic("zero plane normal")
+	}
+	s := mirror3{
+		sdf:    sdf,
+		point:  planePoint,
+		normal: r3.Unit(planeNormal),
+	}
+	v := d3.Box(sdf.Bounds()).Vertices()
+	s.bb = r3.Box{Min: v.Min(), Max: v.Max()}
+	for _, vertex := range v {
+		vertex = s.reflect(vertex)
+		s.bb.Min = d3.MinElem(s.bb.Min, vertex)
+		s.bb.Max = d3.MaxElem(s.bb.Max, vertex)
+	}
+	return &s
+}
+
+// reflect returns the mirror image of p across the plane.
+func (s *mirror3) reflect(p r3.Vec) r3.Vec {
+	return r3.Sub(p, r3.Scale(2*r3.Dot(r3.Sub(p, s.point), s.normal), s.normal))
+}
+
+// Evaluate returns the minimum distance to the mirrored SDF3.
+func (s *mirror3) Evaluate(p r3.Vec) float64 {
+	return math.Min(s.sdf.Evaluate(p), s.sdf.Evaluate(s.reflect(p)))
+}
+
+// Bounds returns the bounding box of the mirrored SDF3.
+func (s *mirror3) Bounds() r3.Box {
+	return s.bb
+}
+
 /* WIP
 
 // Connector3 defines a 3d connection point.
@@ -805,6 +1416,8 @@ type offset3 struct {
 }
 
 // Offset3D returns an SDF3 that offsets the distance function of another SDF3.
+// Positive offsets grow the solid and negative offsets erode it, shrinking its
+// bounding box down to the center of the original box.
 func Offset3D(sdf SDF3, offset float64) SDF3 {
 	s := offset3{
 		sdf:      sdf,
@@ -812,10 +1425,112 @@ func Offset3D(sdf SDF3, offset float64) SDF3 {
 	}
 	// bounding box
 	bb := d3.Box(sdf.Bounds())
-	s.bb = r3.Box(d3.NewBox(bb.Center(), r3.Add(bb.Size(), d3.Elem(2*offset))))
+	s.bb = r3.Box(d3.NewBox(bb.Center(), d3.MaxElem(r3.Add(bb.Size(), d3.Elem(2*offset)), r3.Vec{})))
 	return &s
 }
 
+// OffsetSafe3D returns sdf offset as by Offset3D, checking that the offset
+// leaves some solid and fitting the bounding box of eroded solids. For negative
+// offsets sdf is sampled on a grid within its bounding box, once on construction,
+// and the box is shrunk to the cells which may contain part of the eroded solid.
+// It returns an error if no part of the solid remains.
+//
+// Erosion removes features thinner than twice the offset, which pinches off the
+// parts they joined or removes small parts entirely. The offset field is still
+// valid there, but where a feature pinches off its surface shrinks to a point or
+// line, so meshes of the offset solid are expected to have self-intersections or
+// non-manifold edges at pinch points. When the sampled solid has a different
+// number of separate parts after erosion the offset solid is returned along with
+// an error wrapping ErrTopologyChanged, so callers may warn and carry on.
+// Growth merges features closer than twice the offset in the same way but is
+// not checked. The checks are only as good as the sampling: features smaller
+// than the sampling cells may be missed or reported as fully eroded.
+func OffsetSafe3D(sdf SDF3, offset float64) (SDF3, error) {
+	if sdf == nil {
+		return nil, errors.New("nil SDF3 argument")
+	}
+	if math.IsNaN(offset) || math.IsInf(offset, 0) {
+		return nil, errors.New("offset must be finite")
+	}
+	s := Offset3D(sdf, offset).(*offset3)
+	if offset >= 0 {
+		return s, nil
+	}
+	search := d3.Box(sdf.Bounds())
+	if cell := d3.Max(search.Size()); !(cell > 0) || math.IsInf(cell, 0) {
+		return nil, errors.New("cannot sample SDF3 with empty or infinite bounds")
+	}
+	// Fit the box to the eroded solid the same as AutoBox3D.
+	bb, found := fitBox3(s, search, 32)
+	if !found {
+		return nil, fmt.Errorf("offset %g erodes the whole solid", offset)
+	}
+	box := d3.Box(s.bb)
+	bb = d3.Box{Min: d3.MaxElem(bb.Min, box.Min), Max: d3.MinElem(bb.Max, box.Max)}
+	s.bb = r3.Box{Min: bb.Min, Max: d3.MaxElem(bb.Min, bb.Max)}
+	if before, after := offsetParts(sdf, offset, search, 32); before != after {
+		return s, fmt.Errorf("offset %g turns %d parts into %d: %w", offset, before, after, ErrTopologyChanged)
+	}
+	return s, nil
+}
+
+// offsetParts samples sdf at the cell centers of a grid within search and
+// returns the number of separate parts of the solid before and after it is
+// offset by offset. Parts are inside cells connected through their faces.
+func offsetParts(sdf SDF3, offset float64, search d3.Box, resolution int) (before, after int) {
+	size := search.Size()
+	cell := d3.Max(size) / float64(resolution)
+	var n V3i
+	for i, l := range [3]float64{size.X, size.Y, size.Z} {
+		n[i] = int(math.Max(1, math.Ceil(l/cell)))
+	}
+	d := make([]float64, n[0]*n[1]*n[2])
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := r3.Add(search.Min, r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+				d[(i*n[1]+j)*n[2]+k] = sdf.Evaluate(p)
+			}
+		}
+	}
+	return gridParts(d, n, 0), gridParts(d, n, offset)
+}
+
+// gridParts returns the number of face connected regions of
+// cells of the n sized grid d whose values are below level.
+func gridParts(d []float64, n V3i, level float64) int {
+	visited := make([]bool, len(d))
+	var stack []V3i
+	parts := 0
+	for start := range d {
+		if visited[start] || d[start] >= level {
+			continue
+		}
+		parts++
+		visited[start] = true
+		stack = append(stack[:0], V3i{start / (n[1] * n[2]), start / n[2] % n[1], start % n[2]})
+		for len(stack) > 0 {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for axis := 0; axis < 3; axis++ {
+				for _, step := range [2]int{-1, 1} {
+					nb := c
+					nb[axis] += step
+					if nb[axis] < 0 || nb[axis] >= n[axis] {
+						continue
+					}
+					idx := (nb[0]*n[1]+nb[1])*n[2] + nb[2]
+					if !visited[idx] && d[idx] < level {
+						visited[idx] = true
+						stack = append(stack, nb)
+					}
+				}
+			}
+		}
+	}
+	return parts
+}
+
 // Evaluate returns the minimum distance to an offset SDF3.
 func (s *offset3) Evaluate(p r3.Vec) float64 {
 	return s.sdf.Evaluate(p) - s.distance
@@ -826,6 +1541,264 @@ func (s *offset3) Bounds() r3.Box {
 	return s.bb
 }
 
+// variableRound3 offsets an SDF3 by a position dependent radius.
+type variableRound3 struct {
+	sdf    SDF3
+	radius func(r3.Vec) float64
+	bb     r3.Box
+}
+
+// VariableRound3D returns an SDF3 grown by a radius which varies with position,
+// i.e. sdf(p) - radius(p). Applied to an SDF3 which was eroded beforehand it
+// rounds its edges with a variable radius, as for tapered fillets.
+//
+// The result is only a valid distance bound if the gradient of radius is
+// smaller than 1 everywhere, otherwise rendering may miss parts of the surface.
+// Even then the field is approximate where radius varies quickly.
+// The bounding box is enlarged by the largest radius sampled on a grid spanning
+// it plus the most radius can grow between grid points with a gradient below 1.
+func VariableRound3D(sdf SDF3, radius func(r3.Vec) float64) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if radius == nil {
+		panic("nil radius function")
+	}
+	// Grow the bounding box until it contains the radius bounded on a grid
+	// spanning it. This converges when the radius gradient is below 1.
+	const grid = 16
+	base := d3.Box(sdf.Bounds())
+	bb := base
+	for i := 0; i < 32; i++ {
+		size := bb.Size()
+		// Every point of the box is within half a grid cell diagonal of a sample.
+		rmax := math.Inf(-1)
+		for j := 0; j <= grid; j++ {
+			for k := 0; k <= grid; k++ {
+				for l := 0; l <= grid; l++ {
+					p := r3.Add(bb.Min, r3.Vec{X: size.X * float64(j) / grid, Y: size.Y * float64(k) / grid, Z: size.Z * float64(l) / grid})
+					rmax = math.Max(rmax, radius(p))
+				}
+			}
+		}
+		rmax += r3.Norm(size) / (2 * grid)
+		next := base.Enlarge(d3.Elem(2 * math.Max(rmax, 0)))
+		if next.Size().X <= bb.Size().X*(1+1e-6) {
+			break
+		}
+		bb = next
+	}
+	return &variableRound3{
+		sdf:    sdf,
+		radius: radius,
+		bb:     r3.Box(bb),
+	}
+}
+
+// Evaluate returns the minimum distance to a variably rounded SDF3.
+func (s *variableRound3) Evaluate(p r3.Vec) float64 {
+	return s.sdf.Evaluate(p) - s.radius(p)
+}
+
+// BoundingBox returns the bounding box of a variably rounded SDF3.
+func (s *variableRound3) Bounds() r3.Box {
+	return s.bb
+}
+
+// bevelEdge3 chamfers a single edge of an SDF3.
+type bevelEdge3 struct {
+	sdf    SDF3
+	p1, p2 Plane // planes with unit normals.
+	size   float64
+	k      float64 // inverse norm of the sum of the plane normals.
+}
+
+// BevelEdge3D chamfers the edge of sdf where the faces lying on plane1 and plane2
+// meet. Plane normals must point out of the solid. A wedge along the line where
+// the planes intersect is subtracted from the solid, leaving the rest of the
+// solid untouched. For perpendicular faces size is the width of the chamfer
+// measured along each face.
+//
+// The wedge extends along the whole intersection line but lies within both
+// faces, so parts of the solid beyond either plane are not cut. The distance
+// field is approximate near the chamfer, as for other differences.
+func BevelEdge3D(sdf SDF3, plane1, plane2 Plane, size float64) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if size <= 0 {
+		panic("size <= 0")
+	}
+	if r3.Norm(plane1.Normal) == 0 || r3.Norm(plane2.Normal) == 0 {
+		panic("zero plane normal")
+	}
+	plane1.Normal = r3.Unit(plane1.Normal)
+	plane2.Normal = r3.Unit(plane2.Normal)
+	if r3.Norm(r3.Cross(plane1.Normal, plane2.Normal)) < epsilon {
+		panic("planes do not intersect at an edge")
+	}
+	return &bevelEdge3{
+		sdf:  sdf,
+		p1:   plane1,
+		p2:   plane2,
+		size: size,
+		k:    1 / r3.Norm(r3.Add(plane1.Normal, plane2.Normal)),
+	}
+}
+
+// Evaluate returns the minimum distance to the bevelled SDF3.
+func (s *bevelEdge3) Evaluate(p r3.Vec) float64 {
+	h1 := r3.Dot(r3.Sub(p, s.p1.Point), s.p1.Normal)
+	h2 := r3.Dot(r3.Sub(p, s.p2.Point), s.p2.Normal)
+	// The wedge lies above the chamfer plane, within size of both faces
+	// and behind both of them.
+	wedge := math.Max(-(h1+h2+s.size)*s.k, math.Max(-h1-s.size, -h2-s.size))
+	wedge = math.Max(wedge, math.Max(h1, h2))
+	return math.Max(s.sdf.Evaluate(p), -wedge)
+}
+
+// Bounds returns the bounding box of the bevelled SDF3.
+func (s *bevelEdge3) Bounds() r3.Box {
+	return s.sdf.Bounds()
+}
+
+// autoFillet3 rounds the sharp edges of an SDF3 found by sampling.
+type autoFillet3 struct {
+	sdf        SDF3
+	radius     float64
+	angle      float64
+	resolution int
+	stencil    []r3.Vec // offsets averaged to round edges.
+	// Sharpness of the nodes of a grid with cells of side cell from
+	// origin, 1 for nodes near sharp edges and 0 elsewhere. X varies fastest.
+	sharp  []float64
+	nodes  V3i
+	origin r3.Vec
+	cell   float64
+	bb     r3.Box
+}
+
+// AutoFillet3D rounds the edges of sdf where its faces meet at an angle larger than
+// angleThreshold, in radians, leaving gentler edges and smooth surfaces untouched, as
+// an automatic cleanup of sharp edges. angleThreshold is the angle between the normals
+// of the faces meeting at the edge, π/2 for the edges of a box. Both convex edges,
+// which are rounded off, and concave edges, which are filled in, are rounded.
+//
+// This is a heuristic approximation meant for meshing, not an exact distance field.
+// Sharp edges are found once on construction by sampling the gradient of sdf on a grid
+// with res cells along the longest side of its bounding box, marking grid nodes near
+// which the gradient turns by more than angleThreshold within radius. Near marked
+// nodes the field is blended towards the average of sdf over a sphere of radius
+// radius around each point, which leaves planar faces in place and rounds edges
+// with a radius of roughly radius. Cells should be smaller than radius.
+func AutoFillet3D(sdf SDF3, radius, angleThreshold float64, res int) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if radius <= 0 {
+		panic("radius <= 0")
+	}
+	if angleThreshold <= 0 || angleThreshold >= math.Pi {
+		panic("angleThreshold must be in (0, pi)")
+	}
+	if res < 1 {
+		panic("res < 1")
+	}
+	s := autoFillet3{
+		sdf:        sdf,
+		radius:     radius,
+		angle:      angleThreshold,
+		resolution: res,
+	}
+	// Axes and cube diagonals average linear fields exactly, keeping faces flat.
+	for i := 0; i < 27; i++ {
+		v := r3.Vec{X: float64(i%3 - 1), Y: float64(i/3%3 - 1), Z: float64(i/9 - 1)}
+		if n := r3.Norm(v); n == 1 || n > 1.5 {
+			s.stencil = append(s.stencil, r3.Scale(radius/n, v))
+		}
+	}
+	bb := d3.Box(sdf.Bounds())
+	s.cell = d3.Max(bb.Size()) / float64(res)
+	// Points within radius of the bounding box are also rounded.
+	grid := bb.Enlarge(d3.Elem(2 * (radius + s.cell)))
+	size := grid.Size()
+	s.origin = grid.Min
+	for i, v := range [3]float64{size.X, size.Y, size.Z} {
+		s.nodes[i] = int(math.Ceil(v/s.cell)) + 1
+	}
+	s.sharp = make([]float64, s.nodes[0]*s.nodes[1]*s.nodes[2])
+	minCos := math.Cos(angleThreshold)
+	eps := 1e-3 * math.Min(s.cell, radius)
+	near := 0.5*math.Sqrt(3)*s.cell + radius
+	for k := 0; k < s.nodes[2]; k++ {
+		for j := 0; j < s.nodes[1]; j++ {
+			for i := 0; i < s.nodes[0]; i++ {
+				p := r3.Add(s.origin, r3.Scale(s.cell, r3.Vec{X: float64(i), Y: float64(j), Z: float64(k)}))
+				if math.Abs(sdf.Evaluate(p)) > near {
+					continue
+				}
+				// Compare the gradients within radius of the node pairwise.
+				normals := make([]r3.Vec, 0, 1+len(s.stencil))
+				for _, offset := range append([]r3.Vec{{}}, s.stencil...) {
+					if g := EvaluateGradient(sdf, r3.Add(p, offset), eps); r3.Norm(g) > 0 {
+						normals = append(normals, r3.Unit(g))
+					}
+				}
+			pairs:
+				for a := range normals {
+					for b := a + 1; b < len(normals); b++ {
+						if r3.Dot(normals[a], normals[b]) < minCos {
+							s.sharp[i+s.nodes[0]*(j+s.nodes[1]*k)] = 1
+							break pairs
+						}
+					}
+				}
+			}
+		}
+	}
+	// Averaging moves the surface by less than radius.
+	s.bb = r3.Box(bb.Enlarge(d3.Elem(2 * radius)))
+	return &s
+}
+
+// sharpness returns the sharpness of the grid nodes interpolated trilinearly at p.
+func (s *autoFillet3) sharpness(p r3.Vec) float64 {
+	g := r3.Scale(1/s.cell, r3.Sub(p, s.origin))
+	if g.X < 0 || g.Y < 0 || g.Z < 0 || g.X >= float64(s.nodes[0]-1) || g.Y >= float64(s.nodes[1]-1) || g.Z >= float64(s.nodes[2]-1) {
+		return 0
+	}
+	i, j, k := int(g.X), int(g.Y), int(g.Z)
+	t := r3.Sub(g, r3.Vec{X: float64(i), Y: float64(j), Z: float64(k)})
+	node := func(di, dj, dk int) float64 {
+		return s.sharp[i+di+s.nodes[0]*(j+dj+s.nodes[1]*(k+dk))]
+	}
+	x00 := mix(node(0, 0, 0), node(1, 0, 0), t.X)
+	x10 := mix(node(0, 1, 0), node(1, 1, 0), t.X)
+	x01 := mix(node(0, 0, 1), node(1, 0, 1), t.X)
+	x11 := mix(node(0, 1, 1), node(1, 1, 1), t.X)
+	return mix(mix(x00, x10, t.Y), mix(x01, x11, t.Y), t.Z)
+}
+
+// Evaluate returns the approximate minimum distance to the filleted SDF3.
+func (s *autoFillet3) Evaluate(p r3.Vec) float64 {
+	d := s.sdf.Evaluate(p)
+	w := s.sharpness(p)
+	if w == 0 {
+		return d
+	}
+	avg := 0.0
+	for _, offset := range s.stencil {
+		avg += s.sdf.Evaluate(r3.Add(p, offset))
+	}
+	avg /= float64(len(s.stencil))
+	return mix(d, avg, w)
+}
+
+// Bounds returns the bounding box of the filleted SDF3.
+func (s *autoFillet3) Bounds() r3.Box {
+	return s.bb
+}
+
 // shell3 shells the surface of an existing SDF3.
 type shell3 struct {
 	sdf   SDF3    // parent sdf3
@@ -856,6 +1829,244 @@ func (s *shell3) Bounds() r3.Box {
 	return s.bb
 }
 
+// Lightweight3D hollows out sdf leaving a solid skin of thickness skinThickness
+// and fills the hollow with infill, such as a GradedGyroid3D lattice, which is the
+// usual way of lightening printed parts. A nil infill leaves the part hollow.
+// The result is the union of the skin, the difference of sdf and sdf eroded by
+// skinThickness, and the infill intersected with the eroded sdf. It is built
+// with the package's operators so it can be inspected and modified with Walk3D,
+// for example to blend the infill into the skin with SetBlend3D.
+//
+// The skin is only as thick as sdf is an exact distance field. Parts
+// thinner than twice skinThickness are left solid.
+func Lightweight3D(sdf SDF3, skinThickness float64, infill SDF3) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if skinThickness <= 0 {
+		panic("skinThickness <= 0")
+	}
+	interior := Offset3D(sdf, -skinThickness)
+	skin := Difference3D(sdf, interior)
+	if infill == nil {
+		return skin
+	}
+	return Union3D(skin, Intersect3D(interior, infill))
+}
+
+// solidify3 thickens the zero level of an SDF3 as a sheet.
+type solidify3 struct {
+	sdf       SDF3
+	direction r3.Vec  // as passed to Solidify3D.
+	offset    r3.Vec  // translation of the sheet.
+	delta     float64 // half thickness.
+	bb        r3.Box
+}
+
+// Solidify3D returns a solid of the given thickness around the zero level of sdf,
+// treated as a sheet, such as an imported open surface or the field of an open
+// contour, whose inside and outside are ill-defined. Unlike Shell3D only the
+// distance to the sheet is used, never its sign, and the sheet may be thickened
+// to one side. With a zero direction the solid extends thickness/2 to both sides
+// of the sheet. Otherwise the sheet is moved by thickness/2 along direction, so
+// the solid extends from the sheet by thickness towards direction.
+//
+// The sign of the result is exact but the solid is only as good as the distance
+// to the sheet: fields which are not distances, or whose magnitude does not
+// vanish where the sign flips, give holes or extra material. When thickening to
+// one side, parts of the sheet inclined to direction get thinner walls, by the
+// cosine of their inclination, and the open edges of the sheet are rounded.
+func Solidify3D(sdf SDF3, direction r3.Vec, thickness float64) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if thickness <= 0 {
+		panic("thickness <= 0")
+	}
+	s := solidify3{
+		sdf:       sdf,
+		direction: direction,
+		delta:     thickness / 2,
+	}
+	if r3.Norm(direction) > 0 {
+		s.offset = r3.Scale(s.delta, r3.Unit(direction))
+	}
+	bb := d3.Box(sdf.Bounds()).Translate(s.offset)
+	s.bb = r3.Box(bb.Enlarge(r3.Vec{X: thickness, Y: thickness, Z: thickness}))
+	return &s
+}
+
+// Evaluate returns the minimum distance to the solidified sheet.
+func (s *solidify3) Evaluate(p r3.Vec) float64 {
+	return math.Abs(s.sdf.Evaluate(r3.Sub(p, s.offset))) - s.delta
+}
+
+// Bounds returns the bounding box of the solidified sheet.
+func (s *solidify3) Bounds() r3.Box {
+	return s.bb
+}
+
+// draft3 tapers the walls of an SDF3 by scaling it horizontally with Z.
+type draft3 struct {
+	sdf      SDF3
+	angle    float64
+	partingZ float64
+	center   r2.Vec  // center of the horizontal scaling.
+	k        float64 // scale change per unit Z below the parting line.
+	bb       r3.Box
+}
+
+// AddDraft3D adds a draft of draftAngle radians to the vertical walls of sdf, as
+// needed to release molded parts, by scaling its horizontal cross sections about
+// the center of its bounding box by a factor which varies linearly with Z. The
+// part is unchanged at partingZ, widens below it and narrows above it. Walls on
+// the sides of the bounding box are drafted by draftAngle and walls closer to
+// the center by proportionally less. draftAngle must be positive and no larger
+// than 15 degrees, and the part must not narrow to nothing below its top.
+//
+// The field is an approximation of the distance, scaled down where the part
+// narrows so it remains a bound, and is intended for meshing.
+func AddDraft3D(sdf SDF3, draftAngle, partingZ float64) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if draftAngle <= 0 || draftAngle > math.Pi/12 {
+		panic("draftAngle must be in (0, 15°]")
+	}
+	bb := d3.Box(sdf.Bounds())
+	size := bb.Size()
+	halfWidth := 0.5 * math.Max(size.X, size.Y)
+	if halfWidth == 0 {
+		panic("sdf has no horizontal extent")
+	}
+	center := bb.Center()
+	s := draft3{
+		sdf:      sdf,
+		angle:    draftAngle,
+		partingZ: partingZ,
+		center:   r2.Vec{X: center.X, Y: center.Y},
+		k:        math.Tan(draftAngle) / halfWidth,
+	}
+	if s.scale(bb.Max.Z) <= 0 {
+		panic("part narrows to nothing below its top")
+	}
+	// The part is widest at the bottom or at the parting line.
+	f := math.Max(1, s.scale(bb.Min.Z))
+	s.bb = r3.Box{
+		Min: r3.Vec{X: center.X - f*size.X/2, Y: center.Y - f*size.Y/2, Z: bb.Min.Z},
+		Max: r3.Vec{X: center.X + f*size.X/2, Y: center.Y + f*size.Y/2, Z: bb.Max.Z},
+	}
+	return &s
+}
+
+// scale returns the horizontal scale factor at height z.
+func (s *draft3) scale(z float64) float64 {
+	return 1 + s.k*(s.partingZ-z)
+}
+
+// Evaluate returns the approximate minimum distance to the drafted SDF3.
+func (s *draft3) Evaluate(p r3.Vec) float64 {
+	f := s.scale(p.Z)
+	if f <= 0 {
+		// Above where the part narrows to nothing.
+		return p.Z - s.bb.Max.Z
+	}
+	q := r3.Vec{X: s.center.X + (p.X-s.center.X)/f, Y: s.center.Y + (p.Y-s.center.Y)/f, Z: p.Z}
+	return s.sdf.Evaluate(q) * math.Min(1, f)
+}
+
+// Bounds returns the bounding box of the drafted SDF3.
+func (s *draft3) Bounds() r3.Box {
+	return s.bb
+}
+
+// autoBox3 replaces the bounding box of an SDF3 by one found by sampling.
+type autoBox3 struct {
+	sdf        SDF3
+	search     r3.Box
+	resolution int
+	bb         r3.Box
+}
+
+// AutoBox3D returns sdf with a tight bounding box found by sampling it within
+// searchBox, for SDF3s whose bounding box is unknown, infinite or too large,
+// such as user defined SDF3s, so they can be meshed efficiently. The search box
+// is divided into cells of equal sides with resolution cells along its longest
+// side and the box returned is the smallest containing the cells that may contain
+// part of the solid, found once on construction.
+//
+// The box found is only as good as the resolution: it can be up to a cell larger than
+// the solid on each side, parts of the solid outside searchBox are cut off and parts
+// may be missed if sdf overestimates distances. It panics if no solid is found.
+func AutoBox3D(sdf SDF3, searchBox r3.Box, resolution int) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if resolution < 1 {
+		panic("resolution < 1")
+	}
+	search := d3.Box(searchBox)
+	size := search.Size()
+	if !(size.X > 0 && size.Y > 0 && size.Z > 0) || math.IsInf(d3.Max(size), 0) {
+		panic("search box must have a finite positive size")
+	}
+	bb, found := fitBox3(sdf, search, resolution)
+	if !found {
+		panic("no solid found in search box")
+	}
+	return &autoBox3{
+		sdf:        sdf,
+		search:     searchBox,
+		resolution: resolution,
+		bb:         r3.Box(bb),
+	}
+}
+
+// fitBox3 divides search into cells of equal sides with resolution cells along
+// its longest side and returns the smallest box containing the cells that may
+// contain part of the solid of sdf, clipped to search, and whether any was found.
+func fitBox3(sdf SDF3, search d3.Box, resolution int) (d3.Box, bool) {
+	size := search.Size()
+	cell := d3.Max(size) / float64(resolution)
+	n := V3i{int(math.Ceil(size.X / cell)), int(math.Ceil(size.Y / cell)), int(math.Ceil(size.Z / cell))}
+	// A cell may contain part of the solid if the distance from its
+	// center is smaller than the distance to its corners.
+	halfDiag := 0.5 * math.Sqrt(3) * cell
+	half := d3.Elem(cell / 2)
+	found := false
+	var bb d3.Box
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := r3.Add(search.Min, r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+				if sdf.Evaluate(p) >= halfDiag {
+					continue
+				}
+				c := d3.Box{Min: r3.Sub(p, half), Max: r3.Add(p, half)}
+				if found {
+					bb = bb.Extend(c)
+				} else {
+					bb, found = c, true
+				}
+			}
+		}
+	}
+	if !found {
+		return d3.Box{}, false
+	}
+	return d3.Box{Min: d3.MaxElem(bb.Min, search.Min), Max: d3.MinElem(bb.Max, search.Max)}, true
+}
+
+// Evaluate returns the minimum distance to the SDF3.
+func (s *autoBox3) Evaluate(p r3.Vec) float64 {
+	return s.sdf.Evaluate(p)
+}
+
+// Bounds returns the bounding box found by sampling.
+func (s *autoBox3) Bounds() r3.Box {
+	return s.bb
+}
+
 // LineOf3D returns a union of 3D objects positioned along a line from p0 to p1.
 func LineOf3D(s SDF3, p0, p1 r3.Vec, pattern string) SDF3 {
 	var objects []SDF3
@@ -888,6 +2099,53 @@ func Multi3D(s SDF3, positions d3.Set) SDF3 {
 	return Union3D(objects...)
 }
 
+// RandomPositions3D returns up to count random positions within box that are
+// at least minSpacing apart from each other, suitable for use with Multi3D.
+// Positions are generated by dart throwing which avoids the clumping of
+// uniformly distributed points. Fewer than count positions are returned
+// if the spacing can not be satisfied. The result is deterministic for a given seed.
+func RandomPositions3D(box r3.Box, count int, minSpacing float64, seed int64) d3.Set {
+	if count <= 0 {
+		return nil
+	}
+	size := d3.Box(box).Size()
+	if d3.LTZero(size) {
+		panic("invalid box")
+	}
+	rng := rand.New(rand.NewSource(seed))
+	positions := make(d3.Set, 0, count)
+	// Grid cells have a diagonal of minSpacing so they hold at most one position.
+	cell := minSpacing / math.Sqrt(3)
+	grid := make(map[[3]int]int)
+	key := func(p r3.Vec) [3]int {
+		q := r3.Scale(1/cell, r3.Sub(p, box.Min))
+		return [3]int{int(q.X), int(q.Y), int(q.Z)}
+	}
+	for tries := 0; len(positions) < count && tries < 30*count; tries++ {
+		p := r3.Add(box.Min, r3.Vec{X: rng.Float64() * size.X, Y: rng.Float64() * size.Y, Z: rng.Float64() * size.Z})
+		if minSpacing <= 0 {
+			positions = append(positions, p)
+			continue
+		}
+		k := key(p)
+		ok := true
+		// Positions closer than minSpacing are at most 2 cells away.
+		for i := -2; i <= 2 && ok; i++ {
+			for j := -2; j <= 2 && ok; j++ {
+				for l := -2; l <= 2 && ok; l++ {
+					idx, exists := grid[[3]int{k[0] + i, k[1] + j, k[2] + l}]
+					ok = !exists || r3.Norm(r3.Sub(p, positions[idx])) >= minSpacing
+				}
+			}
+		}
+		if ok {
+			grid[k] = len(positions)
+			positions = append(positions, p)
+		}
+	}
+	return positions
+}
+
 // Orient3D creates a union of an SDF3 at oriented directions.
 func Orient3D(s SDF3, base r3.Vec, directions d3.Set) SDF3 {
 	if s == nil {
@@ -903,6 +2161,107 @@ func Orient3D(s SDF3, base r3.Vec, directions d3.Set) SDF3 {
 	return Union3D(objects...)
 }
 
+// RepeatAlongCurve3D returns a union of count copies of feature placed along curve at
+// parameters evenly spaced from t=0 to t=1, both included. A single copy is placed
+// at t=0. Each copy is rotated so that the Z axis of feature points along the curve
+// tangent, which is estimated with finite differences. Use LineOf3D for straight
+// lines. For closed curves the copies at t=0 and t=1 coincide, so pass one more
+// than the number of copies wanted. If feature is exact the union culls far away
+// copies using their bounding spheres so long curves with many copies remain fast
+// to evaluate.
+func RepeatAlongCurve3D(feature SDF3, curve func(t float64) r3.Vec, count int) SDF3 {
+	if feature == nil {
+		panic("nil SDF3 argument")
+	}
+	if curve == nil {
+		panic("nil curve argument")
+	}
+	if count < 1 {
+		panic("count < 1")
+	}
+	const h = 1e-6
+	objects := make([]SDF3, count)
+	for i := range objects {
+		t := 0.0
+		if count > 1 {
+			t = float64(i) / float64(count-1)
+		}
+		t0, t1 := math.Max(t-h, 0), math.Min(t+h, 1)
+		tangent := r3.Sub(curve(t1), curve(t0))
+		m := Translate3D(curve(t)).Mul(rotateToVec(r3.Vec{Z: 1}, tangent))
+		objects[i] = Transform3D(feature, m)
+	}
+	if count == 1 {
+		return objects[0]
+	}
+	return Union3D(objects...)
+}
+
+// ScatterOnSurface3D returns the union of base and count copies of feature
+// placed on random points of the surface of base. Each copy has its Z axis
+// oriented along the surface normal at its position, so features should be
+// modelled standing on the XY plane pointing towards +Z.
+// The placement is deterministic for a given seed.
+func ScatterOnSurface3D(base, feature SDF3, count int, seed int64) SDF3 {
+	if base == nil || feature == nil {
+		panic("nil SDF3 argument")
+	}
+	if count <= 0 {
+		return base
+	}
+	bb := d3.Box(base.Bounds())
+	size := bb.Size()
+	eps := 1e-5 * r3.Norm(size)
+	rng := rand.New(rand.NewSource(seed))
+	objects := []SDF3{base}
+	// Some samples may fail to reach the surface, try a few more times.
+	for tries := 0; len(objects) <= count && tries < 16*count; tries++ {
+		p := r3.Add(bb.Min, r3.Vec{X: rng.Float64() * size.X, Y: rng.Float64() * size.Y, Z: rng.Float64() * size.Z})
+		p, ok := ProjectToSurface3D(base, p, eps, eps)
+		if !ok {
+			continue
+		}
+		n := normal3(base, p, eps)
+		m := Translate3D(p).Mul(rotateToVec(r3.Vec{Z: 1}, n))
+		objects = append(objects, Transform3D(feature, m))
+	}
+	if len(objects) == 1 {
+		return base
+	}
+	return Union3D(objects...)
+}
+
+// SurfacePoints3D returns about count points on the surface of sdf, as a light
+// weight alternative to meshing for previews. Points are sampled uniformly in
+// the bounding box and those within a thin band around the surface are kept
+// and projected onto it, so they are distributed roughly uniformly over the surface.
+// The points are deterministic for a given seed. Fewer points are returned
+// if the surface occupies a very small part of the bounding box.
+func SurfacePoints3D(sdf SDF3, count int, seed int64) []r3.Vec {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if count <= 0 {
+		return nil
+	}
+	bb := d3.Box(sdf.Bounds())
+	size := bb.Size()
+	band := 0.02 * r3.Norm(size)
+	eps := 1e-5 * r3.Norm(size)
+	rng := rand.New(rand.NewSource(seed))
+	points := make([]r3.Vec, 0, count)
+	for tries := 0; len(points) < count && tries < 256*count; tries++ {
+		p := r3.Add(bb.Min, r3.Vec{X: rng.Float64() * size.X, Y: rng.Float64() * size.Y, Z: rng.Float64() * size.Z})
+		if math.Abs(sdf.Evaluate(p)) > band {
+			continue
+		}
+		if p, ok := ProjectToSurface3D(sdf, p, eps, eps); ok {
+			points = append(points, p)
+		}
+	}
+	return points
+}
+
 func empty3From(s SDF3) empty3 {
 	return empty3{
 		center: d3.Box(s.Bounds()).Center(),
@@ -0,0 +1,1598 @@
+package sdf_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form2/must2"
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestBoundingSphere(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 2, Y: 4, Z: 4}, 0)
+	c, r := sdf.BoundingSphere(sdf.Transform3D(box, sdf.Translate3D(r3.Vec{X: 1})))
+	if c != (r3.Vec{X: 1}) || math.Abs(r-3) > 1e-12 {
+		t.Errorf("got center %v radius %g, want {1 0 0} and 3", c, r)
+	}
+}
+
+func TestUnionCull(t *testing.T) {
+	var objects []sdf.SDF3
+	for i := 0; i < 5; i++ {
+		objects = append(objects, sdf.Transform3D(must3.Sphere(0.5), sdf.Translate3D(r3.Vec{X: 2 * float64(i)})))
+	}
+	u := sdf.Union3D(objects...)
+	for x := -3.; x < 12; x += 0.37 {
+		p := r3.Vec{X: x, Y: 0.3 * x, Z: 0.1}
+		want := math.MaxFloat64
+		for _, obj := range objects {
+			want = math.Min(want, obj.Evaluate(p))
+		}
+		if got := u.Evaluate(p); got != want {
+			t.Errorf("union at %v: got %g, want %g", p, got, want)
+		}
+	}
+	// Objects which underestimate their distance are not culled unless asked to.
+	near := must3.Sphere(0.5)
+	far := scaledField{SDF3: sdf.Transform3D(must3.Sphere(0.5), sdf.Translate3D(r3.Vec{X: 10})), k: 0.1}
+	p := r3.Vec{X: 3}
+	u = sdf.Union3D(near, far)
+	if got, want := u.Evaluate(p), far.Evaluate(p); got != want {
+		t.Errorf("union of approximate objects: got %g, want %g", got, want)
+	}
+	u.(sdf.Culler).SetCull(true)
+	if got, want := u.Evaluate(p), near.Evaluate(p); got != want {
+		t.Errorf("culled union of approximate objects: got %g, want %g", got, want)
+	}
+}
+
+func TestScatterOnSurface(t *testing.T) {
+	const count = 12
+	base := must3.Sphere(2)
+	stud := must3.Box(r3.Vec{X: 0.2, Y: 0.2, Z: 0.6}, 0)
+	s := sdf.ScatterOnSurface3D(base, stud, count, 1)
+	var leaves int
+	var tips int
+	sdf.Walk3D(s, func(node sdf.SDF3, depth int) bool {
+		if depth == 1 {
+			leaves++
+		}
+		return true
+	})
+	if leaves != count+1 {
+		t.Fatalf("got %d union members, want %d", leaves, count+1)
+	}
+	// Every scattered copy is a leaf of the union.
+	for _, leaf := range sdf.EvaluateBreakdown(s, r3.Vec{}) {
+		if leaf.Type == "*must3.box" {
+			tips++
+		}
+	}
+	if tips != count {
+		t.Errorf("got %d studs, want %d", tips, count)
+	}
+	if d := s.Evaluate(r3.Vec{X: 2.5}); d > 0.5 {
+		t.Errorf("unexpected distance %g", d)
+	}
+	// Deterministic from seed.
+	s2 := sdf.ScatterOnSurface3D(base, stud, count, 1)
+	for x := 0.; x < 3; x += 0.1 {
+		p := r3.Vec{X: x, Y: 1.1 * x, Z: -x}
+		if s.Evaluate(p) != s2.Evaluate(p) {
+			t.Fatal("scatter not deterministic for same seed")
+		}
+	}
+}
+
+func TestRandomPositions(t *testing.T) {
+	box := r3.Box{Max: r3.Vec{X: 10, Y: 10, Z: 2}}
+	const spacing = 1.5
+	pos := sdf.RandomPositions3D(box, 40, spacing, 3)
+	if len(pos) != 40 {
+		t.Fatalf("got %d positions, want 40", len(pos))
+	}
+	for i, p := range pos {
+		if p.X < box.Min.X || p.Y < box.Min.Y || p.Z < box.Min.Z || p.X > box.Max.X || p.Y > box.Max.Y || p.Z > box.Max.Z {
+			t.Errorf("position %v outside box", p)
+		}
+		for _, q := range pos[i+1:] {
+			if r3.Norm(r3.Sub(p, q)) < spacing {
+				t.Errorf("positions %v and %v closer than %g", p, q, spacing)
+			}
+		}
+	}
+	again := sdf.RandomPositions3D(box, 40, spacing, 3)
+	for i := range pos {
+		if pos[i] != again[i] {
+			t.Fatal("positions not deterministic for same seed")
+		}
+	}
+	// A 1x1x1 box can't hold more than 8 points spaced by 1.
+	if n := len(sdf.RandomPositions3D(r3.Box{Max: r3.Vec{X: 1, Y: 1, Z: 1}}, 100, 1, 1)); n > 8 || n == 0 {
+		t.Errorf("got %d positions in unit box, want between 1 and 8", n)
+	}
+}
+
+func TestDifference3DSmooth(t *testing.T) {
+	const k = 0.2
+	box := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	hole := sdf.Transform3D(must3.Sphere(0.8), sdf.Translate3D(r3.Vec{Z: 1}))
+	hard := sdf.Difference3D(box, hole)
+	smooth := sdf.Difference3DSmooth(box, hole, k)
+	// Walk across the seam where the sphere cuts the top face of the box.
+	const h = 1e-3
+	prevSlope := math.NaN()
+	for x := 0.5; x < 1; x += h {
+		p := r3.Vec{X: x, Z: 1.05}
+		d := smooth.Evaluate(p)
+		if dh := hard.Evaluate(p); d < dh-1e-12 || d > dh+k/4+1e-12 {
+			t.Errorf("smooth difference %g at %v not within blend band of %g", d, p, dh)
+		}
+		slope := (smooth.Evaluate(r3.Vec{X: x + h, Z: 1.05}) - d) / h
+		if !math.IsNaN(prevSlope) && math.Abs(slope-prevSlope) > 0.05 {
+			t.Errorf("gradient kink at %v: %g -> %g", p, prevSlope, slope)
+		}
+		prevSlope = slope
+	}
+}
+
+func TestValidateField(t *testing.T) {
+	const eps, tol = 1e-6, 1e-6
+	sphere := must3.Sphere(1)
+	stretched := sdf.Transform3D(sphere, sdf.Scale3D(r3.Vec{X: 3, Y: 1, Z: 1}))
+	for _, p := range []r3.Vec{{X: 2}, {Y: 1.5, Z: 1}, {X: 0.5, Y: 0.1}} {
+		grad, ok := sdf.ValidateField(sphere, p, eps, tol)
+		if !ok || math.Abs(grad-1) > 1e-6 {
+			t.Errorf("sphere at %v: got gradient %g consistent=%t, want 1 and true", p, grad, ok)
+		}
+	}
+	// Distances of non-isometric transforms are wrong away from the axis of scaling.
+	grad, ok := sdf.ValidateField(stretched, r3.Vec{X: 4.5, Y: 1.5}, eps, tol)
+	if ok || grad >= 1 {
+		t.Errorf("stretched sphere: got gradient %g consistent=%t, want <1 and false", grad, ok)
+	}
+	// Uniformly scaled fields overshoot or fall short of the surface.
+	for _, k := range []float64{0.5, 2} {
+		scaled := scaledField{SDF3: sphere, k: k}
+		if grad, ok := sdf.ValidateField(scaled, r3.Vec{X: 2, Y: 1}, eps, tol); ok || math.Abs(grad-k) > 1e-6 {
+			t.Errorf("field scaled by %g: got gradient %g consistent=%t, want %g and false", k, grad, ok, k)
+		}
+	}
+}
+
+// scaledField multiplies the distance of an SDF3 by k.
+type scaledField struct {
+	sdf.SDF3
+	k float64
+}
+
+func (s scaledField) Evaluate(p r3.Vec) float64 { return s.k * s.SDF3.Evaluate(p) }
+
+func TestPerforate3D(t *testing.T) {
+	base := must3.Box(r3.Vec{X: 10, Y: 10, Z: 1}, 0)
+	hole := must3.Cylinder(2, 0.3, 0)
+	num := sdf.V3i{12, 12, 1}
+	step := r3.Vec{X: 0.8, Y: 0.8}
+	shift := sdf.Translate3D(r3.Vec{X: -4.4, Y: -4.4})
+	// The shifted hole does not fit its array cell so every hole is evaluated.
+	perforated := sdf.Perforate3D(base, sdf.Transform3D(hole, shift), num, step)
+	if perforated.Bounds() != base.Bounds() {
+		t.Errorf("bounds %v differ from base %v", perforated.Bounds(), base.Bounds())
+	}
+	// A centered hole fits its cell so only the closest holes are evaluated.
+	perforatedCentered := sdf.Transform3D(sdf.Perforate3D(sdf.Transform3D(base, sdf.Translate3D(r3.Vec{X: 4.4, Y: 4.4})), hole, num, step), shift)
+	reference := sdf.Difference3D(base, sdf.Transform3D(sdf.Array3D(hole, num, step), shift))
+	for x := -5.5; x < 5.5; x += 0.0731 {
+		for _, y := range []float64{-4.4, -1.37, 0, 0.43, 3.5} {
+			for _, z := range []float64{0, 0.49, 0.7} {
+				p := r3.Vec{X: x, Y: y, Z: z}
+				want := reference.Evaluate(p)
+				if got := perforated.Evaluate(p); math.Abs(got-want) > 1e-9 {
+					t.Fatalf("perforation at %v: got %g, want %g", p, got, want)
+				}
+				if got := perforatedCentered.Evaluate(p); math.Abs(got-want) > 1e-9 {
+					t.Fatalf("centered perforation at %v: got %g, want %g", p, got, want)
+				}
+			}
+		}
+	}
+	// With a single instance along Z each of the 2x2 closest holes is evaluated once.
+	counted := &countingSDF3{SDF3: hole}
+	perforated = sdf.Perforate3D(base, counted, num, step)
+	for _, p := range []r3.Vec{{X: 1.1, Y: 2.3}, {X: 0.1, Y: 8.7, Z: 0.4}, {X: 20, Y: -3}} {
+		counted.evaluations = 0
+		perforated.Evaluate(p)
+		if counted.evaluations > 4 {
+			t.Errorf("at %v got %d hole evaluations, want at most 4", p, counted.evaluations)
+		}
+	}
+}
+
+// countingSDF3 counts the evaluations of an SDF3. Not safe for concurrent use.
+type countingSDF3 struct {
+	sdf.SDF3
+	evaluations int
+}
+
+func (c *countingSDF3) Evaluate(p r3.Vec) float64 {
+	c.evaluations++
+	return c.SDF3.Evaluate(p)
+}
+
+func TestFitToBox3D(t *testing.T) {
+	s := sdf.Transform3D(must3.Box(r3.Vec{X: 2, Y: 1, Z: 4}, 0), sdf.Translate3D(r3.Vec{X: 5, Y: -3}))
+	target := r3.Box{Min: r3.Vec{X: 1, Y: 1, Z: 1}, Max: r3.Vec{X: 2, Y: 3, Z: 5}}
+	for _, test := range []struct {
+		uniform bool
+		want    r3.Box
+	}{
+		{uniform: false, want: target},
+		// Limited by X ratio of 1/2.
+		{uniform: true, want: r3.Box{Min: r3.Vec{X: 1, Y: 1.75, Z: 2}, Max: r3.Vec{X: 2, Y: 2.25, Z: 4}}},
+	} {
+		fit := sdf.FitToBox3D(s, target, test.uniform)
+		got := fit.Bounds()
+		if r3.Norm(r3.Sub(got.Min, test.want.Min)) > 1e-9 || r3.Norm(r3.Sub(got.Max, test.want.Max)) > 1e-9 {
+			t.Errorf("uniform=%t: got bounds %v, want %v", test.uniform, got, test.want)
+		}
+		// The fitted box surface lies on the bounds.
+		if d := fit.Evaluate(test.want.Max); math.Abs(d) > 1e-9 {
+			t.Errorf("uniform=%t: corner distance %g, want 0", test.uniform, d)
+		}
+	}
+}
+
+func TestNormal3DAdaptiveEpsilon(t *testing.T) {
+	const fixedEps = 1e-3
+	angleError := func(s sdf.SDF3, r, eps float64) float64 {
+		dir := r3.Unit(r3.Vec{X: 1, Y: 2, Z: 3})
+		n := sdf.Normal3D(s, r3.Scale(r, dir), eps)
+		return math.Acos(math.Min(1, r3.Dot(n, dir)))
+	}
+	var errs []float64
+	for _, r := range []float64{1e-4, 1e4} {
+		// Offset the sphere so that the normal is not exact by symmetry.
+		s := sdf.Union3D(must3.Sphere(r), sdf.Transform3D(must3.Sphere(r), sdf.Translate3D(r3.Vec{X: 1.8 * r})))
+		e := angleError(s, r, 0)
+		if e > 1e-6 {
+			t.Errorf("radius %g: adaptive epsilon normal error %g", r, e)
+		}
+		errs = append(errs, e)
+		// The gradient agrees with the normal.
+		p := r3.Scale(r, r3.Unit(r3.Vec{X: 1, Y: 2, Z: 3}))
+		if g := sdf.EvaluateGradient(s, p, 0); math.Abs(r3.Norm(g)-1) > 1e-6 {
+			t.Errorf("radius %g: gradient magnitude %g, want 1", r, r3.Norm(g))
+		}
+	}
+	// A fixed absolute epsilon larger than the small sphere's features fails.
+	small := sdf.Union3D(must3.Sphere(1e-4), sdf.Transform3D(must3.Sphere(1e-4), sdf.Translate3D(r3.Vec{X: 1.8e-4})))
+	if e := angleError(small, 1e-4, fixedEps); e < 100*errs[0] {
+		t.Errorf("fixed epsilon unexpectedly accurate on small sphere: %g", e)
+	}
+}
+
+func TestRevolveCapped3D(t *testing.T) {
+	profile := sdf.Transform2D(must2.Circle(0.5), sdf.Translate2D(r2.Vec{X: 2}))
+	quarter := sdf.RevolveCapped3D(profile, math.Pi/2)
+	threeQuarter := sdf.RevolveCapped3D(profile, 1.5*math.Pi)
+	for _, test := range []struct {
+		s    sdf.SDF3
+		p    r3.Vec
+		want float64
+	}{
+		{s: quarter, p: r3.Vec{X: 2}, want: 0},                      // on the first cut face
+		{s: quarter, p: r3.Vec{Y: 2.5}, want: 0},                    // on the edge of the second cut
+		{s: quarter, p: r3.Vec{X: 2, Y: -1}, want: 1},               // in front of the first face
+		{s: quarter, p: r3.Vec{X: 3, Y: -1}, want: math.Sqrt(1.25)}, // beyond the face rim
+		{s: quarter, p: r3.Vec{X: -1, Y: 2}, want: 1},               // in front of the second face
+		{s: quarter, p: r3.Vec{X: 2, Y: 0.1}, want: -0.1},           // inside near the first cut
+		{s: quarter, p: r3.Vec{}, want: 1.5},                        // on the axis
+		{s: quarter, p: r3.Vec{Z: 3}, want: math.Hypot(2, 3) - 0.5},
+		{s: quarter, p: r3.Vec{X: -2, Y: -2}, want: math.Hypot(3.5, 2)}, // opposite the wedge
+		{s: threeQuarter, p: r3.Vec{X: 2, Y: -0.5}, want: 0.5},
+		{s: threeQuarter, p: r3.Vec{X: -2}, want: -0.5}, // inside away from the cuts
+	} {
+		got := test.s.Evaluate(test.p)
+		if math.IsNaN(got) || math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("distance at %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+}
+
+func TestSphereUnion3D(t *testing.T) {
+	spheres := randomSpheres(500)
+	fast := sdf.SphereUnion3D(spheres)
+	generic := sphereUnionGeneric(spheres)
+	if fast.Bounds() != generic.Bounds() {
+		t.Errorf("bounds %v differ from generic union %v", fast.Bounds(), generic.Bounds())
+	}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 2000; i++ {
+		p := r3.Vec{X: 14*rng.Float64() - 2, Y: 14*rng.Float64() - 2, Z: 14*rng.Float64() - 2}
+		if got, want := fast.Evaluate(p), generic.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("distance at %v: got %g, want %g", p, got, want)
+		}
+	}
+}
+
+func TestSphereUnion3DOverlapping(t *testing.T) {
+	// Densely packed spheres overlap so points are within several of them.
+	rng := rand.New(rand.NewSource(3))
+	spheres := make([]sdf.Sphere, 300)
+	for i := range spheres {
+		spheres[i] = sdf.Sphere{
+			Center: r3.Vec{X: 4 * rng.Float64(), Y: 4 * rng.Float64(), Z: 4 * rng.Float64()},
+			Radius: 0.5 + rng.Float64(),
+		}
+	}
+	fast := sdf.SphereUnion3D(spheres)
+	generic := sphereUnionGeneric(spheres)
+	for i := 0; i < 10000; i++ {
+		p := r3.Vec{X: 7*rng.Float64() - 1.5, Y: 7*rng.Float64() - 1.5, Z: 7*rng.Float64() - 1.5}
+		if got, want := fast.Evaluate(p), generic.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("distance at %v: got %g, want %g", p, got, want)
+		}
+	}
+}
+
+func BenchmarkSphereUnion3D(b *testing.B) {
+	spheres := randomSpheres(1000)
+	rng := rand.New(rand.NewSource(2))
+	points := make([]r3.Vec, 1024)
+	for i := range points {
+		points[i] = r3.Vec{X: 10 * rng.Float64(), Y: 10 * rng.Float64(), Z: 10 * rng.Float64()}
+	}
+	b.Run("generic", func(b *testing.B) {
+		s := sphereUnionGeneric(spheres)
+		for i := 0; i < b.N; i++ {
+			s.Evaluate(points[i%len(points)])
+		}
+	})
+	b.Run("specialized", func(b *testing.B) {
+		s := sdf.SphereUnion3D(spheres)
+		for i := 0; i < b.N; i++ {
+			s.Evaluate(points[i%len(points)])
+		}
+	})
+}
+
+func randomSpheres(n int) []sdf.Sphere {
+	rng := rand.New(rand.NewSource(1))
+	spheres := make([]sdf.Sphere, n)
+	for i := range spheres {
+		spheres[i] = sdf.Sphere{
+			Center: r3.Vec{X: 10 * rng.Float64(), Y: 10 * rng.Float64(), Z: 10 * rng.Float64()},
+			Radius: 0.1 + 0.3*rng.Float64(),
+		}
+	}
+	return spheres
+}
+
+func sphereUnionGeneric(spheres []sdf.Sphere) sdf.SDF3 {
+	objects := make([]sdf.SDF3, len(spheres))
+	for i, sp := range spheres {
+		objects[i] = sdf.Transform3D(must3.Sphere(sp.Radius), sdf.Translate3D(sp.Center))
+	}
+	return sdf.Union3D(objects...)
+}
+
+func TestBoxSet3D(t *testing.T) {
+	if _, err := sdf.BoxSet3D(nil, 0); err == nil {
+		t.Error("expected error for no boxes")
+	}
+	unit := r3.Box{Max: r3.Vec{X: 1, Y: 1, Z: 1}}
+	if _, err := sdf.BoxSet3D([]r3.Box{unit}, -1); err == nil {
+		t.Error("expected error for negative round")
+	}
+	if _, err := sdf.BoxSet3D([]r3.Box{{Min: unit.Max, Max: unit.Min}}, 0); err == nil {
+		t.Error("expected error for inverted box")
+	}
+	// Compare against the generic union of many random boxes.
+	rng := rand.New(rand.NewSource(1))
+	boxes := make([]r3.Box, 500)
+	objects := make([]sdf.SDF3, len(boxes))
+	for i := range boxes {
+		min := r3.Vec{X: 10 * rng.Float64(), Y: 10 * rng.Float64(), Z: 10 * rng.Float64()}
+		size := r3.Vec{X: 0.1 + rng.Float64(), Y: 0.1 + rng.Float64(), Z: 0.1 + rng.Float64()}
+		boxes[i] = r3.Box{Min: min, Max: r3.Add(min, size)}
+		objects[i] = sdf.Transform3D(must3.Box(size, 0), sdf.Translate3D(r3.Add(min, r3.Scale(0.5, size))))
+	}
+	const round = 0.2
+	fast, err := sdf.BoxSet3D(boxes, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic := sdf.Offset3D(sdf.Union3D(objects...), round)
+	if fb, gb := fast.Bounds(), generic.Bounds(); r3.Norm(r3.Sub(fb.Min, gb.Min)) > 1e-9 || r3.Norm(r3.Sub(fb.Max, gb.Max)) > 1e-9 {
+		t.Errorf("bounds %v differ from generic union %v", fb, gb)
+	}
+	for i := 0; i < 2000; i++ {
+		p := r3.Vec{X: 14*rng.Float64() - 2, Y: 14*rng.Float64() - 2, Z: 14*rng.Float64() - 2}
+		if got, want := fast.Evaluate(p), generic.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("distance at %v: got %g, want %g", p, got, want)
+		}
+	}
+	// Touching boxes merge without a groove over the seam.
+	pair, err := sdf.BoxSet3D([]r3.Box{unit, {Min: r3.Vec{X: 1}, Max: r3.Vec{X: 2, Y: 1, Z: 1}}}, round)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, x := range []float64{0.9, 1, 1.1} {
+		if got := pair.Evaluate(r3.Vec{X: x, Y: 0.5, Z: 1.5}); math.Abs(got-(0.5-round)) > 1e-12 {
+			t.Errorf("got %g above seam at x=%g, want %g", got, x, 0.5-round)
+		}
+	}
+}
+
+func TestMinGap3D(t *testing.T) {
+	a := must3.Sphere(1)
+	for _, test := range []struct {
+		offset, want float64
+	}{
+		{offset: 1.5, want: 0.5},  // clearance
+		{offset: 0.8, want: -0.2}, // interference
+	} {
+		b := sdf.Transform3D(must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0), sdf.Translate3D(r3.Vec{X: test.offset + 0.5}))
+		if got := sdf.MinGap3D(a, b, 40); math.Abs(got-test.want) > 0.01 {
+			t.Errorf("offset %g: got gap %g, want %g", test.offset, got, test.want)
+		}
+	}
+}
+
+func TestVariableRound3D(t *testing.T) {
+	// Radius grows linearly along X with a gradient of 0.1.
+	radius := func(p r3.Vec) float64 { return 0.2 + 0.1*p.X }
+	s := sdf.VariableRound3D(must3.Sphere(1), radius)
+	for _, p := range []r3.Vec{{X: 2}, {X: -2}, {Y: 3}, {Z: -0.5}} {
+		want := r3.Norm(p) - 1 - radius(p)
+		if got := s.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Errorf("Evaluate(%v) = %g, want %g", p, got, want)
+		}
+	}
+	// The surface crosses the X axis at 1+radius(4/3) = 4/3.
+	bb := s.Bounds()
+	if bb.Max.X < 4./3-1e-6 {
+		t.Errorf("bounding box %v does not contain grown surface", bb)
+	}
+
+	// A radius peaking at the top face, away from the corners and center of the box.
+	peak := r3.Vec{X: 0.3, Y: -0.6, Z: 1}
+	radius = func(p r3.Vec) float64 { return math.Max(0, 0.4-0.5*r3.Norm(r3.Sub(p, peak))) }
+	s = sdf.VariableRound3D(must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0), radius)
+	// The surface crosses the vertical through the peak at z = 1 + 0.4/1.5.
+	if bb := s.Bounds(); bb.Max.Z < 1+0.4/1.5-1e-6 {
+		t.Errorf("bounding box %v does not contain grown surface", bb)
+	}
+}
+
+func TestImageRelief3D(t *testing.T) {
+	// Black left half and white right half.
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.SetGray(1, 0, color.Gray{Y: 255})
+	base := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	const depth = 0.2
+	s := sdf.ImageRelief3D(base, img, sdf.Plane{Point: r3.Vec{Z: 0.5}, Normal: r3.Vec{Z: 1}}, depth)
+	for _, test := range []struct {
+		p      r3.Vec
+		inside bool
+	}{
+		{p: r3.Vec{X: -0.25, Z: 0.45}, inside: true},  // black is not carved
+		{p: r3.Vec{X: 0.25, Z: 0.45}, inside: false},  // white is carved by depth
+		{p: r3.Vec{X: 0.25, Z: 0.25}, inside: true},   // below the carving
+		{p: r3.Vec{X: 0.25, Z: -0.45}, inside: true},  // opposite face untouched
+		{p: r3.Vec{X: -0.25, Z: 0.55}, inside: false}, // outside base
+	} {
+		if inside := s.Evaluate(test.p) < 0; inside != test.inside {
+			t.Errorf("%v: got inside=%v, want %v", test.p, inside, test.inside)
+		}
+	}
+	if got := s.Evaluate(r3.Vec{X: 0.25, Z: 0.5 - depth}); math.Abs(got) > 1e-12 {
+		t.Errorf("carved face distance %g, want 0", got)
+	}
+}
+
+func TestSurfacePoints3D(t *testing.T) {
+	s := must3.Sphere(2)
+	points := sdf.SurfacePoints3D(s, 500, 1)
+	if len(points) != 500 {
+		t.Fatalf("got %d points, want 500", len(points))
+	}
+	var mean r3.Vec
+	for _, p := range points {
+		if d := s.Evaluate(p); math.Abs(d) > 1e-4 {
+			t.Fatalf("point %v is %g from surface", p, d)
+		}
+		mean = r3.Add(mean, r3.Scale(1./float64(len(points)), p))
+	}
+	// Points covering the whole sphere average out near its center.
+	if r3.Norm(mean) > 0.3 {
+		t.Errorf("points are not spread over the surface, mean %v", mean)
+	}
+	again := sdf.SurfacePoints3D(s, 500, 1)
+	for i := range points {
+		if points[i] != again[i] {
+			t.Fatal("points differ for the same seed")
+		}
+	}
+}
+
+func TestBevelEdge3D(t *testing.T) {
+	// Chamfer the edge of a unit cube along Z at X=Y=0.5.
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	s := sdf.BevelEdge3D(box,
+		sdf.Plane{Point: r3.Vec{X: 0.5}, Normal: r3.Vec{X: 1}},
+		sdf.Plane{Point: r3.Vec{Y: 0.5}, Normal: r3.Vec{Y: 1}}, 0.2)
+	for _, test := range []struct {
+		p    r3.Vec
+		want float64
+	}{
+		{p: r3.Vec{X: 0.4, Y: 0.4}, want: 0},                 // on the chamfer
+		{p: r3.Vec{X: 0.45, Y: 0.45}, want: 0.05},            // cut away, bounded by the faces
+		{p: r3.Vec{X: 0.2, Y: 0.2}, want: -0.4 / math.Sqrt2}, // interior, closest to chamfer
+		{p: r3.Vec{X: -0.45, Y: 0.45}, want: -0.05},          // other edges untouched
+		{p: r3.Vec{X: 0.45, Y: 0.45, Z: 0.6}, want: 0.1},     // beyond top face
+	} {
+		if got := s.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("Evaluate(%v) = %g, want %g", test.p, got, test.want)
+		}
+	}
+	// Solid beyond the planes, such as a boss standing on the top face
+	// by the chamfered edge, is not cut.
+	base := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	boss := sdf.Transform3D(must3.Box(r3.Vec{X: 0.5, Y: 0.5, Z: 2}, 0), sdf.Translate3D(r3.Vec{Y: -0.75, Z: 2}))
+	bossed := sdf.BevelEdge3D(sdf.Union3D(base, boss),
+		sdf.Plane{Point: r3.Vec{Z: 1}, Normal: r3.Vec{Z: 1}},
+		sdf.Plane{Point: r3.Vec{Y: -1}, Normal: r3.Vec{Y: -1}}, 0.3)
+	if got := bossed.Evaluate(r3.Vec{Y: -0.95, Z: 2.5}); math.Abs(got+0.05) > 1e-12 {
+		t.Errorf("got %g inside boss above the edge, want -0.05", got)
+	}
+	if got := bossed.Evaluate(r3.Vec{X: 0.9, Y: -0.95, Z: 0.95}); got <= 0 {
+		t.Errorf("got %g at the chamfered edge beside the boss, want cut away", got)
+	}
+}
+
+func TestVolume3D(t *testing.T) {
+	const r = 1.0
+	want := 4. / 3 * math.Pi * r * r * r
+	sphere := sdf.Transform3D(must3.Sphere(r), sdf.Translate3D(r3.Vec{X: 0.1, Y: 0.2, Z: 0.3}))
+	for _, test := range []struct {
+		cells  int
+		relTol float64
+	}{
+		{cells: 8, relTol: 0.01}, // counting cell centers is off by several percent.
+		{cells: 40, relTol: 0.001},
+	} {
+		got := sdf.Volume3D(sphere, test.cells)
+		if math.Abs(got-want)/want > test.relTol {
+			t.Errorf("%d cells: volume %g, want %g", test.cells, got, want)
+		}
+	}
+}
+
+func TestTrim3D(t *testing.T) {
+	sphere := must3.Sphere(1)
+	// Trim to a slab 1 thick along Z and cut off the +X+Y corner diagonally.
+	s := sdf.Trim3D(sphere, []sdf.Plane{
+		{Point: r3.Vec{Z: 0.5}, Normal: r3.Vec{Z: 1}},
+		{Point: r3.Vec{Z: -0.5}, Normal: r3.Vec{Z: -2}},
+		{Point: r3.Vec{X: 0.5}, Normal: r3.Vec{X: 1, Y: 1}},
+	})
+	bb := s.Bounds()
+	want := r3.Box{Min: r3.Vec{X: -1, Y: -1, Z: -0.5}, Max: r3.Vec{X: 1, Y: 1, Z: 0.5}}
+	if !boxEqual(bb, want, 1e-12) {
+		t.Errorf("bounds %v, want %v", bb, want)
+	}
+	for _, test := range []struct {
+		p    r3.Vec
+		want float64
+	}{
+		{p: r3.Vec{Z: 0.7}, want: 0.2},
+		{p: r3.Vec{Z: -0.2}, want: -0.3},
+		{p: r3.Vec{X: 0.5}, want: 0},
+		{p: r3.Vec{X: -0.5}, want: -0.5},
+	} {
+		if got := s.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("Evaluate(%v) = %g, want %g", test.p, got, test.want)
+		}
+	}
+	// A cube trimmed by a diagonal plane through its center keeps a tight box.
+	cube := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	half := sdf.Trim3D(cube, []sdf.Plane{{Normal: r3.Vec{X: 1, Y: 1, Z: 1}}})
+	want = r3.Box{Min: r3.Vec{X: -1, Y: -1, Z: -1}, Max: r3.Vec{X: 1, Y: 1, Z: 1}}
+	if bb := half.Bounds(); !boxEqual(bb, want, 1e-12) {
+		t.Errorf("diagonal trim bounds %v, want %v", bb, want)
+	}
+	quarter := sdf.Trim3D(cube, []sdf.Plane{{Point: r3.Vec{X: 0.5}, Normal: r3.Vec{X: 1}}, {Normal: r3.Vec{Y: -1}}})
+	want = r3.Box{Min: r3.Vec{X: -1, Y: 0, Z: -1}, Max: r3.Vec{X: 0.5, Y: 1, Z: 1}}
+	if bb := quarter.Bounds(); !boxEqual(bb, want, 1e-12) {
+		t.Errorf("axis aligned trim bounds %v, want %v", bb, want)
+	}
+}
+
+func boxEqual(a, b r3.Box, tol float64) bool {
+	return r3.Norm(r3.Sub(a.Min, b.Min)) <= tol && r3.Norm(r3.Sub(a.Max, b.Max)) <= tol
+}
+
+func TestVoxelize3D(t *testing.T) {
+	// A box aligned to the voxel grid is reproduced by its voxels.
+	box := must3.Box(r3.Vec{X: 4, Y: 4, Z: 4}, 0)
+	blocky := sdf.Voxelize3D(box, 1, 0)
+	smooth := sdf.Voxelize3D(box, 1, 1)
+	half := sdf.Voxelize3D(box, 1, 0.5)
+	for _, test := range []struct {
+		p              r3.Vec
+		blocky, smooth float64
+	}{
+		// Voxel centers on either side of a face and the face between them.
+		{p: r3.Vec{X: 1.5, Y: 0.5, Z: 0.5}, blocky: -0.5, smooth: -0.5},
+		{p: r3.Vec{X: 2, Y: 0.5, Z: 0.5}, blocky: 0, smooth: 0},
+		{p: r3.Vec{X: 2.25, Y: 0.5, Z: 0.5}, blocky: 0.25, smooth: 0.25},
+		// Trilinear interpolation rounds off edges and corners.
+		{p: r3.Vec{X: 2, Y: 2, Z: 0.5}, blocky: 0, smooth: 0.25},
+		{p: r3.Vec{X: 2, Y: 2, Z: 2}, blocky: 0, smooth: 0.375},
+	} {
+		if got := blocky.Evaluate(test.p); math.Abs(got-test.blocky) > 1e-12 {
+			t.Errorf("smoothness 0 at %v: got %g, want %g", test.p, got, test.blocky)
+		}
+		if got := smooth.Evaluate(test.p); math.Abs(got-test.smooth) > 1e-12 {
+			t.Errorf("smoothness 1 at %v: got %g, want %g", test.p, got, test.smooth)
+		}
+		if got, want := half.Evaluate(test.p), (test.blocky+test.smooth)/2; math.Abs(got-want) > 1e-12 {
+			t.Errorf("smoothness 0.5 at %v: got %g, want %g", test.p, got, want)
+		}
+	}
+	// Voxels partially within the bounding box may be solid.
+	want := r3.Box{Min: r3.Vec{X: -3, Y: -3, Z: -3}, Max: r3.Vec{X: 3, Y: 3, Z: 3}}
+	if got := blocky.Bounds(); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	// The voxels of an off grid sphere lie within the bounds.
+	sphere := sdf.Transform3D(must3.Sphere(1.3), sdf.Translate3D(r3.Vec{X: 0.2, Y: -0.1, Z: 0.4}))
+	vox := sdf.Voxelize3D(sphere, 0.5, 0.3)
+	bb := vox.Bounds()
+	for x := bb.Min.X; x <= bb.Max.X; x += 0.1 {
+		for y := bb.Min.Y; y <= bb.Max.Y; y += 0.1 {
+			for _, z := range []float64{bb.Min.Z, bb.Max.Z} {
+				if d := vox.Evaluate(r3.Vec{X: x, Y: y, Z: z}); d <= 0 {
+					t.Fatalf("got %g on bounds at %v, want outside", d, r3.Vec{X: x, Y: y, Z: z})
+				}
+			}
+		}
+	}
+}
+
+func TestProxy3D(t *testing.T) {
+	exact := sdf.Transform3D(must3.Box(r3.Vec{X: 2, Y: 1, Z: 1}, 0.2), sdf.RotateZ(0.3))
+	proxy, err := sdf.Proxy3D(exact, sdf.V3i{20, 20, 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := r3.Norm(exact.Bounds().Max.Sub(exact.Bounds().Min)) / 20
+	rng := rand.New(rand.NewSource(1))
+	bb := proxy.Bounds()
+	size := r3.Sub(bb.Max, bb.Min)
+	for i := 0; i < 1000; i++ {
+		p := r3.Add(bb.Min, r3.Vec{X: rng.Float64() * size.X, Y: rng.Float64() * size.Y, Z: rng.Float64() * size.Z})
+		if d := exact.Evaluate(p); math.Abs(proxy.Evaluate(p)-d) > cell {
+			t.Fatalf("proxy differs from exact field at %v by more than a cell", p)
+		}
+	}
+	// Outside the grid the distance grows.
+	far := r3.Vec{X: 10}
+	if d := proxy.Evaluate(far); d < 8 {
+		t.Errorf("distance far from proxy %g, want > 8", d)
+	}
+	if _, err := sdf.Proxy3D(exact, sdf.V3i{0, 1, 1}); err == nil {
+		t.Error("expected error for bad grid dimensions")
+	}
+	if _, err := sdf.Proxy3D(sheetSDF3{}, sdf.V3i{4, 4, 4}); err == nil {
+		t.Error("expected error for flat bounding box")
+	}
+}
+
+func TestSymmetrize3D(t *testing.T) {
+	// A sphere slightly off the z-axis becomes a 4-fold symmetric blob.
+	off := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 0.1}))
+	sym := sdf.Symmetrize3D(off, 4)
+	p := r3.Vec{X: 0.7, Y: 0.3, Z: 0.2}
+	rotated := sdf.RotateZ(math.Pi / 2).MulPosition(p)
+	if d0, d1 := sym.Evaluate(p), sym.Evaluate(rotated); math.Abs(d0-d1) > 1e-12 {
+		t.Errorf("not symmetric: %g != %g", d0, d1)
+	}
+	// Averaging keeps the size of the shape, on axis it is unchanged.
+	if d := sym.Evaluate(r3.Vec{Z: 2}); math.Abs(d-off.Evaluate(r3.Vec{Z: 2})) > 1e-12 {
+		t.Errorf("distance on axis changed to %g", d)
+	}
+	// The intersection of the copies excludes points outside any of them.
+	sym.SetMax(math.Max)
+	q := r3.Vec{X: 1.05}
+	if d := sym.Evaluate(q); d <= 0 || off.Evaluate(q) > 0 {
+		t.Errorf("intersection of copies contains %v (d=%g)", q, d)
+	}
+	bb := sym.Bounds()
+	if bb.Min.X > -1.1 || bb.Max.Y < 1.1 {
+		t.Errorf("bounds %v do not contain rotated copies", bb)
+	}
+}
+
+func TestUnion3DKeepNil(t *testing.T) {
+	sphere := must3.Sphere(1)
+	at := func(x float64) sdf.SDF3 { return sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: x})) }
+	// Metadata parallel to the union arguments.
+	names := []string{"left", "missing", "right"}
+	u := sdf.Union3DKeepNil(at(-3), nil, at(3))
+	indexer := u.(sdf.IndexEvaluator)
+	for _, test := range []struct {
+		x    float64
+		want string
+	}{
+		{x: -3.5, want: "left"},
+		{x: 2, want: "right"},
+		{x: 10, want: "right"},
+	} {
+		p := r3.Vec{X: test.x}
+		d, i := indexer.EvaluateIndex(p)
+		if names[i] != test.want {
+			t.Errorf("x=%g: got %s, want %s", test.x, names[i], test.want)
+		}
+		if d != u.Evaluate(p) {
+			t.Errorf("x=%g: EvaluateIndex distance %g differs from Evaluate %g", test.x, d, u.Evaluate(p))
+		}
+	}
+	if _, i := sdf.Union3DKeepNil(nil, nil, sphere).(sdf.IndexEvaluator).EvaluateIndex(r3.Vec{}); i != 2 {
+		t.Errorf("single SDF3 union: got index %d, want 2", i)
+	}
+}
+
+type nanSDF3 struct{}
+
+func (nanSDF3) Evaluate(r3.Vec) float64 { return math.NaN() }
+func (nanSDF3) Bounds() r3.Box {
+	return r3.Box{Min: r3.Vec{X: -1, Y: -1, Z: -1}, Max: r3.Vec{X: 1, Y: 1, Z: 1}}
+}
+
+func TestDebugChecks(t *testing.T) {
+	defer func() { sdf.DebugChecks = false }()
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	for _, test := range []struct {
+		name string
+		s    sdf.SDF3
+	}{
+		{"union", sdf.Union3D(box, nanSDF3{})},
+		{"difference", sdf.Difference3D(box, nanSDF3{})},
+		{"intersection", sdf.Intersect3D(nanSDF3{}, box)},
+		{"transform", sdf.Transform3D(nanSDF3{}, sdf.Translate3D(r3.Vec{X: 1}))},
+	} {
+		sdf.DebugChecks = false
+		test.s.Evaluate(r3.Vec{}) // Must not panic.
+		sdf.DebugChecks = true
+		func() {
+			defer func() {
+				r := recover()
+				msg, _ := r.(string)
+				if !strings.Contains(msg, "nanSDF3") {
+					t.Errorf("%s: got panic %v, want it to name nanSDF3", test.name, r)
+				}
+			}()
+			test.s.Evaluate(r3.Vec{})
+		}()
+	}
+}
+
+func TestSilhouette2D(t *testing.T) {
+	// A box rotated about X seen along Z shows its rotated Y extent.
+	box := must3.Box(r3.Vec{X: 2, Y: 1, Z: 1}, 0)
+	rotated := sdf.Transform3D(box, sdf.RotateX(math.Pi/4))
+	s := sdf.Silhouette2D(rotated, 2, 64)
+	halfY := math.Sqrt2 / 2
+	for _, test := range []struct {
+		p      r2.Vec
+		inside bool
+	}{
+		{r2.Vec{}, true},
+		{r2.Vec{X: 0.9, Y: 0.65}, true},
+		{r2.Vec{X: 0.9, Y: halfY + 0.05}, false},
+		{r2.Vec{X: 1.05}, false},
+	} {
+		if got := s.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("point %v: got inside %t, want %t", test.p, got, test.inside)
+		}
+	}
+	bb := s.Bounds()
+	if math.Abs(bb.Max.X-1) > 1e-9 || math.Abs(bb.Max.Y-halfY) > 1e-9 {
+		t.Errorf("got bounds %v, want max {1 %g}", bb, halfY)
+	}
+	// Viewing along X the 2D axes are Y and Z.
+	sx := sdf.Silhouette2D(box, 0, 8)
+	if bb := sx.Bounds(); bb.Max != (r2.Vec{X: 0.5, Y: 0.5}) {
+		t.Errorf("got bounds %v along X, want max {0.5 0.5}", bb)
+	}
+}
+
+func TestGradedGyroid3D(t *testing.T) {
+	period := r3.Vec{X: 2, Y: 4, Z: 2}
+	thickness := func(p r3.Vec) float64 { return 0.1 + 0.02*p.X }
+	g := sdf.GradedGyroid3D(period, thickness)
+	// The origin lies on the gyroid surface.
+	if d := g.Evaluate(r3.Vec{}); math.Abs(d+0.05) > 1e-12 {
+		t.Errorf("got %g at origin, want -0.05", d)
+	}
+	// The lattice repeats with the period along each axis.
+	p := r3.Vec{X: 0.3, Y: 0.7, Z: 0.2}
+	for _, step := range []r3.Vec{{X: period.X}, {Y: period.Y}, {Z: period.Z}} {
+		q := r3.Add(p, step)
+		want := g.Evaluate(p) + (thickness(p)-thickness(q))/2
+		if got := g.Evaluate(q); math.Abs(got-want) > 1e-9 {
+			t.Errorf("got %g at %v, want %g", got, q, want)
+		}
+	}
+	// The sheet is thicker where thickness is larger.
+	cell := must3.Box(period, 0)
+	thin := sdf.Volume3D(sdf.Intersect3D(cell, g), 40)
+	thick := sdf.Volume3D(sdf.Intersect3D(sdf.Transform3D(cell, sdf.Translate3D(r3.Vec{X: 4})), g), 40)
+	if thick <= thin {
+		t.Errorf("got volume %g with thicker walls, want more than %g", thick, thin)
+	}
+	// Clipped lattices take the bounds of the part.
+	if bb := sdf.Intersect3D(cell, g).Bounds(); bb != cell.Bounds() {
+		t.Errorf("got bounds %v, want %v", bb, cell.Bounds())
+	}
+}
+
+func TestAreaProfile3D(t *testing.T) {
+	// A cone's area grows quadratically from its tip.
+	cone := must3.Cone(2, 1, 0, 0)
+	areas := sdf.AreaProfile3D(cone, 2, 4, 200)
+	for i, area := range areas {
+		z := -1 + 2*(float64(i)+0.5)/4
+		r := (1 - z) / 2
+		want := math.Pi * r * r
+		if math.Abs(area-want) > 0.02*want {
+			t.Errorf("station %d: got area %g, want %g", i, area, want)
+		}
+	}
+	box := must3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0)
+	for _, area := range sdf.AreaProfile3D(box, 0, 3, 50) {
+		if math.Abs(area-6) > 1e-9 {
+			t.Errorf("got box area %g along X, want 6", area)
+		}
+	}
+}
+
+func TestIsExactSDF3(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0.1)
+	sphere := must3.Sphere(1)
+	blended := sdf.Union3D(box, sphere)
+	blended.SetMin(sdf.MinPoly(2, 0.1))
+	for _, test := range []struct {
+		name string
+		s    sdf.SDF3
+		want bool
+	}{
+		{"box", box, true},
+		{"union", sdf.Union3D(box, sphere), true},
+		{"rigid", sdf.Transform3D(box, sdf.RotateZ(1).Mul(sdf.Translate3D(r3.Vec{X: 2}))), true},
+		{"uniform scale", sdf.ScaleUniform3D(sphere, 2), true},
+		{"shell", sdf.Shell3D(sdf.Union3D(box, sphere), 0.1), true},
+		{"blended union", blended, false},
+		{"scaled", sdf.Transform3D(box, sdf.Scale3D(r3.Vec{X: 2, Y: 1, Z: 1})), false},
+		{"difference", sdf.Difference3D(box, sphere), false},
+		{"approximate child", sdf.Union3D(box, sdf.Symmetrize3D(sphere, 2)), false},
+		{"not implemented", nanSDF3{}, false},
+	} {
+		if got := sdf.IsExactSDF3(test.s); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCylindricalText3D(t *testing.T) {
+	// A 2x1 rectangle standing in for text.
+	text := must2.Box(r2.Vec{X: 2, Y: 1}, 0)
+	const radius = 5
+	emboss, err := sdf.CylindricalText3D(text, radius, 0.5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engrave, err := sdf.CylindricalText3D(text, radius, 0.5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Half the text width is an arc of 1 at the radius.
+	edge := r3.Vec{X: radius * math.Cos(1./radius), Y: radius * math.Sin(1./radius)}
+	for _, test := range []struct {
+		s      sdf.SDF3
+		p      r3.Vec
+		inside bool
+	}{
+		{emboss, r3.Vec{X: radius + 0.25}, true},
+		{emboss, r3.Vec{X: radius - 0.25}, false},
+		{emboss, r3.Vec{X: radius + 0.25, Z: 0.6}, false},
+		{engrave, r3.Vec{X: radius - 0.25}, true},
+		{engrave, r3.Vec{X: radius + 0.25}, false},
+		{engrave, r3.Add(r3.Scale(0.95, edge), r3.Vec{Y: -0.05}), true},
+		{engrave, r3.Add(r3.Scale(0.95, edge), r3.Vec{Y: 0.05}), false},
+	} {
+		if got := test.s.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("point %v: got inside %t, want %t", test.p, got, test.inside)
+		}
+	}
+	// Beside the engraving distances around the cylinder are shorter than at its radius.
+	beside := r3.Vec{X: 4.6 * math.Cos(0.4), Y: 4.6 * math.Sin(0.4)}
+	if d, want := engrave.Evaluate(beside), 4.6*math.Sin(0.4-1./radius); d > 1.01*want {
+		t.Errorf("got %g beside engraving, overestimates distance %g", d, want)
+	}
+	bb := emboss.Bounds()
+	if bb.Max.X != radius+0.5 || math.Abs(bb.Max.Y-(radius+0.5)*math.Sin(1./radius)) > 1e-12 || bb.Max.Z != 0.5 {
+		t.Errorf("got bounds %v", bb)
+	}
+	for _, test := range []struct {
+		radius, depth float64
+		engrave       bool
+	}{
+		{0, 1, false},
+		{5, 0, false},
+		{0.5, 0.6, true},
+		{0.3, 0.1, false}, // text longer than circumference.
+	} {
+		if _, err := sdf.CylindricalText3D(text, test.radius, test.depth, test.engrave); err == nil {
+			t.Errorf("radius %g depth %g: expected error", test.radius, test.depth)
+		}
+	}
+}
+
+func TestCentroid3D(t *testing.T) {
+	// A cone's centroid lies at a quarter of its height from the base.
+	cone := must3.Cone(2, 1, 0, 0)
+	c := sdf.Centroid3D(cone, 40)
+	want := r3.Vec{Z: -0.5}
+	if r3.Norm(r3.Sub(c, want)) > 0.01 {
+		t.Errorf("got centroid %v, want %v", c, want)
+	}
+}
+
+func TestBalanceTrim3D(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 4, Y: 2, Z: 2}, 0)
+	target := r3.Vec{X: -0.2}
+	const samples = 40
+	trimmed := sdf.BalanceTrim3D(box, target, samples)
+	c := sdf.Centroid3D(trimmed, samples)
+	if r3.Norm(r3.Sub(c, target)) > 4./samples {
+		t.Errorf("got centroid %v, want %v", c, target)
+	}
+	// Pockets are inside the part.
+	for _, p := range []r3.Vec{{X: 2}, {X: 1.96, Y: 0.5}, {X: 1.5, Y: 1}, {X: 1.5, Z: -1}} {
+		if d, want := trimmed.Evaluate(p), box.Evaluate(p); math.Abs(d-want) > 1e-9 {
+			t.Errorf("got %g at %v on the surface, want %g", d, p, want)
+		}
+	}
+	if trimmed.Evaluate(r3.Vec{X: 1.7}) < 0 {
+		t.Error("heavy side was not pocketed")
+	}
+	// A balanced part is not modified.
+	if sdf.BalanceTrim3D(box, r3.Vec{}, samples) != sdf.SDF3(box) {
+		t.Error("balanced part was modified")
+	}
+}
+
+func TestRepeatAlongCurve3D(t *testing.T) {
+	// Cylinders along a circle of radius 5 lie tangent to it.
+	const radius = 5
+	circle := func(t float64) r3.Vec {
+		return r3.Vec{X: radius * math.Cos(math.Pi*t), Y: radius * math.Sin(math.Pi*t)}
+	}
+	beads := sdf.RepeatAlongCurve3D(must3.Cylinder(1, 0.2, 0), circle, 5)
+	for i := 0; i < 5; i++ {
+		theta := math.Pi * float64(i) / 4
+		on := r3.Vec{X: radius * math.Cos(theta), Y: radius * math.Sin(theta)}
+		tangent := r3.Vec{X: -math.Sin(theta), Y: math.Cos(theta)}
+		if d := beads.Evaluate(on); math.Abs(d+0.2) > 1e-9 {
+			t.Errorf("bead %d: got %g at center, want -0.2", i, d)
+		}
+		if d := beads.Evaluate(r3.Add(on, r3.Scale(0.45, tangent))); d >= 0 {
+			t.Errorf("bead %d: got %g along tangent, want inside", i, d)
+		}
+		if d := beads.Evaluate(r3.Add(on, r3.Vec{Z: 0.45})); d <= 0 {
+			t.Errorf("bead %d: got %g off tangent, want outside", i, d)
+		}
+	}
+	single := sdf.RepeatAlongCurve3D(must3.Sphere(1), circle, 1)
+	if d := single.Evaluate(r3.Vec{X: radius}); math.Abs(d+1) > 1e-9 {
+		t.Errorf("got %g at single copy, want -1", d)
+	}
+}
+
+func TestNumberedParts3D(t *testing.T) {
+	base := must3.Box(r3.Vec{X: 10, Y: 10, Z: 2}, 0)
+	// Labels are bars as wide as the number of characters.
+	var texts []string
+	label := func(text string) (sdf.SDF2, error) {
+		texts = append(texts, text)
+		return must2.Box(r2.Vec{X: float64(len(text)), Y: 1}, 0), nil
+	}
+	face := sdf.Plane{Point: r3.Vec{Z: 1}, Normal: r3.Vec{Z: 1}}
+	parts, err := sdf.NumberedParts3D(base, label, "SN", 9, 2, face, 2, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 || texts[0] != "SN9" || texts[1] != "SN10" {
+		t.Fatalf("got %d parts with labels %q", len(parts), texts)
+	}
+	// "SN9" is scaled to 6x2 and "SN10" to 8x2.
+	for i, halfWidth := range []float64{3, 4} {
+		for _, test := range []struct {
+			p      r3.Vec
+			inside bool
+		}{
+			{r3.Vec{X: halfWidth - 0.1, Y: 0.9, Z: 0.6}, false},
+			{r3.Vec{X: halfWidth - 0.1, Y: 0.9, Z: 0.4}, true},
+			{r3.Vec{X: halfWidth + 0.1, Z: 0.9}, true},
+			{r3.Vec{Y: 1.1, Z: 0.9}, true},
+		} {
+			if got := parts[i].Evaluate(test.p) < 0; got != test.inside {
+				t.Errorf("part %d point %v: got inside %t, want %t", i, test.p, got, test.inside)
+			}
+		}
+	}
+	if _, err := sdf.NumberedParts3D(base, label, "", 0, 0, face, 2, 0.5); err == nil {
+		t.Error("expected error for zero count")
+	}
+}
+
+func TestGenerateFromJSON(t *testing.T) {
+	generators := map[string]func(map[string]float64) (sdf.SDF3, error){
+		"sphere": func(params map[string]float64) (sdf.SDF3, error) {
+			if err := sdf.RequireParams(params, "radius"); err != nil {
+				return nil, err
+			}
+			return must3.Sphere(params["radius"]), nil
+		},
+		"box": func(params map[string]float64) (sdf.SDF3, error) {
+			if err := sdf.RequireParams(params, "x", "y", "z"); err != nil {
+				return nil, err
+			}
+			return must3.Box(r3.Vec{X: params["x"], Y: params["y"], Z: params["z"]}, 0), nil
+		},
+	}
+	s, err := sdf.GenerateFromJSON(strings.NewReader(`{"generator": "sphere", "params": {"radius": 2}}`), generators)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Evaluate(r3.Vec{}); d != -2 {
+		t.Errorf("got %g at center, want -2", d)
+	}
+	for _, test := range []struct {
+		spec string
+		want string
+	}{
+		{`{"generator": "cone"}`, `unknown generator "cone", available generators: box, sphere`},
+		{`{"params": {"radius": 2}}`, "part specification missing generator"},
+		{`{"generator": "box", "params": {"x": 1}}`, `generator "box": missing parameters: y, z`},
+		{`{"generator": "sphere", "params": {"radius": "big"}}`, "decoding part specification"},
+		{`{"generator": "sphere", "parameters": {"radius": 2}}`, "decoding part specification"},
+	} {
+		_, err := sdf.GenerateFromJSON(strings.NewReader(test.spec), generators)
+		if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+			t.Errorf("spec %s: got error %v, want %q", test.spec, err, test.want)
+		}
+	}
+}
+
+// sheetSDF3 is the unsigned distance to a unit square sheet on the XY plane.
+type sheetSDF3 struct{}
+
+func (sheetSDF3) Evaluate(p r3.Vec) float64 {
+	q := r3.Vec{X: math.Max(math.Abs(p.X)-0.5, 0), Y: math.Max(math.Abs(p.Y)-0.5, 0), Z: p.Z}
+	return r3.Norm(q)
+}
+
+func (sheetSDF3) Bounds() r3.Box {
+	return r3.Box{Min: r3.Vec{X: -0.5, Y: -0.5}, Max: r3.Vec{X: 0.5, Y: 0.5}}
+}
+
+func TestSolidify3D(t *testing.T) {
+	symmetric := sdf.Solidify3D(sheetSDF3{}, r3.Vec{}, 0.2)
+	up := sdf.Solidify3D(sheetSDF3{}, r3.Vec{Z: 3}, 0.2)
+	for _, test := range []struct {
+		s    sdf.SDF3
+		p    r3.Vec
+		want float64
+	}{
+		{symmetric, r3.Vec{}, -0.1},
+		{symmetric, r3.Vec{Z: -0.3}, 0.2},
+		{symmetric, r3.Vec{X: 0.7}, 0.1},
+		{up, r3.Vec{Z: 0.1}, -0.1},
+		{up, r3.Vec{Z: 0.25}, 0.05},
+		{up, r3.Vec{Z: -0.05}, 0.05},
+	} {
+		if got := test.s.Evaluate(test.p); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("point %v: got %g, want %g", test.p, got, test.want)
+		}
+	}
+	if bb := up.Bounds(); math.Abs(bb.Min.Z) > 1e-12 || math.Abs(bb.Max.Z-0.2) > 1e-12 {
+		t.Errorf("got bounds %v, want Z from 0 to 0.2", bb)
+	}
+}
+
+func TestLightweight3D(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 4, Y: 4, Z: 4}, 0)
+	// Infill of slabs normal to X.
+	slabs := sdf.Transform3D(sdf.Array3D(must3.Box(r3.Vec{X: 0.2, Y: 10, Z: 10}, 0), sdf.V3i{5, 1, 1}, r3.Vec{X: 1}),
+		sdf.Translate3D(r3.Vec{X: -2}))
+	light := sdf.Lightweight3D(box, 0.3, slabs)
+	hollow := sdf.Lightweight3D(box, 0.3, nil)
+	for _, test := range []struct {
+		name   string
+		s      sdf.SDF3
+		p      r3.Vec
+		inside bool
+	}{
+		{"skin", light, r3.Vec{X: 1.85, Y: 0.5}, true},
+		{"infill", light, r3.Vec{Y: 0.5}, true},
+		{"void", light, r3.Vec{X: 0.5, Y: 0.5}, false},
+		{"outside", light, r3.Vec{X: 2.05}, false},
+		{"hollow skin", hollow, r3.Vec{Z: -1.85}, true},
+		{"hollow void", hollow, r3.Vec{}, false},
+	} {
+		if got := test.s.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("%s: got inside %t at %v, want %t", test.name, got, test.p, test.inside)
+		}
+	}
+}
+
+func TestAddDraft3D(t *testing.T) {
+	const angle = 5 * math.Pi / 180
+	box := must3.Box(r3.Vec{X: 2, Y: 4, Z: 2}, 0)
+	drafted := sdf.AddDraft3D(box, angle, 0)
+	// The wall on the widest side of the box is drafted by angle.
+	tan := math.Tan(angle)
+	for _, z := range []float64{-0.9, -0.5, 0, 0.5, 0.9} {
+		wall := 2 - tan*z
+		if d := drafted.Evaluate(r3.Vec{Y: wall - 1e-6, Z: z}); d >= 0 {
+			t.Errorf("z=%g: got %g inside wall at y=%g", z, d, wall)
+		}
+		if d := drafted.Evaluate(r3.Vec{Y: wall + 1e-6, Z: z}); d <= 0 {
+			t.Errorf("z=%g: got %g outside wall at y=%g", z, d, wall)
+		}
+	}
+	bb := drafted.Bounds()
+	if want := 2 + tan; math.Abs(bb.Max.Y-want) > 1e-12 || math.Abs(bb.Max.Z-1) > 1e-12 {
+		t.Errorf("got bounds %v, want max Y %g and max Z 1", bb, want)
+	}
+	if kind, params := drafted.(sdf.SDF3Describer).Describe(); kind != "draft" || params["angle"] != angle {
+		t.Errorf("got description %s %v", kind, params)
+	}
+}
+
+// unboundedSDF3 is an SDF3 with an infinite bounding box.
+type unboundedSDF3 struct{ sdf.SDF3 }
+
+func (unboundedSDF3) Bounds() r3.Box {
+	inf := math.Inf(1)
+	return r3.Box{Min: r3.Vec{X: -inf, Y: -inf, Z: -inf}, Max: r3.Vec{X: inf, Y: inf, Z: inf}}
+}
+
+func TestAutoBox3D(t *testing.T) {
+	const res = 50
+	sphere := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 2, Y: -1, Z: 0.5}))
+	search := r3.Box{Min: r3.Vec{X: -5, Y: -5, Z: -5}, Max: r3.Vec{X: 5, Y: 5, Z: 5}}
+	boxed := sdf.AutoBox3D(unboundedSDF3{sphere}, search, res)
+	want := sphere.Bounds()
+	got := boxed.Bounds()
+	// The box contains the sphere and exceeds it by less than a cell.
+	cell := 10. / res
+	for _, d := range []float64{want.Min.X - got.Min.X, want.Min.Y - got.Min.Y, want.Min.Z - got.Min.Z,
+		got.Max.X - want.Max.X, got.Max.Y - want.Max.Y, got.Max.Z - want.Max.Z} {
+		if d < 0 || d > cell+1e-9 {
+			t.Errorf("got bounds %v for sphere bounds %v", got, want)
+			break
+		}
+	}
+	if p := (r3.Vec{X: 2.3}); boxed.Evaluate(p) != sphere.Evaluate(p) {
+		t.Error("distance modified")
+	}
+	// Parts outside the search box are cut off.
+	clipped := sdf.AutoBox3D(unboundedSDF3{sphere}, r3.Box{Min: search.Min, Max: r3.Vec{X: 2, Y: 5, Z: 5}}, res)
+	if bb := clipped.Bounds(); bb.Max.X != 2 {
+		t.Errorf("got clipped bounds %v, want max X 2", bb)
+	}
+}
+
+func TestRailSweep3D(t *testing.T) {
+	// Section spanning from X=0 to X=1 and a quarter of its width to both sides of Y=0.
+	profile := sdf.Transform2D(must2.Box(r2.Vec{X: 1, Y: 0.5}, 0), sdf.Translate2D(r2.Vec{X: 0.5}))
+	// Rails widening from 2 to 4 while rising 10 along Z. Sections are horizontal.
+	sweep, err := sdf.RailSweep3D(profile, []r3.Vec{{}, {Z: 10}}, []r3.Vec{{X: 2}, {X: 4, Z: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p    r3.Vec
+		want float64
+	}{
+		{p: r3.Vec{X: 1.5, Y: 0.75 + 0.1, Z: 5}, want: 0.1},
+		{p: r3.Vec{X: 3.2, Z: 5}, want: 0.2},
+		{p: r3.Vec{X: 1.5, Z: 5}, want: -0.75},
+		{p: r3.Vec{X: 2, Z: 10.5}, want: 0.5},
+		{p: r3.Vec{X: 1, Z: -0.25}, want: 0.25},
+	} {
+		// The field is scaled down where the rails diverge.
+		if got := sweep.Evaluate(test.p); math.Abs(got) > math.Abs(test.want)+1e-9 ||
+			math.Abs(got) < math.Abs(test.want)/1.2 || (got < 0) != (test.want < 0) {
+			t.Errorf("point %v: got %g, want %g scaled down by less than 1.2", test.p, got, test.want)
+		}
+	}
+	bb := sweep.Bounds()
+	if bb.Min.X > 0 || bb.Max.X < 4 || bb.Min.Y > -1 || bb.Max.Y < 1 || bb.Min.Z > 0 || bb.Max.Z < 10 {
+		t.Errorf("bounds %v do not contain the sweep", bb)
+	}
+	// Sweep around a quarter turn about Z between arcs of radii 3 and 5.
+	var rail0, rail1 []r3.Vec
+	for i := 0; i <= 16; i++ {
+		sin, cos := math.Sincos(float64(i) / 16 * math.Pi / 2)
+		rail0 = append(rail0, r3.Vec{X: 3 * cos, Y: 3 * sin})
+		rail1 = append(rail1, r3.Vec{X: 5 * cos, Y: 5 * sin})
+	}
+	bend, err := sdf.RailSweep3D(profile, rail0, rail1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		p      r3.Vec
+		inside bool
+	}{
+		{p: r3.Vec{X: 4 * math.Cos(0.7), Y: 4 * math.Sin(0.7)}, inside: true},
+		{p: r3.Vec{X: 4 * math.Cos(0.7), Y: 4 * math.Sin(0.7), Z: 0.4}, inside: true},
+		{p: r3.Vec{X: 4 * math.Cos(0.7), Y: 4 * math.Sin(0.7), Z: 0.6}, inside: false},
+		{p: r3.Vec{X: 2.8 * math.Cos(0.7), Y: 2.8 * math.Sin(0.7)}, inside: false},
+		{p: r3.Vec{X: 4, Y: -0.1}, inside: false},
+	} {
+		if got := bend.Evaluate(test.p) < 0; got != test.inside {
+			t.Errorf("bend point %v: got inside %t, want %t", test.p, got, test.inside)
+		}
+	}
+	// Twisted sweep rising and widening through the bend. The
+	// field must not overestimate distances for meshing.
+	for i := range rail0 {
+		rail0[i].Z = float64(i) / 4
+		rail1[i] = r3.Add(rail1[i], r3.Vec{X: rail1[i].X / 40 * float64(i), Y: rail1[i].Y / 40 * float64(i), Z: float64(i) / 3})
+	}
+	twisted, err := sdf.RailSweep3D(profile, rail0, rail1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	bb = twisted.Bounds()
+	size := r3.Sub(bb.Max, bb.Min)
+	for i := 0; i < 20000; i++ {
+		p := r3.Add(bb.Min, r3.Vec{X: rng.Float64() * size.X, Y: rng.Float64() * size.Y, Z: rng.Float64() * size.Z})
+		d := twisted.Evaluate(p)
+		if math.Abs(d) > 0.5 {
+			continue
+		}
+		step := r3.Scale(1e-3, r3.Unit(r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}))
+		if slope := math.Abs(twisted.Evaluate(r3.Add(p, step))-d) / 1e-3; slope > 1.01 {
+			t.Fatalf("field changes by %g times the distance near %v", slope, p)
+		}
+	}
+	if _, err := sdf.RailSweep3D(profile, rail0, rail1[1:]); err == nil {
+		t.Error("expected error for rails of different lengths")
+	}
+	if _, err := sdf.RailSweep3D(profile, rail0[:1], rail1[:1]); err == nil {
+		t.Error("expected error for single station")
+	}
+}
+
+func TestOffsetSafe3D(t *testing.T) {
+	// A thin rod sticking out of a sphere is eroded first.
+	rod := sdf.Transform3D(must3.Box(r3.Vec{X: 6, Y: 0.4, Z: 0.4}, 0), sdf.Translate3D(r3.Vec{X: 4}))
+	s := sdf.Union3D(must3.Sphere(2), rod)
+	eroded, err := sdf.OffsetSafe3D(s, -0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The box fits the eroded sphere within a sampling cell.
+	bb := eroded.Bounds()
+	if bb.Min.X > -1.5 || bb.Max.X < 1.5 || bb.Max.X > 2 {
+		t.Errorf("got bounds %v, want X from -1.5 to 1.5", bb)
+	}
+	if d := eroded.Evaluate(r3.Vec{X: 4}); d <= 0 {
+		t.Errorf("rod not eroded: got %g", d)
+	}
+	if _, err := sdf.OffsetSafe3D(s, -2.1); err == nil {
+		t.Error("expected error eroding the whole solid")
+	}
+	// Eroding a small part entirely or pinching a dumbbell in two is reported.
+	small := sdf.Transform3D(must3.Sphere(0.5), sdf.Translate3D(r3.Vec{X: 4}))
+	dumbbell := sdf.Union3D(
+		sdf.Transform3D(must3.Sphere(1.5), sdf.Translate3D(r3.Vec{X: -3})),
+		sdf.Transform3D(must3.Sphere(1.5), sdf.Translate3D(r3.Vec{X: 3})),
+		must3.Box(r3.Vec{X: 6, Y: 0.4, Z: 0.4}, 0),
+	)
+	for _, test := range []struct {
+		name string
+		s    sdf.SDF3
+	}{
+		{"vanished part", sdf.Union3D(must3.Sphere(2), small)},
+		{"pinched part", dumbbell},
+	} {
+		got, err := sdf.OffsetSafe3D(test.s, -0.6)
+		if !errors.Is(err, sdf.ErrTopologyChanged) {
+			t.Errorf("%s: got error %v, want ErrTopologyChanged", test.name, err)
+		}
+		if got == nil {
+			t.Errorf("%s: got nil SDF3 along with topology change", test.name)
+		}
+	}
+	grown, err := sdf.OffsetSafe3D(s, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := grown.Bounds().Max.X; math.Abs(got-7.5) > 1e-12 {
+		t.Errorf("got grown bounds max X %g, want 7.5", got)
+	}
+}
+
+func TestOffset3DBounds(t *testing.T) {
+	center := r3.Vec{X: 1, Y: 2, Z: 3}
+	box := sdf.Transform3D(must3.Box(r3.Vec{X: 4, Y: 2, Z: 6}, 0), sdf.Translate3D(center))
+	if got := sdf.Offset3D(must3.Sphere(2), -0.5).Bounds(); got.Max.X != 1.5 {
+		t.Errorf("got eroded bounds %v, want max X 1.5", got)
+	}
+	// Eroding by more than half the smallest side collapses that side to the center.
+	got := sdf.Offset3D(box, -1.5).Bounds()
+	want := r3.Box{Min: r3.Vec{X: 0.5, Y: 2, Z: 1.5}, Max: r3.Vec{X: 1.5, Y: 2, Z: 4.5}}
+	if r3.Norm(r3.Sub(got.Min, want.Min)) > 1e-12 || r3.Norm(r3.Sub(got.Max, want.Max)) > 1e-12 {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	// Eroding past every side leaves an empty box at the center.
+	got = sdf.Offset3D(box, -10).Bounds()
+	if got.Min != center || got.Max != center {
+		t.Errorf("got bounds %v, want point %v", got, center)
+	}
+}
+
+func TestTransform3DChecked(t *testing.T) {
+	sphere := must3.Sphere(1)
+	if _, err := sdf.Transform3DChecked(sphere, sdf.Scale3D(r3.Vec{X: 1, Y: 0, Z: 1})); err == nil {
+		t.Error("expected error for singular scale")
+	}
+	if _, err := sdf.Transform3DChecked(sphere, sdf.Scale3D(r3.Vec{X: 1, Y: 1e-15, Z: 1})); err == nil {
+		t.Error("expected error for nearly singular scale")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected Transform3D to panic for singular scale")
+			}
+		}()
+		sdf.Transform3D(sphere, sdf.Scale3D(r3.Vec{X: 1, Y: 1, Z: 0}))
+	}()
+	// Small uniform scales and large translations are well conditioned.
+	s, err := sdf.Transform3DChecked(sphere, sdf.Translate3D(r3.Vec{X: 1e6}).Mul(sdf.Scale3D(r3.Vec{X: 1e-3, Y: 1e-3, Z: 1e-3})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Evaluate(r3.Vec{X: 1e6}); math.IsNaN(got) || got >= 0 {
+		t.Errorf("got %g at center of transformed sphere", got)
+	}
+}
+
+func TestAutoFillet3D(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 4, Y: 4, Z: 4}, 0)
+	const radius = 0.3
+	filleted := sdf.AutoFillet3D(box, radius, math.Pi/4, 40)
+	// Box edges turn by π/2 and are rounded off, faces stay in place.
+	edge := r3.Vec{X: 2, Y: 2}
+	if d := filleted.Evaluate(edge); d <= 0.05 {
+		t.Errorf("edge not rounded: got %g at %v", d, edge)
+	}
+	for _, p := range []r3.Vec{{X: 2}, {X: 2.5, Y: 0.5, Z: -0.3}, {Z: -1}} {
+		if got, want := filleted.Evaluate(p), box.Evaluate(p); got != want {
+			t.Errorf("face at %v moved: got %g, want %g", p, got, want)
+		}
+	}
+	// Edges gentler than the threshold are left sharp.
+	if got := sdf.AutoFillet3D(box, radius, 0.6*math.Pi, 40).Evaluate(edge); got != 0 {
+		t.Errorf("edge below threshold rounded: got %g", got)
+	}
+	// Smooth surfaces are untouched.
+	sphere := must3.Sphere(2)
+	smooth := sdf.AutoFillet3D(sphere, radius, math.Pi/4, 40)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		p := r3.Vec{X: 5*rng.Float64() - 2.5, Y: 5*rng.Float64() - 2.5, Z: 5*rng.Float64() - 2.5}
+		if got, want := smooth.Evaluate(p), sphere.Evaluate(p); got != want {
+			t.Fatalf("sphere changed at %v: got %g, want %g", p, got, want)
+		}
+	}
+}
+
+func TestSmoothUnion3D(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for k = 0")
+			}
+		}()
+		sdf.SmoothUnion3D(0, must3.Sphere(1), must3.Sphere(1))
+	}()
+	left := sdf.Transform3D(must3.Sphere(1.2), sdf.Translate3D(r3.Vec{X: -1}))
+	right := sdf.Transform3D(must3.Sphere(1.2), sdf.Translate3D(r3.Vec{X: 1}))
+	top := sdf.Transform3D(must3.Sphere(1.2), sdf.Translate3D(r3.Vec{Y: 1.5}))
+	const k = 0.4
+	smooth := sdf.SmoothUnion3D(k, left, right)
+	sharp := sdf.Union3D(left, right)
+	// Where both fields are equal the blend lowers the field by k/4.
+	p := r3.Vec{Y: 1.5}
+	if got, want := smooth.Evaluate(p), sharp.Evaluate(p)-k/4; math.Abs(got-want) > 1e-12 {
+		t.Errorf("got %g at seam, want %g", got, want)
+	}
+	// The gradient turns continuously across the seam, unlike that of the plain union.
+	const step = 0.005
+	jump := func(s sdf.SDF3) float64 {
+		var prev r3.Vec
+		largest := 0.0
+		for x := -0.6; x <= 0.6; x += step {
+			g := sdf.EvaluateGradient(s, r3.Vec{X: x, Y: 1.3}, 1e-5)
+			if x > -0.6 {
+				largest = math.Max(largest, r3.Norm(r3.Sub(g, prev)))
+			}
+			prev = g
+		}
+		return largest
+	}
+	if got := jump(smooth); got > 0.05 {
+		t.Errorf("gradient of smooth union jumps by %g", got)
+	}
+	if got := jump(sharp); got < 0.3 {
+		t.Errorf("gradient of plain union jumps by %g, expected a kink", got)
+	}
+	// Small k matches the plain union, folded over more than two SDF3s.
+	tiny := sdf.SmoothUnion3D(1e-9, left, right, top)
+	plain := sdf.Union3D(left, right, top)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		p := r3.Vec{X: 6*rng.Float64() - 3, Y: 6*rng.Float64() - 3, Z: 6*rng.Float64() - 3}
+		if got, want := tiny.Evaluate(p), plain.Evaluate(p); math.Abs(got-want) > 1e-9 {
+			t.Fatalf("at %v got %g, want union %g", p, got, want)
+		}
+	}
+	// Bounds hold the bulge of each of the two blends.
+	bb, pb := sdf.SmoothUnion3D(k, left, right, top).Bounds(), plain.Bounds()
+	if got, want := r3.Sub(pb.Min, bb.Min), (r3.Vec{X: k / 2, Y: k / 2, Z: k / 2}); r3.Norm(r3.Sub(got, want)) > 1e-12 {
+		t.Errorf("got bounds enlarged by %v, want %v", got, want)
+	}
+}
+
+func TestSmoothDifference3D(t *testing.T) {
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic for k < 0")
+			}
+		}()
+		sdf.SmoothDifference3D(-1, must3.Sphere(1), must3.Sphere(1))
+	}()
+	box := must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0)
+	hole := sdf.Transform3D(must3.Sphere(0.8), sdf.Translate3D(r3.Vec{Z: 1}))
+	hard := sdf.Difference3D(box, hole)
+	tiny := sdf.SmoothDifference3D(1e-9, box, hole)
+	small := sdf.SmoothDifference3D(0.1, box, hole)
+	large := sdf.SmoothDifference3D(0.3, box, hole)
+	// Sample the seam where the sphere cuts the top face of the box. The blend
+	// removes more material as k grows, by no more than k/4.
+	for x := 0.; x < 1.2; x += 0.01 {
+		for z := 0.6; z < 1.2; z += 0.01 {
+			p := r3.Vec{X: x, Z: z}
+			dh, d0, d1, d2 := hard.Evaluate(p), tiny.Evaluate(p), small.Evaluate(p), large.Evaluate(p)
+			if math.IsNaN(d2) || math.IsInf(d2, 0) {
+				t.Fatalf("got %g at %v", d2, p)
+			}
+			if math.Abs(d0-dh) > 1e-9 {
+				t.Errorf("at %v got %g with small k, want difference %g", p, d0, dh)
+			}
+			if d1 < dh-1e-12 || d2 < d1-1e-12 || d2 > dh+0.3/4+1e-12 {
+				t.Errorf("at %v field not monotone in k: %g, %g, %g, %g", p, dh, d1, d2, dh+0.3/4)
+			}
+		}
+	}
+	if large.Bounds() != box.Bounds() {
+		t.Errorf("got bounds %v, want those of s0 %v", large.Bounds(), box.Bounds())
+	}
+}
+
+func TestSmoothIntersect3D(t *testing.T) {
+	left := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: -0.5}))
+	right := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 0.5}))
+	const k = 0.3
+	hard := sdf.Intersect3D(left, right)
+	tiny := sdf.SmoothIntersect3D(1e-9, left, right)
+	smooth := sdf.SmoothIntersect3D(k, left, right)
+	// The lens is convex and centered on the origin so the field grows
+	// along rays from the origin, across the rounded seam.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		dir := r3.Unit(r3.Vec{X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()})
+		prev := math.Inf(-1)
+		for r := 0.; r < 2; r += 0.01 {
+			p := r3.Scale(r, dir)
+			d, dh := smooth.Evaluate(p), hard.Evaluate(p)
+			if math.IsNaN(d) || math.IsInf(d, 0) || d < prev-1e-12 {
+				t.Fatalf("field %g at %v not finite and growing from %g", d, p, prev)
+			}
+			if d < dh-1e-12 || d > dh+k/4+1e-12 {
+				t.Errorf("at %v got %g, want within k/4 above intersection %g", p, d, dh)
+			}
+			if got := tiny.Evaluate(p); math.Abs(got-dh) > 1e-9 {
+				t.Errorf("at %v got %g with small k, want intersection %g", p, got, dh)
+			}
+			prev = d
+		}
+	}
+	want := r3.Box{Min: r3.Vec{X: -0.5, Y: -1, Z: -1}, Max: r3.Vec{X: 0.5, Y: 1, Z: 1}}
+	if got := smooth.Bounds(); r3.Norm(got.Min.Sub(want.Min)) > 1e-12 || r3.Norm(got.Max.Sub(want.Max)) > 1e-12 {
+		t.Errorf("got bounds %v, want intersection of bounds %v", got, want)
+	}
+}
+
+func TestMirror3D(t *testing.T) {
+	sphere := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 3, Y: 1, Z: 2}))
+	// Mirror across the plane x+y = 2, with an unnormalized normal.
+	point, normal := r3.Vec{X: 1, Y: 1}, r3.Vec{X: 2, Y: 2}
+	mirrored := sdf.Mirror3D(sphere, point, normal)
+	// The mirror image of the sphere center (3,1,2) is (1,-1,2).
+	image := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{X: 1, Y: -1, Z: 2}))
+	want := sdf.Union3D(sphere, image)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		p := r3.Vec{X: 8*rng.Float64() - 3, Y: 8*rng.Float64() - 4, Z: 6*rng.Float64() - 1}
+		if got, want := mirrored.Evaluate(p), want.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Fatalf("at %v got %g, want %g", p, got, want)
+		}
+	}
+	// Points on the plane are their own mirror image.
+	if got, want := mirrored.Evaluate(point), sphere.Evaluate(point); got != want {
+		t.Errorf("on plane got %g, want %g", got, want)
+	}
+	for _, c := range []r3.Vec{{X: 3, Y: 1, Z: 2}, {X: 1, Y: -1, Z: 2}} {
+		if got := mirrored.Evaluate(c); math.Abs(got+1) > 1e-12 {
+			t.Errorf("got %g at sphere center %v, want -1", got, c)
+		}
+	}
+	// The bounds hold the box of the sphere and its reflection, which is the
+	// box of the sphere rotated 90 degrees about Z.
+	wantBB := r3.Box{Min: r3.Vec{X: 0, Y: -2, Z: 1}, Max: r3.Vec{X: 4, Y: 2, Z: 3}}
+	if got := mirrored.Bounds(); r3.Norm(r3.Sub(got.Min, wantBB.Min)) > 1e-12 || r3.Norm(r3.Sub(got.Max, wantBB.Max)) > 1e-12 {
+		t.Errorf("got bounds %v, want %v", got, wantBB)
+	}
+}
+
+func TestSceneBounds(t *testing.T) {
+	if got := sdf.SceneBounds(); got != (r3.Box{}) {
+		t.Errorf("got %v for empty scene, want zero box", got)
+	}
+	box := sdf.Transform3D(must3.Box(r3.Vec{X: 2, Y: 2, Z: 2}, 0), sdf.Translate3D(r3.Vec{X: 5}))
+	sphere := sdf.Transform3D(must3.Sphere(1), sdf.Translate3D(r3.Vec{Y: -3, Z: 2}))
+	if got, want := sdf.SceneBounds(box), box.Bounds(); got != want {
+		t.Errorf("got %v for single object, want %v", got, want)
+	}
+	want := r3.Box{Min: r3.Vec{X: -1, Y: -4, Z: -1}, Max: r3.Vec{X: 6, Y: 1, Z: 3}}
+	if got := sdf.SceneBounds(box, sphere); got != want {
+		t.Errorf("got bounds %v, want %v", got, want)
+	}
+	if got, want := sdf.SceneCenter(box, sphere), (r3.Vec{X: 2.5, Y: -1.5, Z: 1}); got != want {
+		t.Errorf("got center %v, want %v", got, want)
+	}
+	if got, want := sdf.SceneSize(box, sphere), (r3.Vec{X: 7, Y: 5, Z: 4}); got != want {
+		t.Errorf("got size %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package sdf
+
+import (
+	"math"
+	"sort"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// Sphere is a sphere defined by its center and radius.
+type Sphere struct {
+	Center r3.Vec
+	Radius float64
+}
+
+// sphereUnion is a union of many spheres stored in flat slices
+// and accelerated with a bounding volume hierarchy.
+type sphereUnion struct {
+	centers []r3.Vec
+	radii   []float64
+	nodes   []sphereNode
+}
+
+// sphereNode is a node of the bounding volume hierarchy of a sphereUnion.
+// Leaves hold the spheres in [start, end).
+type sphereNode struct {
+	bb          r3.Box
+	start, end  int
+	left, right int // child node indices, 0 for leaves.
+}
+
+// maximum number of spheres in a bounding volume hierarchy leaf.
+const sphereLeafSize = 4
+
+// SphereUnion3D returns the union of spheres. It is equivalent to the Union3D of
+// the spheres but much faster for large numbers of spheres, such as point clouds
+// or molecular models, since the spheres are evaluated without interface calls
+// and far away spheres are skipped using a bounding volume hierarchy.
+func SphereUnion3D(spheres []Sphere) SDF3 {
+	if len(spheres) == 0 {
+		panic("no spheres")
+	}
+	sorted := append([]Sphere{}, spheres...)
+	for _, sp := range sorted {
+		if sp.Radius <= 0 {
+			panic("sphere radius <= 0")
+		}
+	}
+	s := sphereUnion{}
+	s.build(sorted, 0)
+	s.centers = make([]r3.Vec, len(sorted))
+	s.radii = make([]float64, len(sorted))
+	for i, sp := range sorted {
+		s.centers[i] = sp.Center
+		s.radii[i] = sp.Radius
+	}
+	return &s
+}
+
+// build adds the node of spheres and its descendants to the hierarchy, sorting
+// the spheres so that every node's spheres are contiguous. offset is the index
+// of the first sphere of spheres in the union. Returns the index of the node.
+func (s *sphereUnion) build(spheres []Sphere, offset int) int {
+	bb := d3.Box{Min: d3.Elem(math.Inf(1)), Max: d3.Elem(math.Inf(-1))}
+	for _, sp := range spheres {
+		r := d3.Elem(sp.Radius)
+		bb = bb.Extend(d3.Box{Min: r3.Sub(sp.Center, r), Max: r3.Add(sp.Center, r)})
+	}
+	idx := len(s.nodes)
+	s.nodes = append(s.nodes, sphereNode{bb: r3.Box(bb), start: offset, end: offset + len(spheres)})
+	if len(spheres) <= sphereLeafSize {
+		return idx
+	}
+	// Split at the median along the longest axis.
+	size := bb.Size()
+	var key func(v r3.Vec) float64
+	switch {
+	case size.X >= size.Y && size.X >= size.Z:
+		key = func(v r3.Vec) float64 { return v.X }
+	case size.Y >= size.Z:
+		key = func(v r3.Vec) float64 { return v.Y }
+	default:
+		key = func(v r3.Vec) float64 { return v.Z }
+	}
+	sort.Slice(spheres, func(i, j int) bool { return key(spheres[i].Center) < key(spheres[j].Center) })
+	half := len(spheres) / 2
+	left := s.build(spheres[:half], offset)
+	right := s.build(spheres[half:], offset+half)
+	s.nodes[idx].left, s.nodes[idx].right = left, right
+	return idx
+}
+
+// Evaluate returns the minimum distance to the union of spheres.
+func (s *sphereUnion) Evaluate(p r3.Vec) float64 {
+	d := math.Inf(1)
+	var stack [64]int
+	n := 1 // stack[0] = 0 is the root node.
+	for n > 0 {
+		n--
+		node := &s.nodes[stack[n]]
+		if boxDistance(p, node.bb) >= d {
+			continue
+		}
+		if node.left == 0 {
+			for i := node.start; i < node.end; i++ {
+				d = math.Min(d, r3.Norm(r3.Sub(p, s.centers[i]))-s.radii[i])
+			}
+			continue
+		}
+		// Visit the closest child first to prune more of the farthest.
+		a, b := node.left, node.right
+		if boxDistance(p, s.nodes[a].bb) < boxDistance(p, s.nodes[b].bb) {
+			a, b = b, a
+		}
+		stack[n], stack[n+1] = a, b
+		n += 2
+	}
+	return d
+}
+
+// BoundingBox returns the bounding box of the union of spheres.
+func (s *sphereUnion) Bounds() r3.Box {
+	return s.nodes[0].bb
+}
+
+// boxDistance returns the signed distance from p to box, negative inside the box.
+// It bounds the distances to the spheres or boxes within box, even from inside it.
+func boxDistance(p r3.Vec, box r3.Box) float64 {
+	bb := d3.Box(box)
+	return sdfBox3d(r3.Sub(p, bb.Center()), r3.Scale(0.5, bb.Size()))
+}
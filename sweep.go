@@ -0,0 +1,200 @@
+package sdf
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// railSweep3 is an SDF2 profile swept between two rails.
+type railSweep3 struct {
+	profile SDF2
+	rails   [2][]r3.Vec
+	// Points of the path between the rails, unit tangents
+	// to the path and path lengths at each station.
+	path    []r3.Vec
+	tangent []r3.Vec
+	length  []float64
+	// lipschitz is the largest overestimate of distances by the field.
+	lipschitz float64
+	bb        r3.Box
+}
+
+// RailSweep3D sweeps profile between two rails given as polylines with a point for each
+// station of the sweep, as the sweep along two rails of CAD software. Corresponding points
+// of the rails are joined by the X axis of profile from X=0 on rail0 to X=1 on rail1, so
+// profile spans between the rails if its X ranges from 0 to 1. Profiles are scaled uniformly
+// by the distance between the rails, so the Y axis of profile is scaled as the X axis.
+//
+// The section of the sweep at a point of the path midway between the rails lies on the plane
+// containing the line joining the rails and the path tangent normal to that line. The X axis
+// of the profile points towards rail1 and the Y axis is the cross product of the path tangent
+// with it. Rails and tangents are interpolated linearly between stations. The ends of the
+// sweep are closed by the end sections.
+//
+// The field is approximate: points are matched to the section containing them nearest to
+// the path and distances are measured on the section and along the path. Where sections
+// move faster than the path, such as on the inside of bends or where the rails diverge,
+// this overestimates distances, so the field is scaled down by the largest overestimate
+// found near the profile and underestimates distances elsewhere. Sweeps through bends
+// tighter than the distance from the path to the profile are not supported.
+func RailSweep3D(profile SDF2, rail0, rail1 []r3.Vec) (SDF3, error) {
+	if profile == nil {
+		return nil, errors.New("nil SDF2 argument")
+	}
+	if len(rail0) != len(rail1) {
+		return nil, errors.New("rails must have the same number of points")
+	}
+	n := len(rail0)
+	if n < 2 {
+		return nil, errors.New("rails must have at least 2 points")
+	}
+	s := railSweep3{
+		profile: profile,
+		rails:   [2][]r3.Vec{append([]r3.Vec{}, rail0...), append([]r3.Vec{}, rail1...)},
+		path:    make([]r3.Vec, n),
+		tangent: make([]r3.Vec, n),
+		length:  make([]float64, n),
+	}
+	for i := range s.path {
+		if r3.Norm(r3.Sub(rail1[i], rail0[i])) < epsilon {
+			return nil, errors.New("rails meet")
+		}
+		s.path[i] = r3.Scale(0.5, r3.Add(rail0[i], rail1[i]))
+		if i > 0 {
+			l := r3.Norm(r3.Sub(s.path[i], s.path[i-1]))
+			if l < epsilon {
+				return nil, errors.New("coincident stations")
+			}
+			s.length[i] = s.length[i-1] + l
+		}
+	}
+	for i := range s.tangent {
+		next, prev := s.path[clampInt(i+1, 0, n-1)], s.path[clampInt(i-1, 0, n-1)]
+		s.tangent[i] = r3.Unit(r3.Sub(next, prev))
+	}
+	// Bound the profile's bounding box swept through the interpolated frames.
+	// Distances are measured on the sections and along the path, which overestimates
+	// them where sections move faster than the path, such as on the inside of bends.
+	// The largest overestimate at the corners of the profile scales the field.
+	const steps = 8
+	pbb := profile.Bounds()
+	corners := []r2.Vec{pbb.Min, {X: pbb.Max.X, Y: pbb.Min.Y}, pbb.Max, {X: pbb.Min.X, Y: pbb.Max.Y}}
+	bb := d3.Box{Min: d3.Elem(math.Inf(1)), Max: d3.Elem(math.Inf(-1))}
+	s.lipschitz = 1
+	var prev [4]r3.Vec
+	for i := 0; i < n-1; i++ {
+		ds := (s.length[i+1] - s.length[i]) / steps
+		for j := 0; j <= steps; j++ {
+			origin, e, nrm, t, width, err := s.frame(i, float64(j)/steps)
+			if err != nil {
+				return nil, err
+			}
+			for k, c := range corners {
+				p := r3.Add(origin, r3.Scale(width, r3.Add(r3.Scale(c.X, e), r3.Scale(c.Y, nrm))))
+				bb = d3.Box{Min: d3.MinElem(bb.Min, p), Max: d3.MaxElem(bb.Max, p)}
+				if j > 0 {
+					v := r3.Scale(1/ds, r3.Sub(p, prev[k]))
+					s.lipschitz = math.Max(s.lipschitz, sweepLipschitz(r3.Dot(v, t), r3.Dot(v, e), r3.Dot(v, nrm)))
+				}
+				prev[k] = p
+			}
+		}
+	}
+	// Sections between the sampled frames rotate slightly past the sampled corners.
+	size := bb.Size()
+	s.bb = r3.Box(bb.Enlarge(r3.Scale(0.05, d3.Elem(d3.Max(size)))))
+	return &s, nil
+}
+
+// sweepLipschitz returns the largest factor by which distances measured along the
+// path and on the sections overestimate distances near a point of a section moving
+// with velocity (a, b, c) per unit path length in the frame of the section normal
+// and the profile axes. It is the inverse of the smallest singular value of the
+// Jacobian of the map from path length and section coordinates to space.
+func sweepLipschitz(a, b, c float64) float64 {
+	sum := 1 + a*a + b*b + c*c
+	smallest := (sum - math.Sqrt(math.Max(0, sum*sum-4*a*a))) / 2
+	if smallest <= 0 {
+		return math.Inf(1)
+	}
+	return 1 / math.Sqrt(smallest)
+}
+
+// frame returns the origin on rail0, the unit X and Y axes of the profile, the unit
+// normal of the section and the scale of the profile at fraction f of segment i.
+func (s *railSweep3) frame(i int, f float64) (origin, e, n, t r3.Vec, width float64, err error) {
+	origin = mix3(s.rails[0][i], s.rails[0][i+1], f)
+	chord := r3.Sub(mix3(s.rails[1][i], s.rails[1][i+1], f), origin)
+	width = r3.Norm(chord)
+	e = r3.Scale(1/width, chord)
+	tangent := mix3(s.tangent[i], s.tangent[i+1], f)
+	// Section normal is the tangent made perpendicular to the chord.
+	t = r3.Sub(tangent, r3.Scale(r3.Dot(tangent, e), e))
+	if r3.Norm(t) < epsilon {
+		return origin, e, n, t, width, errors.New("rails cross the path")
+	}
+	t = r3.Unit(t)
+	n = r3.Cross(t, e)
+	return origin, e, n, t, width, nil
+}
+
+// offset returns the distance from the section at fraction f of segment i to p.
+func (s *railSweep3) offset(p r3.Vec, i int, f float64) float64 {
+	_, _, _, t, _, _ := s.frame(i, f)
+	return r3.Dot(r3.Sub(p, mix3(s.path[i], s.path[i+1], f)), t)
+}
+
+// Evaluate returns the approximate minimum distance to the sweep.
+func (s *railSweep3) Evaluate(p r3.Vec) float64 {
+	// Find the segment nearest to p whose end sections lie on both sides of p.
+	// Stations share their sections so the choice is continuous along the path.
+	last := len(s.path) - 1
+	seg, f := -1, 0.0
+	best := math.Inf(1)
+	prev := s.offset(p, 0, 0)
+	for i := 0; i < last; i++ {
+		next := s.offset(p, i, 1)
+		if prev >= 0 && next <= 0 {
+			a, b := s.path[i], s.path[i+1]
+			ab := r3.Sub(b, a)
+			fi := clamp(r3.Dot(r3.Sub(p, a), ab)/r3.Norm2(ab), 0, 1)
+			if d2 := r3.Norm2(r3.Sub(p, mix3(a, b, fi))); d2 < best {
+				best, seg = d2, i
+			}
+		}
+		prev = next
+	}
+	if seg >= 0 {
+		// Bisect for the section containing p.
+		a, b := 0.0, 1.0
+		for i := 0; i < 32; i++ {
+			if s.offset(p, seg, (a+b)/2) > 0 {
+				a = (a + b) / 2
+			} else {
+				b = (a + b) / 2
+			}
+		}
+		f = (a + b) / 2
+	} else if r3.Norm2(r3.Sub(p, s.path[0])) < r3.Norm2(r3.Sub(p, s.path[last])) {
+		// Past the nearest end of the sweep.
+		seg, f = 0, 0
+	} else {
+		seg, f = last-1, 1
+	}
+	origin, e, n, t, width, _ := s.frame(seg, f)
+	q := r3.Sub(p, origin)
+	d2 := width * s.profile.Evaluate(r2.Vec{X: r3.Dot(q, e) / width, Y: r3.Dot(q, n) / width})
+	// Distance along the path past its ends, negative between them.
+	along := s.length[seg] + f*(s.length[seg+1]-s.length[seg]) + r3.Dot(r3.Sub(p, mix3(s.path[seg], s.path[seg+1], f)), t)
+	dz := math.Max(-along, along-s.length[last])
+	return (math.Min(math.Max(d2, dz), 0) + math.Hypot(math.Max(d2, 0), math.Max(dz, 0))) / s.lipschitz
+}
+
+// Bounds returns the bounding box of the sweep.
+func (s *railSweep3) Bounds() r3.Box {
+	return s.bb
+}
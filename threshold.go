@@ -0,0 +1,88 @@
+package sdf
+
+import (
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// ThresholdEvaluator is implemented by SDF3s which can stop evaluating early
+// when the caller only needs to know whether a point is farther than a
+// threshold from the surface, such as when pruning acceleration structures.
+type ThresholdEvaluator interface {
+	// EvaluateThreshold returns the same as Evaluate if the absolute distance
+	// to the surface is smaller than threshold. Otherwise it returns a value
+	// of the same sign as the distance with an absolute value of at least threshold.
+	EvaluateThreshold(p r3.Vec, threshold float64) float64
+}
+
+// EvaluateThreshold evaluates s at p with an early-out threshold if s
+// implements ThresholdEvaluator. Otherwise it returns s.Evaluate(p).
+// See ThresholdEvaluator for the semantics of the result.
+func EvaluateThreshold(s SDF3, p r3.Vec, threshold float64) float64 {
+	if t, ok := s.(ThresholdEvaluator); ok {
+		return t.EvaluateThreshold(p, threshold)
+	}
+	return s.Evaluate(p)
+}
+
+// EvaluateThreshold evaluates the union skipping objects whose bounding sphere
+// is farther than threshold and returning as soon as p is deeper than threshold
+// inside any object. Unions which do not cull are evaluated fully.
+func (s *union3) EvaluateThreshold(p r3.Vec, threshold float64) float64 {
+	if !s.cull {
+		return s.Evaluate(p)
+	}
+	d := math.Inf(1)
+	for i, x := range s.sdf {
+		if d <= -threshold {
+			// Other objects can only lower the minimum further.
+			break
+		}
+		if r3.Norm(r3.Sub(p, s.centers[i]))-s.radii[i] >= math.Min(d, threshold) {
+			continue
+		}
+		d = math.Min(d, EvaluateThreshold(x, p, threshold))
+	}
+	if math.IsInf(d, 1) {
+		// All objects farther than threshold.
+		return threshold
+	}
+	return d
+}
+
+// EvaluateThreshold evaluates the difference skipping the subtracted
+// SDF3 when p is farther than threshold outside of the first SDF3.
+// Blended differences are evaluated fully.
+func (s *diff3) EvaluateThreshold(p r3.Vec, threshold float64) float64 {
+	if s.blend {
+		return s.Evaluate(p)
+	}
+	d := EvaluateThreshold(s.s0, p, threshold)
+	if d >= threshold {
+		// Subtracting can only increase the distance.
+		return d
+	}
+	return math.Max(d, -EvaluateThreshold(s.s1, p, threshold))
+}
+
+// EvaluateThreshold evaluates the array returning as soon as p is deeper than
+// threshold inside any instance. Blended arrays are evaluated fully.
+func (s *array3) EvaluateThreshold(p r3.Vec, threshold float64) float64 {
+	if s.blend || s.near {
+		return s.Evaluate(p)
+	}
+	d := math.MaxFloat64
+	for j := 0; j < s.num[0]; j++ {
+		for k := 0; k < s.num[1]; k++ {
+			for l := 0; l < s.num[2]; l++ {
+				x := p.Sub(r3.Vec{X: float64(j) * s.step.X, Y: float64(k) * s.step.Y, Z: float64(l) * s.step.Z})
+				d = math.Min(d, EvaluateThreshold(s.sdf, x, threshold))
+				if d <= -threshold {
+					return d
+				}
+			}
+		}
+	}
+	return d
+}
@@ -0,0 +1,45 @@
+package sdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestEvaluateThreshold(t *testing.T) {
+	sphere := &countingSDF3{SDF3: must3.Sphere(0.4)}
+	var objects []sdf.SDF3
+	for i := 0; i < 8; i++ {
+		objects = append(objects, sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: float64(i)})))
+	}
+	holes := sdf.Array3D(must3.Box(r3.Vec{X: 0.2, Y: 0.2, Z: 2}, 0), sdf.V3i{8, 1, 1}, r3.Vec{X: 1})
+	// The counted spheres do not report exact distances so culling is opted into.
+	u := sdf.Union3D(objects...)
+	u.(sdf.Culler).SetCull(true)
+	s := sdf.Difference3D(u, holes)
+	const threshold = 0.1
+	var full, early int
+	for x := -1.; x < 9; x += 0.0371 {
+		for _, y := range []float64{0, 0.05, 0.3, 0.45, 1} {
+			p := r3.Vec{X: x, Y: y, Z: 0.1}
+			sphere.evaluations = 0
+			want := s.Evaluate(p)
+			full += sphere.evaluations
+			sphere.evaluations = 0
+			got := sdf.EvaluateThreshold(s, p, threshold)
+			early += sphere.evaluations
+			if math.Abs(want) < threshold && got != want {
+				t.Errorf("distance at %v: got %g, want exact %g", p, got, want)
+			}
+			if math.Abs(want) >= threshold && (math.Abs(got) < threshold || math.Signbit(got) != math.Signbit(want)) {
+				t.Errorf("distance at %v: got %g, want same sign as %g and magnitude above threshold", p, got, want)
+			}
+		}
+	}
+	if early*4 > full {
+		t.Errorf("threshold evaluation did %d leaf evaluations, expected far less than %d", early, full)
+	}
+}
@@ -75,6 +75,11 @@ func mix(x, y, a float64) float64 {
 	return x + (a * (y - x))
 }
 
+// mix3 does a linear interpolation from x to y, a = [0,1]
+func mix3(x, y r3.Vec, a float64) r3.Vec {
+	return r3.Add(x, r3.Scale(a, r3.Sub(y, x)))
+}
+
 // sign returns the sign of x
 func sign(x float64) float64 {
 	if x < 0 {
@@ -93,6 +98,54 @@ func sawTooth(x, period float64) float64 {
 	return period*(t-math.Floor(t)) - period/2
 }
 
+// SmoothAbs returns a smooth approximation of the absolute value of x.
+// The result is sqrt(x*x + k*k) which equals k at x=0 and approaches |x| for |x| >> k.
+// k<=0 returns math.Abs(x).
+func SmoothAbs(x, k float64) float64 {
+	if k <= 0 {
+		return math.Abs(x)
+	}
+	return math.Sqrt(x*x + k*k)
+}
+
+// SmoothMin returns a smooth minimum of a and b using a quadratic polynomial
+// blend of radius k. The result is continuous and has a continuous first derivative.
+// k<=0 returns math.Min(a, b).
+func SmoothMin(a, b, k float64) float64 {
+	if k <= 0 {
+		return math.Min(a, b)
+	}
+	return minQuad(a, b, k)
+}
+
+// SmoothMax returns a smooth maximum of a and b using a quadratic polynomial
+// blend of radius k. It is the counterpart of SmoothMin.
+// k<=0 returns math.Max(a, b).
+func SmoothMax(a, b, k float64) float64 {
+	return -SmoothMin(-a, -b, k)
+}
+
+// SmoothClamp clamps x between lo and hi, blending the transitions
+// at lo and hi with radius k using SmoothMax and SmoothMin. Assumes lo <= hi.
+// k<=0 performs a hard clamp.
+func SmoothClamp(x, lo, hi, k float64) float64 {
+	return SmoothMin(SmoothMax(x, lo, k), hi, k)
+}
+
+// SmoothStep performs Hermite interpolation between 0 and 1 when edge0 < x < edge1.
+// It returns 0 for x <= edge0 and 1 for x >= edge1, same as GLSL's smoothstep.
+// When edge0 == edge1 it is a step, 1 for x >= edge1 and 0 otherwise.
+func SmoothStep(edge0, edge1, x float64) float64 {
+	if edge0 == edge1 {
+		if x >= edge1 {
+			return 1
+		}
+		return 0
+	}
+	t := clamp((x-edge0)/(edge1-edge0), 0, 1)
+	return t * t * (3 - 2*t)
+}
+
 // MinRound returns a minimum function that uses a quarter-circle to join the two objects smoothly.
 func MinRound(k float64) MinFunc {
 	return func(a, b float64) float64 {
@@ -311,6 +364,270 @@ func normal3(s SDF3, p r3.Vec, eps float64) r3.Vec {
 	})
 }
 
+// RelativeGradientEpsilon times the bounding box diagonal of an SDF3 is the
+// step used by EvaluateGradient and Normal3D when eps<=0. It is small enough to
+// resolve features a millionth the size of the model while staying well above
+// floating point noise.
+const RelativeGradientEpsilon = 1e-6
+
+// EvaluateGradient returns the gradient of an SDF3 at p computed with central
+// differences of step eps. If eps<=0 the step is RelativeGradientEpsilon times
+// the bounding box diagonal so the accuracy is independent of the model scale.
+func EvaluateGradient(s SDF3, p r3.Vec, eps float64) r3.Vec {
+	if eps <= 0 {
+		eps = gradientEpsilon(s)
+	}
+	return r3.Scale(0.5/eps, r3.Vec{
+		X: s.Evaluate(p.Add(r3.Vec{X: eps})) - s.Evaluate(p.Add(r3.Vec{X: -eps})),
+		Y: s.Evaluate(p.Add(r3.Vec{Y: eps})) - s.Evaluate(p.Add(r3.Vec{Y: -eps})),
+		Z: s.Evaluate(p.Add(r3.Vec{Z: eps})) - s.Evaluate(p.Add(r3.Vec{Z: -eps})),
+	})
+}
+
+// Normal3D returns the unit normal of an SDF3 at p, which need not be on the surface.
+// eps is handled the same as in EvaluateGradient.
+func Normal3D(s SDF3, p r3.Vec, eps float64) r3.Vec {
+	if eps <= 0 {
+		eps = gradientEpsilon(s)
+	}
+	return normal3(s, p, eps)
+}
+
+// gradientEpsilon returns the gradient step for s relative to its size.
+func gradientEpsilon(s SDF3) float64 {
+	bb := s.Bounds()
+	return RelativeGradientEpsilon * r3.Norm(r3.Sub(bb.Max, bb.Min))
+}
+
+// ValidateField checks the distance reported by an SDF3 at p. It returns the
+// magnitude of the field gradient, which is 1 for an exact distance field, and
+// whether stepping the reported distance along the unit normal lands within tol
+// of the surface. SDF3s built with non-isometric transforms commonly fail this
+// check, as do uniformly scaled fields. The gradient is computed with central
+// differences of step eps, which is handled the same as in EvaluateGradient.
+func ValidateField(sdf SDF3, p r3.Vec, eps, tol float64) (gradMag float64, consistent bool) {
+	grad := EvaluateGradient(sdf, p, eps)
+	gradMag = r3.Norm(grad)
+	if gradMag == 0 {
+		return 0, false
+	}
+	d := sdf.Evaluate(p)
+	q := r3.Sub(p, r3.Scale(d, r3.Unit(grad)))
+	return gradMag, math.Abs(sdf.Evaluate(q)) < tol
+}
+
+// MinGap3D returns the minimum distance from the surface of a to b, which is the
+// clearance between two separate solids. The result is negative if the solids
+// interpenetrate, in which case it is the deepest penetration found.
+// The surface of a is sampled on a grid with samples cells along the longest
+// side of its bounding box, so the result is only as accurate as the grid:
+// features of b narrower than a cell may be missed and the minimum is found
+// within about half a cell of the true gap. Returns +Inf if no surface is found.
+func MinGap3D(a, b SDF3, samples int) float64 {
+	if a == nil || b == nil {
+		panic("nil SDF3 argument")
+	}
+	if samples <= 0 {
+		panic("samples <= 0")
+	}
+	bb := d3.Box(a.Bounds())
+	size := bb.Size()
+	cell := d3.Max(size) / float64(samples)
+	n := V3i{int(math.Ceil(size.X / cell)), int(math.Ceil(size.Y / cell)), int(math.Ceil(size.Z / cell))}
+	// cells whose center is within half a diagonal of the surface straddle it.
+	halfDiag := 0.5 * math.Sqrt(3) * cell
+	eps := 1e-3 * cell
+	gap := math.Inf(1)
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := r3.Add(bb.Min, r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+				if math.Abs(a.Evaluate(p)) > halfDiag {
+					continue
+				}
+				if q, ok := ProjectToSurface3D(a, p, eps, eps); ok {
+					p = q
+				}
+				gap = math.Min(gap, b.Evaluate(p))
+			}
+		}
+	}
+	return gap
+}
+
+// Volume3D returns an estimate of the volume enclosed by the surface of sdf.
+// Its bounding box is divided into cubic cells with cells cells along the longest
+// side. Cells far from the surface count as fully inside or outside. Cells which
+// the surface crosses are split in 8 and each part counts the fraction below the
+// plane where the field, extrapolated with its gradient, vanishes. This makes
+// estimates from coarse grids much more accurate than counting cell centers
+// inside the solid.
+func Volume3D(sdf SDF3, cells int) float64 {
+	var volume float64
+	integrate3(sdf, cells, func(_ r3.Vec, v float64) { volume += v })
+	return volume
+}
+
+// Centroid3D returns an estimate of the centroid, or center of mass with
+// uniform density, of the solid enclosed by the surface of sdf. Its bounding
+// box is divided into cells as done by Volume3D.
+func Centroid3D(sdf SDF3, cells int) r3.Vec {
+	var volume float64
+	var moment r3.Vec
+	integrate3(sdf, cells, func(p r3.Vec, v float64) {
+		volume += v
+		moment = r3.Add(moment, r3.Scale(v, p))
+	})
+	if volume == 0 {
+		panic("sdf encloses no volume")
+	}
+	return r3.Scale(1/volume, moment)
+}
+
+// integrate3 divides the bounding box of sdf in cubic cells with cells cells
+// along its longest side and calls f with the center of each cell, or subcell
+// for cells crossed by the surface, and the volume inside sdf it holds.
+// Cells entirely outside are skipped. See Volume3D.
+func integrate3(sdf SDF3, cells int, f func(p r3.Vec, volume float64)) {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if cells <= 0 {
+		panic("cells <= 0")
+	}
+	bb := d3.Box(sdf.Bounds())
+	size := bb.Size()
+	cell := d3.Max(size) / float64(cells)
+	n := V3i{int(math.Ceil(size.X / cell)), int(math.Ceil(size.Y / cell)), int(math.Ceil(size.Z / cell))}
+	halfDiag := 0.5 * math.Sqrt(3) * cell
+	eps := 1e-3 * cell
+	volume := cell * cell * cell
+	for i := 0; i < n[0]; i++ {
+		for j := 0; j < n[1]; j++ {
+			for k := 0; k < n[2]; k++ {
+				p := r3.Add(bb.Min, r3.Scale(cell, r3.Vec{X: float64(i) + 0.5, Y: float64(j) + 0.5, Z: float64(k) + 0.5}))
+				d := sdf.Evaluate(p)
+				switch {
+				case d <= -halfDiag:
+					f(p, volume)
+				case d < halfDiag:
+					// Split cells crossed by the surface in 8 so
+					// the curvature of the surface is better captured.
+					grad := EvaluateGradient(sdf, p, eps)
+					for _, o := range subcellOffsets {
+						q := r3.Add(p, r3.Scale(cell, o))
+						if frac := cellFraction(2*sdf.Evaluate(q)/cell, grad); frac > 0 {
+							f(q, frac*volume/8)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// subcellOffsets are the centers of the 8 subcells of a unit cell
+// relative to its center.
+var subcellOffsets = [8]r3.Vec{
+	{X: -0.25, Y: -0.25, Z: -0.25}, {X: 0.25, Y: -0.25, Z: -0.25},
+	{X: -0.25, Y: 0.25, Z: -0.25}, {X: 0.25, Y: 0.25, Z: -0.25},
+	{X: -0.25, Y: -0.25, Z: 0.25}, {X: 0.25, Y: -0.25, Z: 0.25},
+	{X: -0.25, Y: 0.25, Z: 0.25}, {X: 0.25, Y: 0.25, Z: 0.25},
+}
+
+// cellFraction returns the approximate fraction of a cell below the plane
+// where the field vanishes, given the field value d in units of the cell
+// size and its gradient grad at the cell center. The fraction is interpolated linearly across the width
+// of the cell along the gradient.
+func cellFraction(d float64, grad r3.Vec) float64 {
+	gradMag := r3.Norm(grad)
+	if gradMag == 0 || math.IsNaN(gradMag) {
+		if d < 0 {
+			return 1
+		}
+		return 0
+	}
+	n := r3.Scale(1/gradMag, grad)
+	width := math.Abs(n.X) + math.Abs(n.Y) + math.Abs(n.Z)
+	return clamp(0.5-d/(gradMag*width), 0, 1)
+}
+
+// AreaProfile3D returns the cross-sectional area of sdf at samples stations evenly
+// spaced along a coordinate axis (0=X, 1=Y, 2=Z), which is useful to compute section
+// properties. Stations lie at the centers of equal divisions of the bounding
+// box along the axis. Each section is a Slice2D whose area is estimated by
+// counting the centers of a grid with gridRes cells along the longest side of
+// the section's bounding box which lie inside it. The error is of the order of the
+// section perimeter times the cell size, so it decreases linearly with gridRes
+// while the number of evaluations grows with its square.
+func AreaProfile3D(sdf SDF3, axis int, samples int, gridRes int) []float64 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if axis < 0 || axis > 2 {
+		panic("axis must be 0, 1 or 2")
+	}
+	if samples <= 0 || gridRes <= 0 {
+		panic("samples and gridRes must be positive")
+	}
+	bb := sdf.Bounds()
+	var n r3.Vec
+	var lo, hi float64
+	switch axis {
+	case 0:
+		n, lo, hi = r3.Vec{X: 1}, bb.Min.X, bb.Max.X
+	case 1:
+		n, lo, hi = r3.Vec{Y: 1}, bb.Min.Y, bb.Max.Y
+	default:
+		n, lo, hi = r3.Vec{Z: 1}, bb.Min.Z, bb.Max.Z
+	}
+	areas := make([]float64, samples)
+	for i := range areas {
+		station := lo + (hi-lo)*(float64(i)+0.5)/float64(samples)
+		section := Slice2D(sdf, r3.Add(bb.Min, r3.Scale(station-r3.Dot(bb.Min, n), n)), n)
+		sbb := d2.Box(section.Bounds())
+		size := sbb.Size()
+		if size.X == 0 || size.Y == 0 {
+			continue
+		}
+		// Fit the grid to the bounding box with nearly square cells.
+		cell := d2.Max(size) / float64(gridRes)
+		nx, ny := int(math.Ceil(size.X/cell)), int(math.Ceil(size.Y/cell))
+		cx, cy := size.X/float64(nx), size.Y/float64(ny)
+		count := 0
+		for ix := 0; ix < nx; ix++ {
+			for iy := 0; iy < ny; iy++ {
+				p := r2.Add(sbb.Min, r2.Vec{X: cx * (float64(ix) + 0.5), Y: cy * (float64(iy) + 0.5)})
+				if section.Evaluate(p) < 0 {
+					count++
+				}
+			}
+		}
+		areas[i] = float64(count) * cx * cy
+	}
+	return areas
+}
+
+// ProjectToSurface3D moves p onto the surface of s with Newton steps along the
+// field gradient, computed with central differences of step eps which is handled
+// the same as in EvaluateGradient. Returns the last point reached and false if the
+// surface was not reached within tol after a few iterations or the gradient vanished.
+func ProjectToSurface3D(s SDF3, p r3.Vec, eps, tol float64) (r3.Vec, bool) {
+	for i := 0; i < 32; i++ {
+		d := s.Evaluate(p)
+		if math.Abs(d) <= tol {
+			return p, true
+		}
+		g := EvaluateGradient(s, p, eps)
+		g2 := r3.Dot(g, g)
+		if g2 == 0 || math.IsNaN(g2) {
+			return p, false
+		}
+		p = r3.Sub(p, r3.Scale(d/g2, g))
+	}
+	return p, false
+}
+
 // normal2 returns the normal of an SDF3 at a point (doesn't need to be on the surface).
 // Computed by sampling it several times inside a box of side 2*eps centered on p.
 func normal2(s SDF2, p r2.Vec, eps float64) r2.Vec {
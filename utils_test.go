@@ -0,0 +1,67 @@
+package sdf
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmoothHelpers(t *testing.T) {
+	const (
+		k    = 0.5
+		h    = 1e-4 // sampling step
+		xmin = -2.
+		xmax = 2.
+	)
+	for _, test := range []struct {
+		name string
+		f    func(x float64) float64
+		hard func(x float64) float64
+		// maximum absolute difference with the hard counterpart.
+		maxDiff float64
+	}{
+		{name: "SmoothAbs", f: func(x float64) float64 { return SmoothAbs(x, k) }, hard: math.Abs, maxDiff: k},
+		{name: "SmoothMin", f: func(x float64) float64 { return SmoothMin(x, 0.3, k) },
+			hard: func(x float64) float64 { return math.Min(x, 0.3) }, maxDiff: k / 4},
+		{name: "SmoothMax", f: func(x float64) float64 { return SmoothMax(x, 0.3, k) },
+			hard: func(x float64) float64 { return math.Max(x, 0.3) }, maxDiff: k / 4},
+		{name: "SmoothClamp", f: func(x float64) float64 { return SmoothClamp(x, -1, 1, k) },
+			hard: func(x float64) float64 { return clamp(x, -1, 1) }, maxDiff: k / 4},
+		{name: "SmoothStep", f: func(x float64) float64 { return SmoothStep(-1, 1, x) },
+			hard: func(x float64) float64 { return clamp(0.5*x+0.5, 0, 1) }, maxDiff: 0.1},
+	} {
+		prev := test.f(xmin)
+		prevSlope := (test.f(xmin+h) - prev) / h
+		for x := xmin + h; x <= xmax; x += h {
+			v := test.f(x)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				t.Fatalf("%s(%g) not finite", test.name, x)
+			}
+			// Functions are 1-Lipschitz so consecutive samples can not be further than h apart.
+			if math.Abs(v-prev) > h*(1+1e-6) {
+				t.Errorf("%s discontinuous at %g: jump of %g", test.name, x, v-prev)
+			}
+			// First derivative continuity: the slope may only change gradually.
+			slope := (v - prev) / h
+			if math.Abs(slope-prevSlope) > 1e-2 {
+				t.Errorf("%s derivative discontinuous at %g: %g -> %g", test.name, x, prevSlope, slope)
+			}
+			if math.Abs(v-test.hard(x)) > test.maxDiff+1e-12 {
+				t.Errorf("%s(%g)=%g too far from hard counterpart %g", test.name, x, v, test.hard(x))
+			}
+			prev, prevSlope = v, slope
+		}
+	}
+	// Zero smoothing must reproduce the hard functions.
+	for _, x := range []float64{-1.5, -0.2, 0, 0.3, 2} {
+		if SmoothAbs(x, 0) != math.Abs(x) || SmoothMin(x, 0.1, 0) != math.Min(x, 0.1) ||
+			SmoothMax(x, 0.1, 0) != math.Max(x, 0.1) || SmoothClamp(x, -1, 1, 0) != clamp(x, -1, 1) {
+			t.Errorf("zero smoothing does not match hard functions at %g", x)
+		}
+	}
+	// Equal edges give a step.
+	for _, test := range []struct{ x, want float64 }{{x: 0.4}, {x: 0.5, want: 1}, {x: 0.6, want: 1}} {
+		if got := SmoothStep(0.5, 0.5, test.x); got != test.want {
+			t.Errorf("SmoothStep(0.5, 0.5, %g) = %g, want %g", test.x, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package sdf
+
+import (
+	"errors"
+	"math"
+
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// voxelize3 is a blocky approximation of an SDF3 built from cubic voxels.
+type voxelize3 struct {
+	sdf        SDF3
+	size       float64 // voxel side length
+	smoothness float64 // [0,1] blend between blocky and trilinear field
+	diag       float64 // voxel diagonal length
+	bb         r3.Box
+}
+
+// Voxelize3D returns an SDF3 made of cubic voxels of side size aligned to the origin.
+// A voxel is solid if the center of the voxel lies within sdf.
+//
+// smoothness in [0,1] controls how the boundary between solid and empty voxels
+// is interpolated. smoothness=0 is the exact distance to the voxel cubes which
+// gives the sharpest blocky look, but marching cubes can not reproduce the sharp
+// voxel edges and corners so meshes alias harshly with slivers along them.
+// smoothness=1 interpolates the classification of the 8 neighboring voxels
+// trilinearly, which meshes cleanly but rounds off the voxel edges entirely.
+// Values in between keep the blocky look while softening the surface enough for
+// clean meshing. A value around 0.3 is a good tradeoff between blockiness and mesh quality.
+//
+// The field is a bound on the distance to the voxel surface, exact for smoothness=0
+// within a voxel side of the surface.
+func Voxelize3D(sdf SDF3, size, smoothness float64) SDF3 {
+	if sdf == nil {
+		panic("nil SDF3 argument")
+	}
+	if size <= 0 {
+		panic("voxel size <= 0")
+	}
+	if smoothness < 0 || smoothness > 1 {
+		panic("smoothness must be in [0,1]")
+	}
+	// Voxels partially contained in the original bounding box may
+	// be solid, so we enlarge the box by a voxel on every side.
+	bb := d3.Box(sdf.Bounds())
+	bb = bb.Enlarge(d3.Elem(2 * size))
+	return &voxelize3{
+		sdf:        sdf,
+		size:       size,
+		smoothness: smoothness,
+		diag:       math.Sqrt(3) * size,
+		bb:         r3.Box(bb),
+	}
+}
+
+// Evaluate returns the minimum distance to the voxelized SDF3.
+func (s *voxelize3) Evaluate(p r3.Vec) float64 {
+	d := s.sdf.Evaluate(p)
+	// The voxel surface is always within a voxel diagonal of the sdf surface.
+	far := math.Abs(d) - s.diag
+	if far >= s.size {
+		return math.Copysign(far, d)
+	}
+	h := 0.5 * s.size
+	g := r3.Scale(1/s.size, p)
+	own := r3.Vec{X: math.Floor(g.X), Y: math.Floor(g.Y), Z: math.Floor(g.Z)}
+	// Classify the 27 voxels surrounding p: true is solid.
+	var solid [27]bool
+	var centers [27]r3.Vec
+	for i := range solid {
+		offset := r3.Vec{X: float64(i%3 - 1), Y: float64(i/3%3 - 1), Z: float64(i/9 - 1)}
+		centers[i] = r3.Scale(s.size, r3.Add(r3.Add(own, offset), d3.Elem(0.5)))
+		solid[i] = s.sdf.Evaluate(centers[i]) <= 0
+	}
+	// Exact distance to the voxel cubes. Voxels which are not adjacent to the
+	// voxel containing p are farther than a voxel side so we clamp to it.
+	inside := solid[13]
+	blocky := s.size
+	for i := range solid {
+		if solid[i] != inside {
+			blocky = math.Min(blocky, sdfBox3d(r3.Sub(p, centers[i]), d3.Elem(h)))
+		}
+	}
+	if inside {
+		blocky = -blocky
+	}
+	// Trilinear interpolation of the classification of the 8 voxel centers
+	// surrounding p. Scaled so that the field gradient is at most 1.
+	lo := r3.Sub(g, d3.Elem(0.5))
+	lo = r3.Vec{X: math.Floor(lo.X), Y: math.Floor(lo.Y), Z: math.Floor(lo.Z)}
+	t := r3.Sub(r3.Sub(g, d3.Elem(0.5)), lo)
+	lo = r3.Sub(lo, own)
+	var c [8]float64
+	for i := range c {
+		ix := int(lo.X) + i&1 + 1
+		iy := int(lo.Y) + i>>1&1 + 1
+		iz := int(lo.Z) + i>>2&1 + 1
+		c[i] = 1
+		if solid[ix+3*iy+9*iz] {
+			c[i] = -1
+		}
+	}
+	x00 := mix(c[0], c[1], t.X)
+	x10 := mix(c[2], c[3], t.X)
+	x01 := mix(c[4], c[5], t.X)
+	x11 := mix(c[6], c[7], t.X)
+	y0 := mix(x00, x10, t.Y)
+	y1 := mix(x01, x11, t.Y)
+	trilinear := h * mix(y0, y1, t.Z)
+
+	v := mix(blocky, trilinear, s.smoothness)
+	if far > 0 {
+		return math.Copysign(math.Max(math.Abs(v), far), d)
+	}
+	return v
+}
+
+// BoundingBox returns the bounding box of the voxelized SDF3.
+func (s *voxelize3) Bounds() r3.Box {
+	return s.bb
+}
+
+// proxy3 is an SDF3 sampled on a grid and interpolated trilinearly.
+type proxy3 struct {
+	sdf    SDF3
+	res    V3i       // cells along each axis
+	cell   r3.Vec    // cell size
+	values []float64 // field at the (res+1)^3 grid nodes, X varies fastest.
+	bb     r3.Box
+}
+
+// Proxy3D samples sdf once on a grid with res cells along each axis of its
+// bounding box and returns a trilinear interpolation of the samples. It is
+// much faster to evaluate than an expensive SDF3 and meant for previews
+// while the exact SDF3 is used for the final output.
+//
+// The proxy differs from sdf by up to about a cell size near the surface and
+// features smaller than a cell are lost. Outside the sampled grid the distance
+// to the grid is added to the interpolated value. An error is returned if the
+// bounding box of sdf is flat or infinite along any axis.
+func Proxy3D(sdf SDF3, res V3i) (SDF3, error) {
+	if sdf == nil {
+		return nil, errors.New("nil SDF3 argument")
+	}
+	if res[0] < 1 || res[1] < 1 || res[2] < 1 {
+		return nil, errors.New("bad grid dimensions")
+	}
+	bb := d3.Box(sdf.Bounds())
+	size := bb.Size()
+	for _, l := range [3]float64{size.X, size.Y, size.Z} {
+		if !(l > 0) || math.IsInf(l, 0) {
+			return nil, errors.New("cannot sample SDF3 with empty or infinite bounds")
+		}
+	}
+	// Sample one cell beyond the bounding box so the surface
+	// is interpolated from samples on both of its sides.
+	cell := d3.DivElem(bb.Size(), R3FromI(res))
+	bb = d3.Box{Min: r3.Sub(bb.Min, cell), Max: r3.Add(bb.Max, cell)}
+	res = res.AddScalar(2)
+	s := proxy3{
+		sdf:    sdf,
+		res:    res,
+		cell:   cell,
+		values: make([]float64, (res[0]+1)*(res[1]+1)*(res[2]+1)),
+		bb:     r3.Box(bb),
+	}
+	for k := 0; k <= res[2]; k++ {
+		for j := 0; j <= res[1]; j++ {
+			for i := 0; i <= res[0]; i++ {
+				p := r3.Add(bb.Min, d3.MulElem(cell, r3.Vec{X: float64(i), Y: float64(j), Z: float64(k)}))
+				s.values[s.index(i, j, k)] = sdf.Evaluate(p)
+			}
+		}
+	}
+	return &s, nil
+}
+
+func (s *proxy3) index(i, j, k int) int {
+	return i + (s.res[0]+1)*(j+(s.res[1]+1)*k)
+}
+
+// Evaluate returns the interpolated distance to the proxy SDF3.
+func (s *proxy3) Evaluate(p r3.Vec) float64 {
+	q := d3.Clamp(p, s.bb.Min, s.bb.Max)
+	outside := r3.Norm(r3.Sub(p, q))
+	g := d3.DivElem(r3.Sub(q, s.bb.Min), s.cell)
+	i := clampInt(int(g.X), 0, s.res[0]-1)
+	j := clampInt(int(g.Y), 0, s.res[1]-1)
+	k := clampInt(int(g.Z), 0, s.res[2]-1)
+	t := r3.Sub(g, r3.Vec{X: float64(i), Y: float64(j), Z: float64(k)})
+	x00 := mix(s.values[s.index(i, j, k)], s.values[s.index(i+1, j, k)], t.X)
+	x10 := mix(s.values[s.index(i, j+1, k)], s.values[s.index(i+1, j+1, k)], t.X)
+	x01 := mix(s.values[s.index(i, j, k+1)], s.values[s.index(i+1, j, k+1)], t.X)
+	x11 := mix(s.values[s.index(i, j+1, k+1)], s.values[s.index(i+1, j+1, k+1)], t.X)
+	return mix(mix(x00, x10, t.Y), mix(x01, x11, t.Y), t.Z) + outside
+}
+
+// Bounds returns the bounding box of the sampled grid.
+func (s *proxy3) Bounds() r3.Box {
+	return s.bb
+}
@@ -0,0 +1,458 @@
+package sdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/soypat/sdf/internal/d2"
+	"github.com/soypat/sdf/internal/d3"
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// SDF3Parent is an SDF3 composed of other SDF3s, such as a union or a transform.
+// SDF3s which do not implement SDF3Parent are considered leaves of the SDF3 tree.
+type SDF3Parent interface {
+	SDF3
+	// Children returns the SDF3s the parent is built from.
+	Children() []SDF3
+}
+
+// Walk3D walks the SDF3 tree rooted at s depth first, calling fn for
+// every node with its depth in the tree (s has depth 0).
+// If fn returns false the children of node are not visited.
+func Walk3D(s SDF3, fn func(node SDF3, depth int) bool) {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	walk3(s, 0, fn)
+}
+
+func walk3(s SDF3, depth int, fn func(node SDF3, depth int) bool) {
+	if !fn(s, depth) {
+		return
+	}
+	if parent, ok := s.(SDF3Parent); ok {
+		for _, child := range parent.Children() {
+			walk3(child, depth+1, fn)
+		}
+	}
+}
+
+// TreeStats3D returns the number of nodes and leaves of the SDF3 tree rooted
+// at s and its maximum depth, where s has depth 0. Nodes shared by several
+// parents are counted once per parent since each is evaluated separately.
+// Combined with the mesh resolution it gives a rough idea of how costly rendering is.
+func TreeStats3D(s SDF3) (nodeCount, leafCount, maxDepth int) {
+	Walk3D(s, func(node SDF3, depth int) bool {
+		nodeCount++
+		if _, ok := node.(SDF3Parent); !ok {
+			leafCount++
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return true
+	})
+	return nodeCount, leafCount, maxDepth
+}
+
+// SetBlend3D sets the minimum function of every union and the maximum function
+// of every difference and intersection in the SDF3 tree rooted at s, which is
+// how a whole model is made smooth at once. Nodes are modified in place.
+// A nil min or max leaves the respective nodes unchanged. Symmetrize3D nodes
+// are not modified since their SetMax replaces averaging the copies.
+//
+// Bounding boxes are not enlarged, so material a blending min adds at the seams
+// of unions may lie outside them and be clipped by renderers. SmoothBlend3D and
+// SmoothUnion3D take the blend radius and enlarge the bounding boxes to hold it.
+func SetBlend3D(s SDF3, min MinFunc, max MaxFunc) {
+	Walk3D(s, func(node SDF3, _ int) bool {
+		if _, ok := node.(*symmetrize3); ok {
+			return true
+		}
+		if u, ok := node.(SDF3Union); ok && min != nil {
+			u.SetMin(min)
+		}
+		if d, ok := node.(SDF3Diff); ok && max != nil {
+			d.SetMax(max)
+		}
+		return true
+	})
+}
+
+// SmoothBlend3D returns a copy of the SDF3 tree s where every union, difference
+// and intersection is blended with radius k as by SmoothUnion3D, SmoothDifference3D
+// and SmoothIntersect3D, which is how a whole model is made smooth at once.
+// Unlike SetBlend3D parents are rebuilt so bounding boxes are enlarged to hold the
+// blend all the way up the tree, and s is left unmodified. Arrays, rotated unions
+// and SDF3Parent implementations from outside this package are left sharp.
+func SmoothBlend3D(s SDF3, k float64) SDF3 {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	if !(k > 0) || math.IsInf(k, 1) {
+		panic("k must be finite and > 0")
+	}
+	parent, ok := s.(sdf3Rebuilder)
+	if !ok {
+		return s
+	}
+	children := parent.Children()
+	blended := make([]SDF3, len(children))
+	for i, child := range children {
+		blended[i] = SmoothBlend3D(child, k)
+	}
+	switch t := s.(type) {
+	case *union3:
+		u := SmoothUnion3D(k, blended...).(*union3)
+		u.index = t.index
+		return u
+	case *diff3:
+		return SmoothDifference3D(k, blended[0], blended[1])
+	case *intersection3:
+		return SmoothIntersect3D(k, blended[0], blended[1])
+	}
+	return parent.withChildren(blended)
+}
+
+// LeafResult is the distance to a leaf of an SDF3 tree at a point.
+type LeafResult struct {
+	// Path is the slash separated list of nodes from the root to the leaf.
+	// Nodes with more than one child are suffixed with the child index, i.e. "union3[1]".
+	Path string
+	// Type is the Go type of the leaf.
+	Type string
+	// Distance is the distance returned by the leaf at the point
+	// mapped into the leaf's frame of reference.
+	Distance float64
+}
+
+// EvaluateBreakdown evaluates every leaf of the SDF3 tree at p and returns
+// the results in depth first order. It is meant for debugging unexpected
+// CSG results and is much slower than Evaluate.
+//
+// The point is mapped through distance preserving transforms, elongations and
+// rotated copies so that each leaf is evaluated where the root would evaluate it.
+// Array and rotated unions are evaluated at their first instance and scaling
+// is not applied to leaf distances.
+func EvaluateBreakdown(s SDF3, p r3.Vec) []LeafResult {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	var results []LeafResult
+	breakdown3(s, p, "", &results)
+	return results
+}
+
+func breakdown3(s SDF3, p r3.Vec, path string, results *[]LeafResult) {
+	name := nodeName(s)
+	parent, ok := s.(SDF3Parent)
+	if !ok {
+		*results = append(*results, LeafResult{
+			Path:     path + name,
+			Type:     fmt.Sprintf("%T", s),
+			Distance: s.Evaluate(p),
+		})
+		return
+	}
+	children := parent.Children()
+	q := localPoint3(s, p)
+	for i, child := range children {
+		prefix := path + name + "/"
+		if len(children) > 1 {
+			prefix = path + name + "[" + strconv.Itoa(i) + "]/"
+		}
+		breakdown3(child, q, prefix, results)
+	}
+}
+
+// localPoint3 maps p into the frame of reference of the children of s.
+func localPoint3(s SDF3, p r3.Vec) r3.Vec {
+	switch s := s.(type) {
+	case *transform3:
+		return s.inverse.MulPosition(p)
+	case *scaleUniform3:
+		return r3.Scale(s.invK, p)
+	case *elongate3:
+		return p.Sub(d3.Clamp(p, s.hn, s.hp))
+	case *rotateCopy3:
+		p2 := r2.Vec{X: p.X, Y: p.Y}
+		p2 = d2.PolarToXY(r2.Norm(p2), sawTooth(math.Atan2(p2.Y, p2.X), s.theta))
+		return r3.Vec{X: p2.X, Y: p2.Y, Z: p.Z}
+	}
+	return p
+}
+
+// nodeName returns the type name of s without package or pointer qualifiers.
+func nodeName(s SDF3) string {
+	name := strings.TrimLeft(fmt.Sprintf("%T", s), "*")
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// FlattenTransforms3D returns an SDF3 equivalent to s where chains of nested
+// transforms and uniform scalings are collapsed into at most a single uniform
+// scaling of a single transform with the combined matrix. This reduces
+// the cost of Evaluate for deeply nested transforms. Parents are rebuilt
+// when their children change so s is left unmodified.
+func FlattenTransforms3D(s SDF3) SDF3 {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	switch t := s.(type) {
+	case *transform3:
+		child := FlattenTransforms3D(t.sdf)
+		switch inner := child.(type) {
+		case *transform3:
+			return Transform3D(inner.sdf, t.matrix.Mul(inner.matrix))
+		case *scaleUniform3:
+			// Move the scaling outwards so that it does not split transform chains.
+			// Transforming a scaled SDF3 by M is equivalent to scaling the SDF3
+			// transformed by S⁻¹·M·S where S is the scaling matrix.
+			k := r3.Vec{X: inner.k, Y: inner.k, Z: inner.k}
+			m := Scale3D(d3.DivElem(d3.Elem(1), k)).Mul(t.matrix).Mul(Scale3D(k))
+			return ScaleUniform3D(FlattenTransforms3D(Transform3D(inner.sdf, m)), inner.k)
+		}
+		if child != t.sdf {
+			return Transform3D(child, t.matrix)
+		}
+		return s
+	case *scaleUniform3:
+		child := FlattenTransforms3D(t.sdf)
+		if inner, ok := child.(*scaleUniform3); ok {
+			return ScaleUniform3D(inner.sdf, t.k*inner.k)
+		}
+		if child != t.sdf {
+			return ScaleUniform3D(child, t.k)
+		}
+		return s
+	}
+	parent, ok := s.(sdf3Rebuilder)
+	if !ok {
+		return s
+	}
+	children := parent.Children()
+	flat := make([]SDF3, len(children))
+	changed := false
+	for i, child := range children {
+		flat[i] = FlattenTransforms3D(child)
+		changed = changed || flat[i] != child
+	}
+	if !changed {
+		return s
+	}
+	return parent.withChildren(flat)
+}
+
+// sdf3Rebuilder is an SDF3Parent which can be rebuilt with different children.
+type sdf3Rebuilder interface {
+	SDF3Parent
+	// withChildren returns a new parent with the same parameters and the
+	// children replaced. The number of children must not change.
+	withChildren(children []SDF3) SDF3
+}
+
+// MapLeaves3D returns a copy of the SDF3 tree s where every leaf is replaced
+// by fn(leaf). Composite nodes are rebuilt with their original parameters
+// so bounding boxes are updated and s is left unmodified. This is useful for
+// global operations like offsetting every primitive for tolerance compensation.
+// Leaves of SDF3Parent implementations from outside this package can not
+// be rebuilt so these are passed to fn as a whole.
+func MapLeaves3D(s SDF3, fn func(leaf SDF3) SDF3) SDF3 {
+	if s == nil {
+		panic("nil SDF3 argument")
+	}
+	parent, ok := s.(sdf3Rebuilder)
+	if !ok {
+		return fn(s)
+	}
+	children := parent.Children()
+	mapped := make([]SDF3, len(children))
+	for i, child := range children {
+		mapped[i] = MapLeaves3D(child, fn)
+	}
+	return parent.withChildren(mapped)
+}
+
+// Children returns the SDF3s of the union.
+func (s *union3) Children() []SDF3 { return s.sdf }
+
+// Children returns the minuend and subtrahend of the difference.
+func (s *diff3) Children() []SDF3 { return []SDF3{s.s0, s.s1} }
+
+// Children returns the SDF3s of the intersection.
+func (s *intersection3) Children() []SDF3 { return []SDF3{s.s0, s.s1} }
+
+// Children returns the transformed SDF3.
+func (s *transform3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the scaled SDF3.
+func (s *scaleUniform3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the elongated SDF3.
+func (s *elongate3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the cut SDF3.
+func (s *cut3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the trimmed SDF3.
+func (s *trim3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the arrayed SDF3.
+func (s *array3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the rotated SDF3.
+func (s *rotateUnion) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the rotated SDF3.
+func (s *rotateCopy3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the symmetrized SDF3.
+func (s *symmetrize3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the mirrored SDF3.
+func (s *mirror3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the offset SDF3.
+func (s *offset3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the shelled SDF3.
+func (s *shell3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the rounded SDF3.
+func (s *variableRound3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the carved SDF3.
+func (s *relief3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the bevelled SDF3.
+func (s *bevelEdge3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the solidified SDF3.
+func (s *solidify3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the drafted SDF3.
+func (s *draft3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the SDF3 with the sampled bounding box.
+func (s *autoBox3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the filleted SDF3.
+func (s *autoFillet3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+// Children returns the voxelized SDF3.
+func (s *voxelize3) Children() []SDF3 { return []SDF3{s.sdf} }
+
+func (s *union3) withChildren(c []SDF3) SDF3 {
+	u := Union3D(c...).(*union3)
+	u.min, u.blend, u.index, u.pad = s.min, s.blend, s.index, s.pad
+	if s.cull != exactAll(s.sdf...) {
+		// Culling was set explicitly.
+		u.cull = s.cull
+	}
+	u.bb = r3.Box(d3.Box(u.bb).Enlarge(d3.Elem(2 * s.pad)))
+	return u
+}
+
+func (s *diff3) withChildren(c []SDF3) SDF3 {
+	d := Difference3D(c[0], c[1]).(*diff3)
+	d.max, d.blend = s.max, s.blend
+	return d
+}
+
+func (s *intersection3) withChildren(c []SDF3) SDF3 {
+	i := Intersect3D(c[0], c[1])
+	i.SetMax(s.max)
+	return i
+}
+
+func (s *transform3) withChildren(c []SDF3) SDF3 {
+	return Transform3D(c[0], s.matrix)
+}
+
+func (s *scaleUniform3) withChildren(c []SDF3) SDF3 {
+	return ScaleUniform3D(c[0], s.k)
+}
+
+func (s *elongate3) withChildren(c []SDF3) SDF3 {
+	return Elongate3D(c[0], r3.Sub(s.hp, s.hn))
+}
+
+func (s *cut3) withChildren(c []SDF3) SDF3 {
+	return Cut3D(c[0], s.a, r3.Scale(-1, s.n))
+}
+
+func (s *trim3) withChildren(c []SDF3) SDF3 {
+	return Trim3D(c[0], s.planes)
+}
+
+func (s *array3) withChildren(c []SDF3) SDF3 {
+	a := Array3D(c[0], s.num, s.step).(*array3)
+	a.min, a.near, a.blend = s.min, s.near, s.blend
+	return a
+}
+
+func (s *rotateUnion) withChildren(c []SDF3) SDF3 {
+	r := RotateUnion3D(c[0], s.num, s.step.Inverse()).(*rotateUnion)
+	r.min, r.blend = s.min, s.blend
+	return r
+}
+
+func (s *rotateCopy3) withChildren(c []SDF3) SDF3 {
+	return RotateCopy3D(c[0], int(math.Round(tau/s.theta)))
+}
+
+func (s *symmetrize3) withChildren(c []SDF3) SDF3 {
+	sym := Symmetrize3D(c[0], len(s.rot))
+	sym.SetMax(s.max)
+	return sym
+}
+
+func (s *mirror3) withChildren(c []SDF3) SDF3 {
+	return Mirror3D(c[0], s.point, s.normal)
+}
+
+func (s *offset3) withChildren(c []SDF3) SDF3 {
+	return Offset3D(c[0], s.distance)
+}
+
+func (s *shell3) withChildren(c []SDF3) SDF3 {
+	return Shell3D(c[0], 2*s.delta)
+}
+
+func (s *variableRound3) withChildren(c []SDF3) SDF3 {
+	return VariableRound3D(c[0], s.radius)
+}
+
+func (s *relief3) withChildren(c []SDF3) SDF3 {
+	return ImageRelief3D(c[0], s.img, s.plane, s.depth)
+}
+
+func (s *bevelEdge3) withChildren(c []SDF3) SDF3 {
+	return BevelEdge3D(c[0], s.p1, s.p2, s.size)
+}
+
+func (s *solidify3) withChildren(c []SDF3) SDF3 {
+	return Solidify3D(c[0], s.direction, 2*s.delta)
+}
+
+func (s *draft3) withChildren(c []SDF3) SDF3 {
+	return AddDraft3D(c[0], s.angle, s.partingZ)
+}
+
+func (s *autoBox3) withChildren(c []SDF3) SDF3 {
+	return AutoBox3D(c[0], s.search, s.resolution)
+}
+
+func (s *autoFillet3) withChildren(c []SDF3) SDF3 {
+	return AutoFillet3D(c[0], s.radius, s.angle, s.resolution)
+}
+
+func (s *voxelize3) withChildren(c []SDF3) SDF3 {
+	return Voxelize3D(c[0], s.size, s.smoothness)
+}
@@ -0,0 +1,234 @@
+package sdf_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soypat/sdf"
+	"github.com/soypat/sdf/form3/must3"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+func TestEvaluateBreakdown(t *testing.T) {
+	sphere := must3.Sphere(1)
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	moved := sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: 3}))
+	s := sdf.Difference3D(sdf.Union3D(sphere, moved), box)
+
+	var nodes, maxDepth int
+	sdf.Walk3D(s, func(node sdf.SDF3, depth int) bool {
+		nodes++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return true
+	})
+	if nodes != 6 || maxDepth != 3 {
+		t.Errorf("got %d nodes with max depth %d, want 6 nodes with depth 3", nodes, maxDepth)
+	}
+
+	p := r3.Vec{X: 3, Y: 0.5}
+	got := sdf.EvaluateBreakdown(s, p)
+	want := []sdf.LeafResult{
+		{Path: "diff3[0]/union3[0]/sphere", Type: "*must3.sphere", Distance: math.Hypot(3, 0.5) - 1},
+		{Path: "diff3[0]/union3[1]/transform3/sphere", Type: "*must3.sphere", Distance: -0.5},
+		{Path: "diff3[1]/box", Type: "*must3.box", Distance: 2.5},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d leaves, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Path != want[i].Path || got[i].Type != want[i].Type || math.Abs(got[i].Distance-want[i].Distance) > 1e-9 {
+			t.Errorf("leaf %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	// Leaves are not visited when fn returns false.
+	nodes = 0
+	sdf.Walk3D(s, func(node sdf.SDF3, depth int) bool {
+		nodes++
+		return depth < 1
+	})
+	if nodes != 3 {
+		t.Errorf("got %d nodes visited with pruning, want 3", nodes)
+	}
+}
+
+func TestTreeStats3D(t *testing.T) {
+	sphere := must3.Sphere(1)
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	moved := sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: 3}))
+	// difference(union(sphere, transform(sphere)), box)
+	s := sdf.Difference3D(sdf.Union3D(sphere, moved), box)
+	nodes, leaves, depth := sdf.TreeStats3D(s)
+	if nodes != 6 || leaves != 3 || depth != 3 {
+		t.Errorf("got %d nodes, %d leaves and depth %d, want 6, 3 and 3", nodes, leaves, depth)
+	}
+	if nodes, leaves, depth := sdf.TreeStats3D(sphere); nodes != 1 || leaves != 1 || depth != 0 {
+		t.Errorf("leaf: got %d nodes, %d leaves and depth %d", nodes, leaves, depth)
+	}
+}
+
+func TestSetBlend3D(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	moved := sdf.Transform3D(box, sdf.Translate3D(r3.Vec{X: 1}))
+	hole := must3.Sphere(0.3)
+	part := sdf.Difference3D(sdf.Union3D(box, moved), sdf.Transform3D(hole, sdf.Translate3D(r3.Vec{X: 0.5, Y: 0.5})))
+	// The inner corner where the boxes meet and the rim of the hole.
+	corner, rim := r3.Vec{X: 0.5, Y: 0.5, Z: 0.5}, r3.Vec{X: 0.5, Y: 0.5, Z: 0.3}
+	sharpCorner, sharpRim := part.Evaluate(corner), part.Evaluate(rim)
+	const k = 0.2
+	sdf.SetBlend3D(part, func(a, b float64) float64 { return sdf.SmoothMin(a, b, k) },
+		func(a, b float64) float64 { return sdf.SmoothMax(a, b, k) })
+	if got := part.Evaluate(corner); got >= sharpCorner {
+		t.Errorf("got %g at union, want blended below %g", got, sharpCorner)
+	}
+	if got := part.Evaluate(rim); got <= sharpRim {
+		t.Errorf("got %g at difference, want blended above %g", got, sharpRim)
+	}
+}
+
+func TestSmoothBlend3D(t *testing.T) {
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	moved := sdf.Transform3D(box, sdf.Translate3D(r3.Vec{X: 1}))
+	part := sdf.Transform3D(sdf.Union3D(box, moved), sdf.Translate3D(r3.Vec{Y: 2}))
+	corner := r3.Vec{X: 0.5, Y: 2.5, Z: 0.5}
+	sharp := part.Evaluate(corner)
+	blended := sdf.SmoothBlend3D(part, 0.4)
+	if got := part.Evaluate(corner); got != sharp {
+		t.Errorf("original changed to %g at union, want %g", got, sharp)
+	}
+	if got := blended.Evaluate(corner); got >= sharp {
+		t.Errorf("got %g at union, want blended below %g", got, sharp)
+	}
+	bb := blended.Bounds()
+	for x := -1.; x <= 2; x += 0.05 {
+		for z := -1.; z <= 1; z += 0.05 {
+			p := r3.Vec{X: x, Y: 2.5, Z: z}
+			inside := p.X >= bb.Min.X && p.X <= bb.Max.X && p.Z >= bb.Min.Z && p.Z <= bb.Max.Z
+			if blended.Evaluate(p) < 0 && !inside {
+				t.Fatalf("surface at %v lies outside bounds %v", p, bb)
+			}
+		}
+	}
+}
+
+func TestFlattenTransforms(t *testing.T) {
+	s := nestedTransforms(10)
+	flat := sdf.FlattenTransforms3D(s)
+	var depth int
+	sdf.Walk3D(flat, func(node sdf.SDF3, d int) bool {
+		if d > depth {
+			depth = d
+		}
+		return true
+	})
+	if depth != 3 {
+		t.Errorf("flattened tree depth %d, want 3", depth)
+	}
+	for x := -2.; x < 2; x += 0.13 {
+		p := r3.Vec{X: x, Y: 0.5 - x, Z: 0.3 * x}
+		if got, want := flat.Evaluate(p), s.Evaluate(p); math.Abs(got-want) > 1e-9 {
+			t.Errorf("flattened distance at %v is %g, want %g", p, got, want)
+		}
+	}
+}
+
+func BenchmarkFlattenTransforms(b *testing.B) {
+	s := nestedTransforms(10)
+	p := r3.Vec{X: 0.3, Y: 0.2, Z: 0.1}
+	b.Run("nested", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s.Evaluate(p)
+		}
+	})
+	flat := sdf.FlattenTransforms3D(s)
+	b.Run("flattened", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			flat.Evaluate(p)
+		}
+	})
+}
+
+// nestedTransforms returns a union of a sphere and a box each
+// nested in depth alternating translations and rotations.
+func nestedTransforms(depth int) sdf.SDF3 {
+	var s0, s1 sdf.SDF3 = must3.Sphere(0.5), must3.Box(r3.Vec{X: 1, Y: 0.5, Z: 0.2}, 0)
+	for i := 0; i < depth; i++ {
+		m := sdf.Translate3D(r3.Vec{X: 0.05 * float64(i), Z: -0.02})
+		if i%2 == 1 {
+			m = sdf.Rotate3D(r3.Vec{X: 1, Y: 1}, 0.1*float64(i))
+		}
+		s0 = sdf.Transform3D(s0, m)
+		s1 = sdf.ScaleUniform3D(sdf.Transform3D(s1, m), 1.01)
+	}
+	return sdf.Union3D(s0, s1)
+}
+
+func TestMapLeaves(t *testing.T) {
+	sphere := must3.Sphere(1)
+	box := must3.Box(r3.Vec{X: 1, Y: 1, Z: 1}, 0)
+	s := sdf.Difference3D(sdf.Transform3D(sphere, sdf.Translate3D(r3.Vec{X: 1})), box)
+	const offset = 0.1
+	var leaves int
+	grown := sdf.MapLeaves3D(s, func(leaf sdf.SDF3) sdf.SDF3 {
+		leaves++
+		return sdf.Offset3D(leaf, offset)
+	})
+	if leaves != 2 {
+		t.Errorf("got %d leaves mapped, want 2", leaves)
+	}
+	want := sdf.Difference3D(sdf.Transform3D(sdf.Offset3D(sphere, offset), sdf.Translate3D(r3.Vec{X: 1})), sdf.Offset3D(box, offset))
+	for x := -2.; x < 3; x += 0.17 {
+		p := r3.Vec{X: x, Y: 0.3, Z: -0.2}
+		if got, want := grown.Evaluate(p), want.Evaluate(p); math.Abs(got-want) > 1e-12 {
+			t.Errorf("mapped distance at %v: got %g, want %g", p, got, want)
+		}
+	}
+	if grown.Bounds() != want.Bounds() {
+		t.Errorf("mapped bounds %v not updated, want %v", grown.Bounds(), want.Bounds())
+	}
+	if s.Bounds() == grown.Bounds() || s.Evaluate(r3.Vec{X: 2.05}) <= 0 {
+		t.Error("original tree was modified")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	cone := must3.Cone(2, 1, 0.5, 0.2)
+	box := must3.Box(r3.Vec{X: 1, Y: 2, Z: 3}, 0.1)
+	s := sdf.Difference3D(sdf.Union3D(sdf.ScaleUniform3D(cone, 2), sdf.Shell3D(must3.Cylinder(2, 1, 0.1), 0.2)), sdf.Array3D(box, sdf.V3i{2, 1, 3}, r3.Vec{X: 1}))
+	kinds := map[string]map[string]float64{}
+	sdf.Walk3D(s, func(node sdf.SDF3, depth int) bool {
+		d, ok := node.(sdf.SDF3Describer)
+		if !ok {
+			t.Errorf("%T does not implement SDF3Describer", node)
+			return true
+		}
+		kind, params := d.Describe()
+		kinds[kind] = params
+		return true
+	})
+	for kind, want := range map[string]map[string]float64{
+		"cone":          {"height": 2, "r0": 1, "r1": 0.5, "round": 0.2},
+		"box":           {"size_x": 1, "size_y": 2, "size_z": 3, "round": 0.1},
+		"cylinder":      {"height": 2, "radius": 1, "round": 0.1},
+		"scale_uniform": {"k": 2},
+		"shell":         {"thickness": 0.2},
+		"array":         {"num_x": 2, "num_y": 1, "num_z": 3, "step_x": 1, "step_y": 0, "step_z": 0},
+		"union":         {},
+		"difference":    {},
+	} {
+		got, ok := kinds[kind]
+		if !ok {
+			t.Errorf("kind %q not found", kind)
+			continue
+		}
+		if len(got) != len(want) {
+			t.Errorf("%s: got params %v, want %v", kind, got, want)
+		}
+		for name, v := range want {
+			if math.Abs(got[name]-v) > 1e-12 {
+				t.Errorf("%s: got %s=%g, want %g", kind, name, got[name], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package sdf
+
+import (
+	"errors"
+	"math"
+
+	"gonum.org/v1/gonum/spatial/r2"
+	"gonum.org/v1/gonum/spatial/r3"
+)
+
+// cylindricalText3 is an SDF2 wrapped around the Z axis.
+type cylindricalText3 struct {
+	text    SDF2
+	radius  float64
+	depth   float64
+	engrave bool
+	// Radial extents of the wrapped text.
+	r0, r1 float64
+	bb     r3.Box
+}
+
+// CylindricalText3D wraps the text profile around a cylinder of radius cylinderRadius
+// centered on the Z axis, as is done to label rings and cups. This package does not render
+// fonts, so text is an SDF2 of the laid out text, scaled to its final size, such as one
+// built from a font's glyph outlines with form2.Polygon. The X axis of text runs around the
+// cylinder counter-clockwise starting from the +X axis and its Y axis runs along Z.
+//
+// The returned SDF3 is the text with thickness depth. If engrave is false it lies outside
+// the cylinder surface and is to be added to the cylinder, otherwise it lies inside and is
+// to be subtracted. Bending makes the distance field approximate: distances around the
+// cylinder are measured along arcs at its radius and scaled down closer to the axis so
+// they are not overestimated by the bend. Fonts are laid out with form3.CylindricalText,
+// which is kept out of this package so it does not depend on form2's polygons.
+func CylindricalText3D(text SDF2, cylinderRadius, depth float64, engrave bool) (SDF3, error) {
+	if text == nil {
+		return nil, errors.New("nil SDF2 argument")
+	}
+	if cylinderRadius <= 0 || depth <= 0 {
+		return nil, errors.New("cylinder radius and depth must be positive")
+	}
+	if engrave && depth >= cylinderRadius {
+		return nil, errors.New("engraving depth must be smaller than cylinder radius")
+	}
+	bb := text.Bounds()
+	if bb.Max.X-bb.Min.X >= tau*cylinderRadius {
+		return nil, errors.New("text longer than cylinder circumference")
+	}
+	if bb.Min.X < -pi*cylinderRadius || bb.Max.X > pi*cylinderRadius {
+		return nil, errors.New("text must lie within half a circumference of the origin")
+	}
+	s := cylindricalText3{
+		text:    text,
+		radius:  cylinderRadius,
+		depth:   depth,
+		engrave: engrave,
+		r0:      cylinderRadius,
+		r1:      cylinderRadius + depth,
+	}
+	if engrave {
+		s.r0, s.r1 = cylinderRadius-depth, cylinderRadius
+	}
+	// Bound the arc spanned by the text.
+	theta0, theta1 := bb.Min.X/cylinderRadius, bb.Max.X/cylinderRadius
+	xy := []r2.Vec{
+		{X: s.r0 * math.Cos(theta0), Y: s.r0 * math.Sin(theta0)},
+		{X: s.r1 * math.Cos(theta0), Y: s.r1 * math.Sin(theta0)},
+		{X: s.r0 * math.Cos(theta1), Y: s.r0 * math.Sin(theta1)},
+		{X: s.r1 * math.Cos(theta1), Y: s.r1 * math.Sin(theta1)},
+	}
+	// Include the extremes of the outer arc along each axis.
+	for k := -2; k <= 2; k++ {
+		if theta := float64(k) * pi / 2; theta > theta0 && theta < theta1 {
+			xy = append(xy, r2.Vec{X: s.r1 * math.Cos(theta), Y: s.r1 * math.Sin(theta)})
+		}
+	}
+	s.bb = r3.Box{Min: r3.Vec{X: xy[0].X, Y: xy[0].Y, Z: bb.Min.Y}, Max: r3.Vec{X: xy[0].X, Y: xy[0].Y, Z: bb.Max.Y}}
+	for _, v := range xy[1:] {
+		s.bb.Min.X, s.bb.Max.X = math.Min(s.bb.Min.X, v.X), math.Max(s.bb.Max.X, v.X)
+		s.bb.Min.Y, s.bb.Max.Y = math.Min(s.bb.Min.Y, v.Y), math.Max(s.bb.Max.Y, v.Y)
+	}
+	return &s, nil
+}
+
+// Evaluate returns the approximate minimum distance to the wrapped text.
+func (s *cylindricalText3) Evaluate(p r3.Vec) float64 {
+	r := math.Hypot(p.X, p.Y)
+	q := r2.Vec{X: s.radius * math.Atan2(p.Y, p.X), Y: p.Z}
+	radial := math.Max(s.r0-r, r-s.r1)
+	// Arcs closer to the axis than the reference radius are shorter
+	// than their unwrapped length, scale so distance is not overestimated.
+	return math.Max(math.Min(1, r/s.radius)*s.text.Evaluate(q), radial)
+}
+
+// Bounds returns the bounding box of the wrapped text.
+func (s *cylindricalText3) Bounds() r3.Box {
+	return s.bb
+}